@@ -0,0 +1,88 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type ackKey struct{}
+
+// acker counts acknowledgements of an event.
+type acker struct {
+	lock sync.Mutex
+	left int
+	done func(error)
+}
+
+// WithAck returns a context for emitting an event that needs n acknowledgements, e.g. one by each observer,
+// or one by a single critical observer. Once Ack was called n times with the context or a context derived from it,
+// done is called with nil. If Nack is called first, done is called with its error. done is called at most once.
+// Sources like the broker bridges use this to acknowledge messages only after they were processed.
+func WithAck(ctx context.Context, n int, done func(error)) context.Context {
+	if n < 1 {
+		n = 1
+	}
+
+	return context.WithValue(ctx, ackKey{}, &acker{left: n, done: done})
+}
+
+// Ack acknowledges the event delivered with ctx. It does nothing if the event doesn't need acknowledgement.
+func Ack(ctx context.Context) {
+	ackWith(ctx, nil)
+}
+
+// Nack rejects the event delivered with ctx, e.g. so the message carrying it is redelivered.
+// It does nothing if the event doesn't need acknowledgement.
+func Nack(ctx context.Context, err error) {
+	if err == nil {
+		err = ErrNacked
+	}
+
+	ackWith(ctx, err)
+}
+
+// ErrNacked is passed to the done function of WithAck if Nack was called with a nil error.
+var ErrNacked = errors.New("voyeur: nacked")
+
+func ackWith(ctx context.Context, err error) {
+	a, ok := ctx.Value(ackKey{}).(*acker)
+	if !ok {
+		return
+	}
+
+	a.lock.Lock()
+	if a.left == 0 {
+		a.lock.Unlock()
+		return
+	}
+
+	if err != nil {
+		a.left = 0
+	} else {
+		a.left--
+	}
+	fire := a.left == 0
+	a.lock.Unlock()
+
+	if fire {
+		a.done(err)
+	}
+}
@@ -0,0 +1,48 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+)
+
+func ExampleWithAck() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	// an observer that processes events later
+	var pending []context.Context
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		pending = append(pending, ctx)
+	}))
+
+	ctx = WithAck(ctx, 1, func(err error) {
+		fmt.Println("processed, error:", err)
+	})
+	em.Emit(ctx, stringEvent("job"))
+
+	fmt.Println("emitted")
+	for _, ctx := range pending {
+		Ack(ctx)
+	}
+
+	// Output:
+	// emitted
+	// processed, error: <nil>
+}
@@ -0,0 +1,126 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+)
+
+// Snapshot is the state of an aggregating filter, see Aggregate.
+type Snapshot[S any] struct {
+	State S
+
+	// Periodic is set on snapshots emitted because the interval passed, see WithSnapshots.
+	Periodic bool
+}
+
+func (Snapshot[S]) EventType() string {
+	return "snapshot"
+}
+
+type aggregate[S any] struct {
+	fn    func(S, Event) S
+	em    Emitter
+	o     *observable
+	state S
+
+	// lock makes sure snapshots are emitted in the order the state changed
+	lock  sync.Mutex
+	ended bool
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// Aggregate returns a Filter that folds the observed events into a state using fn, starting with init, and
+// emits a Snapshot after each change. With WithSnapshots, the state is also emitted periodically, so late
+// observers and dashboards get the full state without waiting for the next change. The state is passed by
+// value, so if S holds a map or slice, fn should copy it instead of changing it in place. The Filter is a Closer;
+// closing it before End stops the periodic snapshots.
+func Aggregate[S any](init S, fn func(S, Event) S, opts ...Option) Filter {
+	em, o := Pair(opts...)
+	a := &aggregate[S]{
+		fn:    fn,
+		em:    em,
+		o:     o.(*observable),
+		state: init,
+		stop:  make(chan struct{}),
+	}
+
+	if every := a.o.cfg.snapshots; every > 0 {
+		go a.tick(a.o.cfg.clock.NewTicker(every))
+	}
+
+	return a
+}
+
+func (a *aggregate[S]) OnEvent(ctx context.Context, e Event) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.ended {
+		return
+	}
+
+	if e == End {
+		a.ended = true
+		a.Close()
+		a.em.End(ctx)
+		return
+	}
+
+	a.state = a.fn(a.state, e)
+	a.em.Emit(ctx, Snapshot[S]{State: a.state})
+}
+
+// tick emits the state periodically until End or Close.
+func (a *aggregate[S]) tick(t Ticker) {
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C():
+		case <-a.stop:
+			return
+		}
+
+		a.lock.Lock()
+		if !a.ended {
+			a.em.Emit(context.Background(), Snapshot[S]{State: a.state, Periodic: true})
+		}
+		a.lock.Unlock()
+	}
+}
+
+func (a *aggregate[S]) Close() error {
+	a.stopOnce.Do(func() { close(a.stop) })
+	return nil
+}
+
+func (a *aggregate[S]) Register(ctx context.Context, oer Observer) {
+	a.o.Register(ctx, oer)
+}
+
+func (a *aggregate[S]) Name() string {
+	return a.o.Name()
+}
+
+func (a *aggregate[S]) String() string {
+	return nameOr(a.o.Name(), "aggregate", a)
+}
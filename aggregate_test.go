@@ -0,0 +1,80 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func ExampleAggregate() {
+	ctx := context.Background()
+	clock := newFakeClock()
+
+	count := Aggregate(0, func(n int, e Event) int {
+		return n + 1
+	}, WithSnapshots(time.Minute), WithClock(clock))
+
+	snapshots := make(chan Snapshot[int])
+	count.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if snap, ok := e.(Snapshot[int]); ok {
+			snapshots <- snap
+		}
+	}))
+
+	go func() {
+		count.OnEvent(ctx, stringEvent("a"))
+		count.OnEvent(ctx, stringEvent("b"))
+	}()
+	fmt.Println(<-snapshots)
+	fmt.Println(<-snapshots)
+
+	// a dashboard registering now gets the full state within a minute
+	clock.Advance(time.Minute)
+	fmt.Println(<-snapshots)
+
+	count.OnEvent(ctx, End)
+
+	// Output:
+	// {1 false}
+	// {2 false}
+	// {2 true}
+}
+
+func TestAggregateClose(t *testing.T) {
+	ctx := context.Background()
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 10; i++ {
+		a := Aggregate(0, func(n int, e Event) int { return n + 1 }, WithSnapshots(time.Hour))
+		a.OnEvent(ctx, intEvent(i))
+
+		// End never comes
+		a.(Closer).Close()
+	}
+
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 100 {
+			t.Fatalf("goroutines leaked: %d before, %d after closing", before, runtime.NumGoroutine())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+Package asyncapi generates AsyncAPI 2.6 documents (https://www.asyncapi.com) describing the events of an application:
+which topics exist and which event types are published on them. Payloads are described using package jsonschema.
+*/
+package asyncapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/jsonschema"
+)
+
+// Version is the AsyncAPI version of the generated documents.
+const Version = "2.6.0"
+
+// Document is an AsyncAPI document.
+type Document struct {
+	AsyncAPI   string             `json:"asyncapi"`
+	Info       Info               `json:"info"`
+	Channels   map[string]Channel `json:"channels"`
+	Components Components         `json:"components"`
+}
+
+// Info describes the application.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Channel is a topic.
+type Channel struct {
+	Description string     `json:"description,omitempty"`
+	Subscribe   *Operation `json:"subscribe,omitempty"`
+}
+
+// Operation says which messages can be received on a channel.
+type Operation struct {
+	Message MessageRef `json:"message"`
+}
+
+// MessageRef refers to one or more messages in the components.
+type MessageRef struct {
+	Ref   string       `json:"$ref,omitempty"`
+	OneOf []MessageRef `json:"oneOf,omitempty"`
+}
+
+// Components hold the messages, one per EventType.
+type Components struct {
+	Messages map[string]Message `json:"messages"`
+}
+
+// Message describes an event type.
+type Message struct {
+	Name    string          `json:"name"`
+	Title   string          `json:"title,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Generator generates a Document.
+type Generator struct {
+	Info Info
+
+	// Types holds the event types to describe. Defaults to voyeur.DefaultTypes().
+	Types *voyeur.TypeRegistry
+
+	// Channels maps topics to the EventTypes published on them.
+	Channels map[string][]string
+}
+
+// AddBus adds all topics of bus that are not in Channels yet, with the given EventTypes.
+func (g *Generator) AddBus(bus *voyeur.Bus, types ...string) {
+	if g.Channels == nil {
+		g.Channels = make(map[string][]string)
+	}
+
+	for _, topic := range bus.Topics() {
+		if _, ok := g.Channels[topic]; !ok {
+			g.Channels[topic] = types
+		}
+	}
+}
+
+// Generate returns the document. All EventTypes used in Channels must be registered in Types.
+func (g *Generator) Generate() (*Document, error) {
+	types := g.Types
+	if types == nil {
+		types = voyeur.DefaultTypes()
+	}
+
+	doc := &Document{
+		AsyncAPI:   Version,
+		Info:       g.Info,
+		Channels:   make(map[string]Channel),
+		Components: Components{Messages: make(map[string]Message)},
+	}
+
+	for _, typ := range types.Types() {
+		t, _ := types.Lookup(typ)
+
+		payload, err := json.Marshal(jsonschema.Generate(t))
+		if err != nil {
+			return nil, err
+		}
+
+		doc.Components.Messages[typ] = Message{
+			Name:    typ,
+			Title:   t.String(),
+			Payload: payload,
+		}
+	}
+
+	for topic, typs := range g.Channels {
+		var refs []MessageRef
+
+		sorted := append([]string(nil), typs...)
+		sort.Strings(sorted)
+		for _, typ := range sorted {
+			if _, ok := doc.Components.Messages[typ]; !ok {
+				return nil, fmt.Errorf("asyncapi: event type %q of topic %q is not registered", typ, topic)
+			}
+
+			refs = append(refs, MessageRef{Ref: "#/components/messages/" + typ})
+		}
+
+		ch := Channel{}
+		switch len(refs) {
+		case 0:
+		case 1:
+			ch.Subscribe = &Operation{Message: refs[0]}
+		default:
+			ch.Subscribe = &Operation{Message: MessageRef{OneOf: refs}}
+		}
+
+		doc.Channels[topic] = ch
+	}
+
+	return doc, nil
+}
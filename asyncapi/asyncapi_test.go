@@ -0,0 +1,129 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asyncapi
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+type orderCreated struct {
+	ID string `json:"id"`
+}
+
+func (orderCreated) EventType() string {
+	return "order.created"
+}
+
+type orderCancelled struct {
+	ID string `json:"id"`
+}
+
+func (orderCancelled) EventType() string {
+	return "order.cancelled"
+}
+
+func Example() {
+	types := voyeur.NewTypeRegistry()
+	types.Register(orderCreated{})
+	types.Register(orderCancelled{})
+
+	bus := voyeur.NewBus()
+	bus.Register(context.Background(), "audit", voyeur.ObserverFunc(func(context.Context, voyeur.Event) {}))
+
+	g := &Generator{
+		Info:  Info{Title: "shop", Version: "1.0.0"},
+		Types: types,
+		Channels: map[string][]string{
+			"orders": {"order.created", "order.cancelled"},
+		},
+	}
+	g.AddBus(bus)
+
+	doc, err := g.Generate()
+	if err != nil {
+		panic(err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(doc)
+
+	// Output:
+	// {
+	//   "asyncapi": "2.6.0",
+	//   "info": {
+	//     "title": "shop",
+	//     "version": "1.0.0"
+	//   },
+	//   "channels": {
+	//     "audit": {},
+	//     "orders": {
+	//       "subscribe": {
+	//         "message": {
+	//           "oneOf": [
+	//             {
+	//               "$ref": "#/components/messages/order.cancelled"
+	//             },
+	//             {
+	//               "$ref": "#/components/messages/order.created"
+	//             }
+	//           ]
+	//         }
+	//       }
+	//     }
+	//   },
+	//   "components": {
+	//     "messages": {
+	//       "order.cancelled": {
+	//         "name": "order.cancelled",
+	//         "title": "asyncapi.orderCancelled",
+	//         "payload": {
+	//           "type": "object",
+	//           "properties": {
+	//             "id": {
+	//               "type": "string"
+	//             }
+	//           },
+	//           "required": [
+	//             "id"
+	//           ]
+	//         }
+	//       },
+	//       "order.created": {
+	//         "name": "order.created",
+	//         "title": "asyncapi.orderCreated",
+	//         "payload": {
+	//           "type": "object",
+	//           "properties": {
+	//             "id": {
+	//               "type": "string"
+	//             }
+	//           },
+	//           "required": [
+	//             "id"
+	//           ]
+	//         }
+	//       }
+	//     }
+	//   }
+	// }
+}
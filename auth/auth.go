@@ -0,0 +1,162 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+Package auth authenticates the peers of network bridges and decides which topics they may access.
+
+HTTP based bridges are protected by wrapping their handler using Middleware. Connection based bridges,
+like remote.Server, take a function that checks each new connection, see ConnAuthorizer.
+*/
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ErrUnauthenticated is returned if the identity of a peer can't be established.
+var ErrUnauthenticated = errors.New("auth: unauthenticated")
+
+// ErrForbidden is returned if a peer may not access a topic.
+var ErrForbidden = errors.New("auth: forbidden")
+
+// Authenticator returns the identity of the peer that sent an HTTP request.
+type Authenticator interface {
+	Authenticate(*http.Request) (string, error)
+}
+
+// BearerTokens is an Authenticator that maps bearer tokens to identities.
+type BearerTokens map[string]string
+
+// Authenticate returns the identity for the bearer token in the Authorization header.
+func (bt BearerTokens) Authenticate(r *http.Request) (string, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+
+	for t, id := range bt {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return id, nil
+		}
+	}
+
+	return "", ErrUnauthenticated
+}
+
+// ClientCert is an Authenticator that uses the common name of the verified TLS client certificate as identity.
+// The server must be configured to verify client certificates.
+type ClientCert struct{}
+
+// Authenticate returns the common name of the client certificate.
+func (ClientCert) Authenticate(r *http.Request) (string, error) {
+	if r.TLS == nil {
+		return "", ErrUnauthenticated
+	}
+
+	return stateIdentity(*r.TLS)
+}
+
+// ConnIdentity returns the common name of the verified client certificate of a TLS connection.
+func ConnIdentity(c net.Conn) (string, error) {
+	tc, ok := c.(*tls.Conn)
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+
+	if err := tc.Handshake(); err != nil {
+		return "", err
+	}
+
+	return stateIdentity(tc.ConnectionState())
+}
+
+func stateIdentity(state tls.ConnectionState) (string, error) {
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", ErrUnauthenticated
+	}
+
+	return state.VerifiedChains[0][0].Subject.CommonName, nil
+}
+
+// AllowList maps identities to the topics they may access. Topics are patterns as in path.Match, e.g. "user.*".
+type AllowList map[string][]string
+
+// Allowed returns whether identity may access topic.
+func (al AllowList) Allowed(identity, topic string) bool {
+	for _, pattern := range al[identity] {
+		if ok, _ := path.Match(pattern, topic); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying identity.
+func WithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom returns the identity carried by ctx.
+func IdentityFrom(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(identityKey{}).(string)
+	return id, ok
+}
+
+// Middleware protects next: requests are authenticated using authn, and the identity may only access the topic returned by topic.
+// The identity is passed on in the request context, see IdentityFrom.
+func Middleware(next http.Handler, authn Authenticator, al AllowList, topic func(*http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := authn.Authenticate(r)
+		if err != nil {
+			http.Error(w, ErrUnauthenticated.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if !al.Allowed(id, topic(r)) {
+			http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+	})
+}
+
+// ConnAuthorizer returns a function that only accepts TLS connections whose client identity may access topic.
+func ConnAuthorizer(al AllowList, topic string) func(net.Conn) error {
+	return func(c net.Conn) error {
+		id, err := ConnIdentity(c)
+		if err != nil {
+			return err
+		}
+
+		if !al.Allowed(id, topic) {
+			return ErrForbidden
+		}
+
+		return nil
+	}
+}
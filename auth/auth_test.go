@@ -0,0 +1,74 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package auth
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := IdentityFrom(r.Context())
+		w.Write([]byte(id))
+	})
+
+	h := Middleware(ok,
+		BearerTokens{"s3cret": "billing"},
+		AllowList{"billing": {"order.*"}},
+		func(r *http.Request) string { return r.URL.Query().Get("topic") },
+	)
+
+	for _, tc := range []struct {
+		token, topic string
+		status       int
+	}{
+		{"s3cret", "order.created", http.StatusOK},
+		{"s3cret", "user.created", http.StatusForbidden},
+		{"wrong", "order.created", http.StatusUnauthorized},
+		{"", "order.created", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/?topic="+tc.topic, nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("token %q, topic %q: expected %d, got %d", tc.token, tc.topic, tc.status, rec.Code)
+		}
+
+		if rec.Code == http.StatusOK && rec.Body.String() != "billing" {
+			t.Errorf("expected identity billing in context, got %q", rec.Body.String())
+		}
+	}
+}
+
+func TestConnAuthorizerRequiresTLS(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	if err := ConnAuthorizer(AllowList{"x": {"*"}}, "t")(a); err != ErrUnauthenticated {
+		t.Errorf("expected ErrUnauthenticated for plain connection, got %v", err)
+	}
+}
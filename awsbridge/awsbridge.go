@@ -0,0 +1,216 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+Package awsbridge connects voyeur streams to Amazon SNS and SQS.
+
+To keep voyeur free of dependencies, the bridges talk to AWS through the small SNS and SQS interfaces,
+which are easily implemented on top of the clients of the AWS SDK. Events are sent as CloudEvents in
+structured JSON format, see package cloudevents.
+*/
+package awsbridge
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/cloudevents"
+)
+
+// SNS publishes messages to SNS topics.
+type SNS interface {
+	Publish(ctx context.Context, topicARN, body string) error
+}
+
+// Message is a message received from SQS.
+type Message struct {
+	ID            string
+	ReceiptHandle string
+	Body          string
+}
+
+// SQS receives and acknowledges messages from SQS queues.
+type SQS interface {
+	// Receive returns up to max messages, hiding them from other consumers for the visibility timeout.
+	Receive(ctx context.Context, queueURL string, max int, visibility time.Duration) ([]Message, error)
+
+	// Delete deletes a message, which acknowledges it.
+	Delete(ctx context.Context, queueURL, receiptHandle string) error
+
+	// ChangeVisibility sets the visibility timeout of a received message, counted from now.
+	ChangeVisibility(ctx context.Context, queueURL, receiptHandle string, visibility time.Duration) error
+}
+
+// Sink is an Observer that publishes events to an SNS topic.
+type Sink struct {
+	Client   SNS
+	TopicARN string
+	Encoder  cloudevents.Encoder
+
+	// OnError is called when an event can't be published. It may be nil.
+	OnError func(voyeur.Event, error)
+}
+
+// OnEvent publishes e. End is not published.
+func (s *Sink) OnEvent(ctx context.Context, e voyeur.Event) {
+	if e == voyeur.End {
+		return
+	}
+
+	err := func() error {
+		ce, err := s.Encoder.Encode(e)
+		if err != nil {
+			return err
+		}
+
+		body, err := json.Marshal(ce)
+		if err != nil {
+			return err
+		}
+
+		return s.Client.Publish(ctx, s.TopicARN, string(body))
+	}()
+
+	if err != nil && s.OnError != nil {
+		s.OnError(e, err)
+	}
+}
+
+// Source consumes an SQS queue and emits the received events.
+// A message is deleted from the queue only after Emit returned, i.e. after all observers of a synchronous Pair processed it,
+// or, if Acks is set, after the observers acknowledged it.
+// While that takes, the visibility timeout of the message is extended periodically, so it isn't redelivered in the meantime.
+// Messages that can't be decoded are left in the queue, so SQS can move them to a dead-letter queue.
+type Source struct {
+	Client   SQS
+	QueueURL string
+	Decoder  cloudevents.Decoder
+
+	// MaxMessages is the number of messages received at once. Defaults to 10.
+	MaxMessages int
+
+	// Visibility is the visibility timeout of received messages. Defaults to 30 seconds.
+	Visibility time.Duration
+
+	// Clock is used to extend the visibility timeout. Defaults to voyeur.SystemClock.
+	Clock voyeur.Clock
+
+	// Acks, if not zero, is the number of times voyeur.Ack must be called with the context of an event before its
+	// message is deleted, e.g. once by each observer, or once by a single critical observer. If voyeur.Nack is
+	// called instead, the message is made visible again right away, so it is redelivered.
+	Acks int
+
+	// OnError is called for errors that don't stop Run. It may be nil.
+	OnError func(error)
+}
+
+// Run receives messages and emits them on em until ctx is cancelled or receiving fails. em is ended before Run returns.
+func (s *Source) Run(ctx context.Context, em voyeur.Emitter) error {
+	defer em.End(ctx)
+
+	max, visibility, clock := s.MaxMessages, s.Visibility, s.Clock
+	if max == 0 {
+		max = 10
+	}
+	if visibility == 0 {
+		visibility = 30 * time.Second
+	}
+	if clock == nil {
+		clock = voyeur.SystemClock
+	}
+
+	for ctx.Err() == nil {
+		msgs, err := s.Client.Receive(ctx, s.QueueURL, max, visibility)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+
+		for _, msg := range msgs {
+			s.handle(ctx, em, msg, visibility, clock)
+		}
+	}
+
+	return ctx.Err()
+}
+
+func (s *Source) handle(ctx context.Context, em voyeur.Emitter, msg Message, visibility time.Duration, clock voyeur.Clock) {
+	var ce cloudevents.CloudEvent
+	if err := json.Unmarshal([]byte(msg.Body), &ce); err != nil {
+		s.error(err)
+		return
+	}
+
+	e, err := s.Decoder.Decode(&ce)
+	if err != nil {
+		s.error(err)
+		return
+	}
+
+	done := make(chan struct{})
+	ticker := clock.NewTicker(visibility / 2)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				if err := s.Client.ChangeVisibility(ctx, s.QueueURL, msg.ReceiptHandle, visibility); err != nil {
+					s.error(err)
+				}
+			}
+		}
+	}()
+
+	finish := func(nack error) {
+		close(done)
+
+		// acks may arrive after Run returned, and the message was processed nonetheless
+		ctx := context.WithoutCancel(ctx)
+		if nack != nil {
+			if err := s.Client.ChangeVisibility(ctx, s.QueueURL, msg.ReceiptHandle, 0); err != nil {
+				s.error(err)
+			}
+			return
+		}
+
+		if err := s.Client.Delete(ctx, s.QueueURL, msg.ReceiptHandle); err != nil {
+			s.error(err)
+		}
+	}
+
+	if s.Acks == 0 {
+		em.Emit(ctx, e)
+		finish(nil)
+		return
+	}
+
+	em.Emit(voyeur.WithAck(ctx, s.Acks, finish), e)
+}
+
+func (s *Source) error(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+}
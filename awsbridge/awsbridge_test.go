@@ -0,0 +1,177 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package awsbridge
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/cloudevents"
+)
+
+type ping struct {
+	N int `json:"n"`
+}
+
+func (ping) EventType() string {
+	return "ping"
+}
+
+// fakeAWS is an SNS topic directly feeding an SQS queue.
+type fakeAWS struct {
+	lock     sync.Mutex
+	queue    []Message
+	inflight map[string]Message
+	deleted  []string
+	next     int
+}
+
+func (f *fakeAWS) Publish(ctx context.Context, topicARN, body string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.next++
+	id := strconv.Itoa(f.next)
+	f.queue = append(f.queue, Message{ID: id, ReceiptHandle: "r" + id, Body: body})
+	return nil
+}
+
+func (f *fakeAWS) Receive(ctx context.Context, queueURL string, max int, visibility time.Duration) ([]Message, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if len(f.queue) == 0 {
+		return nil, context.Canceled
+	}
+
+	if max > len(f.queue) {
+		max = len(f.queue)
+	}
+
+	msgs := f.queue[:max]
+	f.queue = f.queue[max:]
+	for _, msg := range msgs {
+		f.inflight[msg.ReceiptHandle] = msg
+	}
+
+	return msgs, nil
+}
+
+func (f *fakeAWS) Delete(ctx context.Context, queueURL, receiptHandle string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.inflight, receiptHandle)
+	f.deleted = append(f.deleted, receiptHandle)
+	return nil
+}
+
+func (f *fakeAWS) ChangeVisibility(ctx context.Context, queueURL, receiptHandle string, visibility time.Duration) error {
+	return nil
+}
+
+func TestBridge(t *testing.T) {
+	ctx := context.Background()
+	aws := &fakeAWS{inflight: make(map[string]Message)}
+
+	types := voyeur.NewTypeRegistry()
+	types.Register(ping{})
+
+	sink := &Sink{
+		Client:   aws,
+		TopicARN: "arn:aws:sns:test",
+		Encoder:  cloudevents.Encoder{Source: "test"},
+		OnError:  func(e voyeur.Event, err error) { t.Error(err) },
+	}
+
+	for i := 0; i < 3; i++ {
+		sink.OnEvent(ctx, ping{i})
+	}
+
+	var got []voyeur.Event
+	em, o := voyeur.Pair()
+	o.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		// nothing is acked before the observers are done
+		if e != voyeur.End && len(aws.deleted) != len(got) {
+			t.Errorf("message acked before delivery")
+		}
+		got = append(got, e)
+	}))
+
+	src := &Source{
+		Client:      aws,
+		QueueURL:    "https://sqs.test/queue",
+		Decoder:     cloudevents.Decoder{Types: types},
+		MaxMessages: 2,
+	}
+
+	src.Run(ctx, em)
+
+	if len(got) != 4 || got[0] != (ping{0}) || got[2] != (ping{2}) || got[3] != voyeur.End {
+		t.Errorf("unexpected events %v", got)
+	}
+
+	if len(aws.deleted) != 3 || len(aws.inflight) != 0 {
+		t.Errorf("expected all messages to be deleted, got %v", aws.deleted)
+	}
+}
+
+func TestAcks(t *testing.T) {
+	ctx := context.Background()
+	aws := &fakeAWS{inflight: make(map[string]Message)}
+
+	types := voyeur.NewTypeRegistry()
+	types.Register(ping{})
+
+	sink := &Sink{Client: aws, Encoder: cloudevents.Encoder{Source: "test"}}
+	sink.OnEvent(ctx, ping{0})
+	sink.OnEvent(ctx, ping{1})
+
+	var acks []context.Context
+	em, o := voyeur.Pair()
+	o.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		if e != voyeur.End {
+			// process later
+			acks = append(acks, ctx)
+		}
+	}))
+
+	src := &Source{
+		Client:   aws,
+		QueueURL: "https://sqs.test/queue",
+		Decoder:  cloudevents.Decoder{Types: types},
+		Acks:     1,
+	}
+	src.Run(ctx, em)
+
+	if len(aws.deleted) != 0 || len(acks) != 2 {
+		t.Fatalf("expected nothing deleted before acks, got %v", aws.deleted)
+	}
+
+	voyeur.Ack(acks[0])
+	voyeur.Nack(acks[1], nil)
+
+	if fmt.Sprint(aws.deleted) != "[r1]" {
+		t.Errorf("expected only the acked message to be deleted, got %v", aws.deleted)
+	}
+}
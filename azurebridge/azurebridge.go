@@ -0,0 +1,304 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+Package azurebridge connects voyeur streams to Azure Event Hubs.
+
+To keep voyeur free of dependencies, the bridges talk to Event Hubs through the small Producer and Consumer
+interfaces, which are easily implemented on top of the Azure SDK. Events are sent as CloudEvents in structured
+JSON format, see package cloudevents.
+*/
+package azurebridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/cloudevents"
+)
+
+// EventData is an event hub message.
+type EventData struct {
+	Body         []byte
+	PartitionKey string
+
+	// SequenceNumber is set on received messages.
+	SequenceNumber int64
+}
+
+// Producer sends messages to an event hub.
+type Producer interface {
+	Send(ctx context.Context, ev *EventData) error
+}
+
+// Consumer reads the partitions of an event hub.
+type Consumer interface {
+	Partitions(ctx context.Context) ([]string, error)
+
+	// Open returns a client reading partition after the given sequence number. A negative number means from the start.
+	Open(ctx context.Context, partition string, after int64) (PartitionClient, error)
+}
+
+// PartitionClient reads a single partition.
+type PartitionClient interface {
+	// Receive returns up to max messages, blocking until at least one is available or ctx is cancelled.
+	Receive(ctx context.Context, max int) ([]*EventData, error)
+	Close(ctx context.Context) error
+}
+
+// CheckpointStore persists how far each partition has been processed.
+type CheckpointStore interface {
+	// Load returns the sequence number of the last processed message of a partition. ok is false if there is none.
+	Load(ctx context.Context, partition string) (seq int64, ok bool, err error)
+	Save(ctx context.Context, partition string, seq int64) error
+}
+
+// Sink is an Observer that sends events to an event hub.
+type Sink struct {
+	Client  Producer
+	Encoder cloudevents.Encoder
+
+	// PartitionKey returns the partition key of an event. Events with the same key go to the same partition.
+	// It may be nil, in which case Event Hubs picks a partition.
+	PartitionKey func(voyeur.Event) string
+
+	// OnError is called when an event can't be sent. It may be nil.
+	OnError func(voyeur.Event, error)
+}
+
+// OnEvent sends e. End is not sent.
+func (s *Sink) OnEvent(ctx context.Context, e voyeur.Event) {
+	if e == voyeur.End {
+		return
+	}
+
+	err := func() error {
+		ce, err := s.Encoder.Encode(e)
+		if err != nil {
+			return err
+		}
+
+		body, err := json.Marshal(ce)
+		if err != nil {
+			return err
+		}
+
+		ev := &EventData{Body: body}
+		if s.PartitionKey != nil {
+			ev.PartitionKey = s.PartitionKey(e)
+		}
+
+		return s.Client.Send(ctx, ev)
+	}()
+
+	if err != nil && s.OnError != nil {
+		s.OnError(e, err)
+	}
+}
+
+// Source reads all partitions of an event hub and emits the events they carry.
+// Partitions are read concurrently, so only events of the same partition are emitted in order.
+// After Emit returned for all messages of a batch, or, if Acks is set, after the observers acknowledged them,
+// the partition is checkpointed, and after a restart reading continues after the checkpoint.
+type Source struct {
+	Client      Consumer
+	Checkpoints CheckpointStore
+	Decoder     cloudevents.Decoder
+
+	// BatchSize is the number of messages received at once. Defaults to 100.
+	BatchSize int
+
+	// Acks, if not zero, is the number of times voyeur.Ack must be called with the context of an event before it
+	// counts as processed, e.g. once by each observer, or once by a single critical observer. The next batch is only
+	// received after all events of a batch were acknowledged. If voyeur.Nack is called, the partition is read again
+	// from the last checkpoint.
+	Acks int
+
+	// OnError is called for messages that can't be decoded, failed checkpoints and nacks. It may be nil.
+	OnError func(error)
+}
+
+// nackError is returned by readPartition if an event was nacked.
+type nackError struct {
+	err error
+}
+
+func (err nackError) Error() string {
+	return "azurebridge: nacked: " + err.err.Error()
+}
+
+// Run emits the events until ctx is cancelled or reading a partition fails. em is ended before Run returns.
+func (s *Source) Run(ctx context.Context, em voyeur.Emitter) error {
+	defer em.End(ctx)
+
+	parts, err := s.Client.Partitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	for _, part := range parts {
+		wg.Add(1)
+		go func(part string) {
+			defer wg.Done()
+			if err := s.runPartition(ctx, em, part); err != nil && ctx.Err() == nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(part)
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return ctx.Err()
+}
+
+// runPartition reads a partition, and again from the last checkpoint after a nack.
+func (s *Source) runPartition(ctx context.Context, em voyeur.Emitter, part string) error {
+	for {
+		err := s.readPartition(ctx, em, part)
+
+		var nack nackError
+		if !errors.As(err, &nack) {
+			return err
+		}
+		s.error(nack.err)
+	}
+}
+
+func (s *Source) readPartition(ctx context.Context, em voyeur.Emitter, part string) error {
+	after, ok, err := s.Checkpoints.Load(ctx, part)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		after = -1
+	}
+
+	pc, err := s.Client.Open(ctx, part, after)
+	if err != nil {
+		return err
+	}
+	defer pc.Close(context.Background())
+
+	size := s.BatchSize
+	if size == 0 {
+		size = 100
+	}
+
+	for {
+		evs, err := pc.Receive(ctx, size)
+		if err != nil {
+			return err
+		}
+
+		if len(evs) == 0 {
+			continue
+		}
+
+		if err := s.emit(ctx, em, evs); err != nil {
+			return err
+		}
+
+		if err := s.Checkpoints.Save(ctx, part, evs[len(evs)-1].SequenceNumber); err != nil {
+			s.error(err)
+		}
+	}
+}
+
+// emit emits the events of a batch and, if Acks is set, waits until they were acknowledged.
+func (s *Source) emit(ctx context.Context, em voyeur.Emitter, evs []*EventData) error {
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		nack error
+	)
+
+	for _, ev := range evs {
+		var ce cloudevents.CloudEvent
+		if err := json.Unmarshal(ev.Body, &ce); err != nil {
+			s.error(err)
+			continue
+		}
+
+		e, err := s.Decoder.Decode(&ce)
+		if err != nil {
+			s.error(err)
+			continue
+		}
+
+		if s.Acks == 0 {
+			em.Emit(ctx, e)
+			continue
+		}
+
+		wg.Add(1)
+		em.Emit(voyeur.WithAck(ctx, s.Acks, func(err error) {
+			defer wg.Done()
+			if err != nil {
+				lock.Lock()
+				defer lock.Unlock()
+				if nack == nil {
+					nack = err
+				}
+			}
+		}), e)
+	}
+
+	if s.Acks == 0 {
+		return nil
+	}
+
+	acked := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(acked)
+	}()
+
+	select {
+	case <-acked:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if nack != nil {
+		return nackError{nack}
+	}
+	return nil
+}
+
+func (s *Source) error(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+}
@@ -0,0 +1,244 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package azurebridge
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/cloudevents"
+)
+
+type reading struct {
+	Sensor string `json:"sensor"`
+	Value  int    `json:"value"`
+}
+
+func (reading) EventType() string {
+	return "reading"
+}
+
+// fakeHub is an event hub with two partitions.
+type fakeHub struct {
+	lock  sync.Mutex
+	parts [2][]*EventData
+}
+
+func (h *fakeHub) Send(ctx context.Context, ev *EventData) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	f := fnv.New32()
+	f.Write([]byte(ev.PartitionKey))
+	p := f.Sum32() % 2
+
+	ev.SequenceNumber = int64(len(h.parts[p]))
+	h.parts[p] = append(h.parts[p], ev)
+	return nil
+}
+
+func (h *fakeHub) Partitions(ctx context.Context) ([]string, error) {
+	return []string{"0", "1"}, nil
+}
+
+func (h *fakeHub) Open(ctx context.Context, partition string, after int64) (PartitionClient, error) {
+	p, _ := strconv.Atoi(partition)
+	return &fakePartition{hub: h, p: p, next: after + 1}, nil
+}
+
+type fakePartition struct {
+	hub  *fakeHub
+	p    int
+	next int64
+}
+
+func (pc *fakePartition) Receive(ctx context.Context, max int) ([]*EventData, error) {
+	pc.hub.lock.Lock()
+	evs := pc.hub.parts[pc.p]
+	pc.hub.lock.Unlock()
+
+	if pc.next >= int64(len(evs)) {
+		// everything is read, block until cancelled
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	batch := evs[pc.next:]
+	if len(batch) > max {
+		batch = batch[:max]
+	}
+	pc.next += int64(len(batch))
+	return batch, nil
+}
+
+func (pc *fakePartition) Close(ctx context.Context) error {
+	return nil
+}
+
+type memCheckpoints struct {
+	lock sync.Mutex
+	seqs map[string]int64
+}
+
+func (c *memCheckpoints) Load(ctx context.Context, partition string) (int64, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	seq, ok := c.seqs[partition]
+	return seq, ok, nil
+}
+
+func (c *memCheckpoints) Save(ctx context.Context, partition string, seq int64) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.seqs[partition] = seq
+	return nil
+}
+
+// consume runs a Source until it has seen n events and returns the events.
+func consume(hub *fakeHub, cps CheckpointStore, n int) []reading {
+	types := voyeur.NewTypeRegistry()
+	types.Register(reading{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		lock sync.Mutex
+		got  []reading
+	)
+
+	em, o := voyeur.Pair()
+	o.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		if r, ok := e.(reading); ok {
+			lock.Lock()
+			got = append(got, r)
+			if len(got) == n {
+				cancel()
+			}
+			lock.Unlock()
+		}
+	}))
+
+	src := &Source{Client: hub, Checkpoints: cps, Decoder: cloudevents.Decoder{Types: types}, BatchSize: 2}
+	src.Run(ctx, em)
+
+	sort.Slice(got, func(i, j int) bool { return got[i].Value < got[j].Value })
+	return got
+}
+
+func TestBridge(t *testing.T) {
+	ctx := context.Background()
+	hub := &fakeHub{}
+	cps := &memCheckpoints{seqs: make(map[string]int64)}
+
+	sink := &Sink{
+		Client:       hub,
+		Encoder:      cloudevents.Encoder{Source: "test"},
+		PartitionKey: func(e voyeur.Event) string { return e.(reading).Sensor },
+	}
+
+	for i := 0; i < 6; i++ {
+		sink.OnEvent(ctx, reading{Sensor: "s" + strconv.Itoa(i%3), Value: i})
+	}
+
+	if got := consume(hub, cps, 6); len(got) != 6 || got[5].Value != 5 {
+		t.Fatalf("unexpected events %v", got)
+	}
+
+	// after restarting only new events are emitted
+	sink.OnEvent(ctx, reading{Sensor: "s0", Value: 6})
+	if got := consume(hub, cps, 1); len(got) != 1 || got[0].Value != 6 {
+		t.Fatalf("unexpected events after restart %v", got)
+	}
+}
+
+func TestNack(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := &fakeHub{}
+	cps := &memCheckpoints{seqs: make(map[string]int64)}
+
+	sink := &Sink{Client: hub, Encoder: cloudevents.Encoder{Source: "test"}}
+	for i := 0; i < 3; i++ {
+		sink.OnEvent(ctx, reading{Sensor: "s0", Value: i})
+	}
+
+	types := voyeur.NewTypeRegistry()
+	types.Register(reading{})
+
+	var (
+		got    []int
+		nacked bool
+	)
+
+	em, o := voyeur.Pair()
+	o.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		r, ok := e.(reading)
+		if !ok {
+			return
+		}
+
+		got = append(got, r.Value)
+		if r.Value == 0 && !nacked {
+			nacked = true
+			voyeur.Nack(ctx, nil)
+			return
+		}
+
+		if r.Value == 2 {
+			// stop without acknowledging, so the last batch isn't checkpointed
+			cancel()
+			return
+		}
+
+		// acknowledge asynchronously
+		go voyeur.Ack(ctx)
+	}))
+
+	src := &Source{
+		Client:      hub,
+		Checkpoints: cps,
+		Decoder:     cloudevents.Decoder{Types: types},
+		BatchSize:   2,
+		Acks:        1,
+		OnError: func(err error) {
+			if err != voyeur.ErrNacked {
+				t.Error(err)
+			}
+		},
+	}
+	src.Run(ctx, em)
+
+	// the nacked batch was read again
+	if fmt.Sprint(got) != "[0 1 0 1 2]" {
+		t.Errorf("unexpected events %v", got)
+	}
+
+	if fmt.Sprint(cps.seqs) != "map[0:1]" && fmt.Sprint(cps.seqs) != "map[1:1]" {
+		t.Errorf("expected checkpoint at 1, got %v", cps.seqs)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff computes exponentially growing delays between retries, e.g. of reconnects.
+// The zero value is usable.
+type Backoff struct {
+	// Min is the first delay. Defaults to 100ms.
+	Min time.Duration
+
+	// Max caps the delay. Defaults to 30s.
+	Max time.Duration
+
+	// Factor is what the delay is multiplied with on each attempt. Defaults to 2.
+	Factor float64
+
+	// Jitter randomizes each delay by up to this fraction of it, so clients don't retry in lockstep.
+	Jitter float64
+}
+
+// Delay returns the delay before retry number attempt, counting from zero.
+func (b Backoff) Delay(attempt int) time.Duration {
+	min, max, factor := b.Min, b.Max, b.Factor
+	if min <= 0 {
+		min = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+	if factor < 1 {
+		factor = 2
+	}
+
+	d := float64(min)
+	for i := 0; i < attempt && d < float64(max); i++ {
+		d *= factor
+	}
+	if d > float64(max) {
+		d = float64(max)
+	}
+
+	if b.Jitter > 0 {
+		d += d * b.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(d)
+}
@@ -0,0 +1,37 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"fmt"
+	"time"
+)
+
+func ExampleBackoff() {
+	b := Backoff{Min: time.Second, Max: 10 * time.Second}
+	for i := 0; i < 5; i++ {
+		fmt.Println(b.Delay(i))
+	}
+
+	// Output:
+	// 1s
+	// 2s
+	// 4s
+	// 8s
+	// 10s
+}
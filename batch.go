@@ -0,0 +1,182 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchEvent holds the events collected by Batch, in the order they were observed.
+type BatchEvent struct {
+	Events []Event
+}
+
+func (BatchEvent) EventType() string {
+	return "batch"
+}
+
+type batch struct {
+	n    int
+	wait time.Duration
+	em   Emitter
+	o    *observable
+
+	lock    sync.Mutex
+	pending []Event
+	ctx     context.Context
+	ended   bool
+	// gen counts the batches, so a timer started for a batch that was already emitted is ignored
+	gen  uint64
+	wake chan struct{}
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Batch returns a Filter that collects events and emits them as a BatchEvent once there are n of them, or the
+// first of them waited maxWait, whichever comes first. End emits the events collected so far and is passed on.
+// Use it to write to databases or send over the network in bulk. The WithClock option sets the Clock used for
+// waiting. The Filter is a Closer; closing it before End stops its goroutine and drops the collected events.
+func Batch(n int, maxWait time.Duration, opts ...Option) Filter {
+	if n < 1 {
+		n = 1
+	}
+
+	em, o := Pair(opts...)
+	b := &batch{
+		n:      n,
+		wait:   maxWait,
+		em:     em,
+		o:      o.(*observable),
+		wake:   make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+
+	go b.run()
+	return b
+}
+
+func (b *batch) OnEvent(ctx context.Context, e Event) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.ended {
+		return
+	}
+
+	if e == End {
+		b.ended = true
+		b.flush()
+		b.em.End(ctx)
+		b.signal()
+		return
+	}
+
+	b.pending = append(b.pending, e)
+	b.ctx = ctx
+	switch len(b.pending) {
+	case b.n:
+		b.flush()
+	case 1:
+		// start the timer for the new batch
+		b.signal()
+	}
+}
+
+func (b *batch) signal() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flush emits the pending events, if any. b.lock must be held.
+func (b *batch) flush() {
+	if len(b.pending) == 0 {
+		return
+	}
+
+	b.em.Emit(b.ctx, BatchEvent{Events: b.pending})
+	b.pending = nil
+	b.gen++
+}
+
+// run emits batches that waited too long, until End or Close.
+func (b *batch) run() {
+	var (
+		t    Timer
+		fire <-chan time.Time
+		gen  uint64
+	)
+
+	for {
+		select {
+		case <-b.wake:
+			b.lock.Lock()
+			ended, waiting, current := b.ended, len(b.pending) > 0, b.gen
+			b.lock.Unlock()
+
+			if t != nil && (ended || current != gen) {
+				t.Stop()
+				t, fire = nil, nil
+			}
+			if ended {
+				return
+			}
+			if waiting && t == nil {
+				t, gen = b.o.cfg.clock.NewTimer(b.wait), current
+				fire = t.C()
+			}
+		case <-fire:
+			t, fire = nil, nil
+
+			b.lock.Lock()
+			if b.gen == gen {
+				b.flush()
+			} else if len(b.pending) > 0 {
+				// the batch the timer was started for is gone, start one for the current batch
+				b.signal()
+			}
+			b.lock.Unlock()
+		case <-b.closed:
+			if t != nil {
+				t.Stop()
+			}
+			return
+		}
+	}
+}
+
+func (b *batch) Close() error {
+	b.closeOnce.Do(func() { close(b.closed) })
+	return nil
+}
+
+func (b *batch) Register(ctx context.Context, oer Observer) {
+	b.o.Register(ctx, oer)
+}
+
+func (b *batch) Name() string {
+	return b.o.Name()
+}
+
+func (b *batch) String() string {
+	return nameOr(b.o.Name(), "batch", b)
+}
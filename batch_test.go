@@ -0,0 +1,99 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func ExampleBatch() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	b := Batch(2, time.Minute)
+	o.Register(ctx, b)
+	b.Register(ctx, printObserver{})
+
+	for i := 1; i <= 5; i++ {
+		em.Emit(ctx, intEvent(i))
+	}
+	em.End(ctx)
+
+	// Output:
+	// {[1 2]}
+	// {[3 4]}
+	// {[5]}
+	// End
+}
+
+func TestBatchMaxWait(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	em, o := Pair()
+
+	got := make(chan Event, 10)
+	b := Batch(10, time.Second, WithClock(clock))
+	o.Register(ctx, b)
+	b.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		got <- e
+	}))
+
+	em.Emit(ctx, intEvent(1))
+	em.Emit(ctx, intEvent(2))
+
+	for waiting := true; waiting; {
+		clock.Advance(time.Second)
+		select {
+		case e := <-got:
+			if s := fmt.Sprint(e); s != "{[1 2]}" {
+				t.Fatalf("expected batch of 1 and 2, got %s", s)
+			}
+			waiting = false
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	em.End(ctx)
+	if e := <-got; e != End {
+		t.Fatalf("expected End, got %v", e)
+	}
+}
+
+func TestBatchClose(t *testing.T) {
+	ctx := context.Background()
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 10; i++ {
+		b := Batch(10, time.Hour)
+		b.OnEvent(ctx, intEvent(i))
+
+		// End never comes
+		b.(Closer).Close()
+	}
+
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 100 {
+			t.Fatalf("goroutines leaked: %d before, %d after closing", before, runtime.NumGoroutine())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
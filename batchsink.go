@@ -0,0 +1,199 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchSinkOptions configure BatchSink.
+type BatchSinkOptions struct {
+	// MinSize and MaxSize bound the batch size. They default to 1 and 100.
+	MinSize, MaxSize int
+
+	// MaxLatency is the longest time an event waits for its batch to fill up. Defaults to one second.
+	MaxLatency time.Duration
+
+	// TargetFlush, if not zero, makes the batch size adapt to how long flushing takes: while flushes take longer,
+	// batches grow to make fewer calls, and while they take less than half of it, batches shrink to lower latency.
+	// Otherwise the batch size is MaxSize.
+	TargetFlush time.Duration
+
+	// Clock defaults to SystemClock.
+	Clock Clock
+
+	// OnError is called when flushing fails. It may be nil.
+	OnError func([]Event, error)
+}
+
+type batchSink struct {
+	flush func(context.Context, []Event) error
+	opts  BatchSinkOptions
+
+	lock    sync.Mutex
+	pending []Event
+	ctx     context.Context
+	end     bool
+	size    int
+
+	wake chan struct{}
+}
+
+// BatchSink returns an Observer that collects events and passes them to flush in batches, on its own goroutine.
+// A batch is flushed when it is full or its first event waited MaxLatency. End flushes the last batch and ends
+// the goroutine. Use it for database and network sinks.
+func BatchSink(flush func(context.Context, []Event) error, opts BatchSinkOptions) Observer {
+	if opts.MinSize < 1 {
+		opts.MinSize = 1
+	}
+	if opts.MaxSize < opts.MinSize {
+		opts.MaxSize = 100
+		if opts.MaxSize < opts.MinSize {
+			opts.MaxSize = opts.MinSize
+		}
+	}
+	if opts.MaxLatency <= 0 {
+		opts.MaxLatency = time.Second
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+
+	b := &batchSink{
+		flush: flush,
+		opts:  opts,
+		ctx:   context.Background(),
+		size:  opts.MaxSize,
+		wake:  make(chan struct{}, 1),
+	}
+	if opts.TargetFlush > 0 {
+		b.size = opts.MinSize
+	}
+
+	go b.run()
+	return b
+}
+
+func (b *batchSink) OnEvent(ctx context.Context, e Event) {
+	b.lock.Lock()
+	switch {
+	case b.end:
+	case e == End:
+		b.end = true
+	default:
+		b.pending = append(b.pending, e)
+		b.ctx = ctx
+	}
+	b.lock.Unlock()
+
+	b.signal()
+}
+
+func (b *batchSink) signal() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the next batch if it is due, and whether the sink ended.
+func (b *batchSink) take(expired bool) (batch []Event, ctx context.Context, waiting, end bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	n := len(b.pending)
+	if n >= b.size || b.end || (expired && n > 0) {
+		if n > b.size {
+			n = b.size
+		}
+
+		batch = b.pending[:n:n]
+		b.pending = b.pending[n:]
+	}
+
+	return batch, b.ctx, len(b.pending) > 0, b.end && len(b.pending) == 0
+}
+
+func (b *batchSink) run() {
+	var (
+		t       Timer
+		expired <-chan time.Time
+	)
+
+	for {
+		fired := false
+		select {
+		case <-b.wake:
+		case <-expired:
+			fired = true
+		}
+
+		batch, ctx, waiting, end := b.take(fired)
+		if len(batch) > 0 || fired {
+			if t != nil {
+				t.Stop()
+			}
+			t, expired = nil, nil
+		}
+
+		if len(batch) > 0 {
+			b.do(ctx, batch)
+		}
+
+		switch {
+		case end:
+			return
+		case waiting && len(batch) > 0:
+			// there may be another full batch
+			b.signal()
+		case waiting && t == nil:
+			t = b.opts.Clock.NewTimer(b.opts.MaxLatency)
+			expired = t.C()
+		}
+	}
+}
+
+// do flushes batch and adapts the batch size.
+func (b *batchSink) do(ctx context.Context, batch []Event) {
+	start := b.opts.Clock.Now()
+	err := b.flush(ctx, batch)
+	took := b.opts.Clock.Now().Sub(start)
+
+	if err != nil && b.opts.OnError != nil {
+		b.opts.OnError(batch, err)
+	}
+
+	if target := b.opts.TargetFlush; target > 0 {
+		b.lock.Lock()
+		switch {
+		case took > target && b.size < b.opts.MaxSize:
+			b.size *= 2
+			if b.size > b.opts.MaxSize {
+				b.size = b.opts.MaxSize
+			}
+		case took < target/2 && b.size > b.opts.MinSize:
+			b.size /= 2
+			if b.size < b.opts.MinSize {
+				b.size = b.opts.MinSize
+			}
+		}
+		b.lock.Unlock()
+	}
+}
@@ -0,0 +1,124 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// batches returns a flush function passing the batches to a channel.
+func batches(ch chan<- []Event) func(context.Context, []Event) error {
+	return func(ctx context.Context, batch []Event) error {
+		ch <- batch
+		return nil
+	}
+}
+
+func expectBatch(t *testing.T, ch <-chan []Event, exp string) {
+	t.Helper()
+	select {
+	case batch := <-ch:
+		if fmt.Sprint(batch) != exp {
+			t.Fatalf("expected %s, got %v", exp, batch)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timeout waiting for %s", exp)
+	}
+}
+
+func TestBatchSinkSize(t *testing.T) {
+	ctx := context.Background()
+	ch := make(chan []Event, 10)
+	sink := BatchSink(batches(ch), BatchSinkOptions{MaxSize: 3, Clock: newFakeClock()})
+
+	for i := 0; i < 7; i++ {
+		sink.OnEvent(ctx, intEvent(i))
+	}
+	sink.OnEvent(ctx, End)
+
+	expectBatch(t, ch, "[0 1 2]")
+	expectBatch(t, ch, "[3 4 5]")
+	expectBatch(t, ch, "[6]")
+}
+
+func TestBatchSinkLatency(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	ch := make(chan []Event, 10)
+	sink := BatchSink(batches(ch), BatchSinkOptions{MaxSize: 10, MaxLatency: time.Second, Clock: clock})
+
+	sink.OnEvent(ctx, intEvent(1))
+	sink.OnEvent(ctx, intEvent(2))
+
+	// the timer is started by the sink's goroutine, so advance until it fired
+	for {
+		clock.Advance(time.Second)
+		select {
+		case batch := <-ch:
+			if fmt.Sprint(batch) != "[1 2]" {
+				t.Fatalf("unexpected batch %v", batch)
+			}
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestBatchSinkAdapts(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	ch, release := make(chan []Event), make(chan struct{})
+
+	// every flush takes longer than the target, so batches grow
+	sink := BatchSink(func(ctx context.Context, batch []Event) error {
+		clock.Advance(20 * time.Millisecond)
+		ch <- batch
+		<-release
+		return nil
+	}, BatchSinkOptions{
+		MinSize:     1,
+		MaxSize:     8,
+		TargetFlush: 10 * time.Millisecond,
+		MaxLatency:  time.Hour,
+		Clock:       clock,
+	})
+
+	sizes := []int{1, 2, 4, 8, 8}
+	sink.OnEvent(ctx, intEvent(0))
+	for i, size := range sizes {
+		select {
+		case batch := <-ch:
+			if len(batch) != size {
+				t.Fatalf("expected batch of %d, got %v", size, batch)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for batch of %d", size)
+		}
+
+		// queue the next batch while this one is being flushed
+		if i+1 < len(sizes) {
+			for j := 0; j < sizes[i+1]; j++ {
+				sink.OnEvent(ctx, intEvent(j))
+			}
+		}
+		release <- struct{}{}
+	}
+}
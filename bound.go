@@ -0,0 +1,316 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bound is the most events a component holds in memory at once.
+type Bound struct {
+	Events int
+
+	// Unbounded is set if the number of events held is only limited by the input, e.g. a Recorder.
+	Unbounded bool
+}
+
+// Add returns the sum of both bounds.
+func (b Bound) Add(other Bound) Bound {
+	return Bound{Events: b.Events + other.Events, Unbounded: b.Unbounded || other.Unbounded}
+}
+
+func (b Bound) String() string {
+	if b.Unbounded {
+		return "unbounded"
+	}
+
+	return fmt.Sprintf("%d events", b.Events)
+}
+
+// Bounded is implemented by components to declare how many events they buffer, see AuditMemory.
+// The streams, filters, sinks and stores of this package are all Bounded.
+type Bounded interface {
+	Bound() Bound
+}
+
+// ComponentBound is the bound of an audited component.
+type ComponentBound struct {
+	Name  string
+	Bound Bound
+}
+
+// MemoryReport is the result of AuditMemory.
+type MemoryReport struct {
+	// Bound is the total worst case of the audited components.
+	Bound
+
+	Components []ComponentBound
+
+	// Undeclared are the names of the components that are not Bounded.
+	Undeclared []string
+}
+
+// Bytes returns the worst case memory use in bytes, assuming each event takes eventSize bytes.
+// It returns -1 if the components are unbounded.
+func (r MemoryReport) Bytes(eventSize int) int {
+	if r.Unbounded {
+		return -1
+	}
+
+	return r.Events * eventSize
+}
+
+// Strict returns an UnboundedError if any component is unbounded or did not declare its bound.
+// Use it in tests or at startup of resource-constrained deployments.
+func (r MemoryReport) Strict() error {
+	var names []string
+	for _, c := range r.Components {
+		if c.Bound.Unbounded {
+			names = append(names, c.Name)
+		}
+	}
+	names = append(names, r.Undeclared...)
+
+	if len(names) > 0 {
+		return UnboundedError{Components: names}
+	}
+	return nil
+}
+
+// UnboundedError is returned by MemoryReport.Strict.
+type UnboundedError struct {
+	Components []string
+}
+
+func (err UnboundedError) Error() string {
+	return "voyeur: unbounded or undeclared memory use: " + strings.Join(err.Components, ", ")
+}
+
+// AuditMemory reports the worst case number of events the given components hold in memory, e.g. the streams
+// and filters of an application. The stages of a Pipeline are audited separately.
+func AuditMemory(components ...interface{}) MemoryReport {
+	var r MemoryReport
+	for _, c := range components {
+		if p, ok := c.(*Pipeline); ok {
+			sub := AuditMemory(p.stagesAsComponents()...)
+			r.Bound = r.Bound.Add(sub.Bound)
+			r.Components = append(r.Components, sub.Components...)
+			r.Undeclared = append(r.Undeclared, sub.Undeclared...)
+			continue
+		}
+
+		b, ok := c.(Bounded)
+		if !ok {
+			r.Undeclared = append(r.Undeclared, NameOf(c))
+			continue
+		}
+
+		bound := b.Bound()
+		r.Bound = r.Bound.Add(bound)
+		r.Components = append(r.Components, ComponentBound{Name: NameOf(c), Bound: bound})
+	}
+
+	return r
+}
+
+// boundOf returns the bound of v, or zero if v is not Bounded.
+func boundOf(v interface{}) Bound {
+	if b, ok := v.(Bounded); ok {
+		return b.Bound()
+	}
+
+	return Bound{}
+}
+
+// Bound returns the events the stream queues: the buffer of a buffered stream, and the buffer of each observer
+// of an async one. Fair streams queue for each producer, so they are unbounded.
+func (o *observable) Bound() Bound {
+	switch {
+	case o.fair != nil:
+		return Bound{Unbounded: true}
+	case o.queue != nil:
+		return Bound{Events: cap(o.queue)}
+	case o.cfg.async:
+		o.lock.Lock()
+		defer o.lock.Unlock()
+		return Bound{Events: mailboxSize(o.cfg.buffer) * len(o.observers)}
+	}
+
+	return Bound{}
+}
+
+func (em *emitter) Bound() Bound {
+	return (*observable)(em).Bound()
+}
+
+func (m *mapFilter) Bound() Bound {
+	return m.o.Bound()
+}
+
+func (b *branch) Bound() Bound {
+	return b.o.Bound().Add(boundOf(b.then)).Add(boundOf(b.els))
+}
+
+func (f filter) Bound() Bound {
+	return boundOf(f.Observable).Add(boundOf(f.Observer))
+}
+
+func (ObserverFunc) Bound() Bound {
+	return Bound{}
+}
+
+func (ObservableFunc) Bound() Bound {
+	return Bound{}
+}
+
+func (EmitterFunc) Bound() Bound {
+	return Bound{}
+}
+
+// Bound is N, plus the stream. Retaining by key is unbounded, as the number of keys is.
+func (r *retainer) Bound() Bound {
+	b := r.o.Bound()
+	if r.opts.Key != nil {
+		b.Unbounded = true
+	}
+
+	return b.Add(Bound{Events: r.opts.N})
+}
+
+func (l *limiter) Bound() Bound {
+	return Bound{Events: l.opts.Buffer}
+}
+
+// Bound is unbounded, as events pile up while a batch is flushed.
+func (b *batchSink) Bound() Bound {
+	return Bound{Unbounded: true}
+}
+
+// Bound counts the pending event as one.
+func (db *debounce) Bound() Bound {
+	return db.o.Bound().Add(Bound{Events: 1})
+}
+
+// Bound is n, for the pending batch, plus the stream.
+func (b *batch) Bound() Bound {
+	return b.o.Bound().Add(Bound{Events: b.n})
+}
+
+// Bound counts the state as one event.
+func (a *aggregate[S]) Bound() Bound {
+	return a.o.Bound().Add(Bound{Events: 1})
+}
+
+func (g *Group) Bound() Bound {
+	return boundOf(g.o).Add(boundOf(g.memO))
+}
+
+func (r *Recorder) Bound() Bound {
+	return Bound{Unbounded: true}
+}
+
+func (l *Lineage) Bound() Bound {
+	return Bound{Unbounded: true}
+}
+
+func (s *Scheduler) Bound() Bound {
+	return Bound{Unbounded: true}
+}
+
+// Bound is unbounded, as the number of keys is.
+func (s *Store[V]) Bound() Bound {
+	return boundOf(s.o).Add(Bound{Unbounded: true})
+}
+
+// Bound is unbounded, as the number of keys is.
+func (r *Replica[V]) Bound() Bound {
+	return Bound{Unbounded: true}
+}
+
+// Bound is unbounded, as all undoable events are kept.
+func (h *History) Bound() Bound {
+	return Bound{Unbounded: true}
+}
+
+func (r *Router) Bound() Bound {
+	return Bound{}
+}
+
+// Bound is the number of events played.
+func (p *Player) Bound() Bound {
+	return Bound{Events: len(p.events)}
+}
+
+// Bound is the sum of the bounds of the topics, including those of namespaces.
+func (b *Bus) Bound() Bound {
+	if b == nil {
+		return Bound{}
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var bound Bound
+	for _, t := range b.topics {
+		bound = bound.Add(boundOf(t.o))
+	}
+	for _, ns := range b.namespaces {
+		bound = bound.Add(ns.Bound())
+	}
+
+	return bound
+}
+
+func (t *busTopic) Bound() Bound {
+	return boundOf(t.o)
+}
+
+func (in into[T]) Bound() Bound {
+	return boundOf(in.o)
+}
+
+func (em typedEmitter[T]) Bound() Bound {
+	return boundOf(em.em)
+}
+
+func (o typedObservable[T]) Bound() Bound {
+	return boundOf(o.o)
+}
+
+func (b *BufferedEmitter) Bound() Bound {
+	return Bound{Events: b.size}
+}
+
+func (q *QuotaEmitter) Bound() Bound {
+	return Bound{}
+}
+
+// Bound is the sum of the bounds of the stages.
+func (p *Pipeline) Bound() Bound {
+	return AuditMemory(p.stagesAsComponents()...).Bound
+}
+
+func (p *Pipeline) stagesAsComponents() []interface{} {
+	cs := make([]interface{}, len(p.stages))
+	for i, s := range p.stages {
+		cs[i] = s
+	}
+
+	return cs
+}
@@ -0,0 +1,80 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func ExampleAuditMemory() {
+	ctx := context.Background()
+
+	em, src := Pair(WithName("source"), WithBuffer(16))
+	p := NewPipeline(ctx, src,
+		Retain(RetainOptions{N: 100}, WithName("recent")),
+		Tap(func(context.Context, Event) {}, WithName("log")),
+	)
+	sink := Limit(ObserverFunc(func(context.Context, Event) {}), LimitOptions{Buffer: 8})
+	p.Register(ctx, sink)
+
+	report := AuditMemory(em, p, sink)
+	for _, c := range report.Components {
+		fmt.Println(c.Name, c.Bound)
+	}
+	fmt.Println("total", report.Bound, report.Bytes(512), "bytes")
+	fmt.Println(report.Strict())
+
+	report = AuditMemory(p, NewRecorder())
+	fmt.Println(report.Strict())
+
+	// Output:
+	// source 16 events
+	// recent 100 events
+	// log 0 events
+	// *voyeur.limiter 8 events
+	// total 124 events 63488 bytes
+	// <nil>
+	// voyeur: unbounded or undeclared memory use: *voyeur.Recorder
+}
+
+func TestBoundedComponents(t *testing.T) {
+	bus := NewBus()
+	topicEm, topicO := bus.Topic("a")
+	bus.Namespace("ns").Topic("b")
+
+	em, o := PairT[intEvent](WithBuffer(4))
+	report := AuditMemory(
+		NewStore[int](),
+		NewReplica[int](),
+		NewHistory(nil),
+		NewRouter(),
+		NewPlayer(make([]Recorded, 3), 0),
+		bus, topicEm, topicO,
+		em, o,
+	)
+
+	if len(report.Undeclared) > 0 {
+		t.Errorf("components without bound: %v", report.Undeclared)
+	}
+
+	if b := AuditMemory(NewPlayer(make([]Recorded, 3), 0), em).Bound; b.Events != 7 {
+		t.Errorf("expected 7 events, got %v", b)
+	}
+}
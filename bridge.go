@@ -0,0 +1,126 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"path"
+)
+
+// BridgeRule selects events forwarded by BridgeBuses.
+type BridgeRule struct {
+	// Topic is a pattern as in path.Match, e.g. "orders.*".
+	Topic string
+
+	// Types, if not empty, only lets through events with one of these EventTypes.
+	Types []string
+}
+
+func (r BridgeRule) match(topic string, e Event) bool {
+	if ok, _ := path.Match(r.Topic, topic); !ok {
+		return false
+	}
+
+	if len(r.Types) == 0 {
+		return true
+	}
+
+	for _, typ := range r.Types {
+		if e.EventType() == typ {
+			return true
+		}
+	}
+
+	return false
+}
+
+type bridgeKey struct{}
+
+// bridged returns whether the event emitted with ctx has already been on bus.
+func bridged(ctx context.Context, bus *Bus) bool {
+	visited, _ := ctx.Value(bridgeKey{}).([]*Bus)
+	for _, b := range visited {
+		if b == bus {
+			return true
+		}
+	}
+
+	return false
+}
+
+func withBridged(ctx context.Context, bus *Bus) context.Context {
+	visited, _ := ctx.Value(bridgeKey{}).([]*Bus)
+	visited = append(visited[:len(visited):len(visited)], bus)
+	return context.WithValue(ctx, bridgeKey{}, visited)
+}
+
+// BridgeBuses forwards the events of src that match any of the rules to the topic with the same name on dst,
+// until ctx is cancelled. This includes topics created on src later on.
+// Events remember the buses they have been bridged from, and are never bridged back to one of those,
+// so buses can be bridged in both directions, or in circles, without events echoing forever.
+// This relies on the context of the events, so use BridgeHop where events cross process boundaries.
+// End is not forwarded.
+func BridgeBuses(ctx context.Context, src, dst *Bus, rules ...BridgeRule) {
+	if src == nil {
+		return
+	}
+
+	src.watch(ctx, func(topic string) {
+		src.Register(ctx, topic, ObserverFunc(func(ectx context.Context, e Event) {
+			if e == End || bridged(ectx, dst) {
+				return
+			}
+
+			for _, r := range rules {
+				if r.match(topic, e) {
+					dst.Emit(withBridged(ectx, src), topic, e)
+					return
+				}
+			}
+		}))
+	})
+}
+
+// BridgeHop returns a Filter for one end of a bridge between processes, where the context of an event, and with it
+// the buses it has been bridged from, is lost. Events that already passed a hop with the same name are dropped,
+// all others are wrapped in an Envelope like Wrap does, and name is added to its Origin. Name each process
+// uniquely and put a hop on the way out of each, so events can't echo between them. End is passed on.
+func BridgeHop(name string, opts ...Option) Filter {
+	return Map(func(ctx context.Context, em Emitter, e Event) {
+		if e == End {
+			em.Emit(ctx, e)
+			return
+		}
+
+		env := seal(ctx, e, nil, nil, nil).(Envelope)
+		for _, hop := range env.Origin {
+			if hop == name {
+				return
+			}
+		}
+
+		env.Origin = append(env.Origin[:len(env.Origin):len(env.Origin)], name)
+		em.Emit(ctx, env)
+	}, opts...)
+}
+
+// OriginOf returns the names of the hops that e passed, in order, see BridgeHop.
+func OriginOf(e Event) []string {
+	env, _ := e.(Envelope)
+	return env.Origin
+}
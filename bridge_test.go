@@ -0,0 +1,93 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+func ExampleBridgeBuses() {
+	ctx := context.Background()
+	a, b := NewBus(), NewBus()
+
+	// bridge in both directions
+	BridgeBuses(ctx, a, b, BridgeRule{Topic: "orders.*"})
+	BridgeBuses(ctx, b, a, BridgeRule{Topic: "*", Types: []string{"string"}})
+
+	var onA, onB []Event
+	a.Register(ctx, "orders.new", ObserverFunc(func(ctx context.Context, e Event) {
+		onA = append(onA, e)
+	}))
+	b.Register(ctx, "orders.new", ObserverFunc(func(ctx context.Context, e Event) {
+		onB = append(onB, e)
+	}))
+
+	a.Emit(ctx, "orders.new", stringEvent("from a"))
+	b.Emit(ctx, "orders.new", stringEvent("from b"))
+	b.Emit(ctx, "orders.new", intEvent(1))
+
+	fmt.Println("a:", onA)
+	fmt.Println("b:", onB)
+
+	// Output:
+	// a: [from a from b]
+	// b: [from a from b 1]
+}
+
+func ExampleBridgeHop() {
+	ctx := context.Background()
+
+	// two processes, each forwarding everything it observes to the other
+	emA, oA := Pair(WithBuffer(16))
+	emB, oB := Pair(WithBuffer(16))
+
+	outA, outB := BridgeHop("a"), BridgeHop("b")
+	Connect(ctx, oA, outA)
+	Pipe(ctx, outA, emB)
+	Connect(ctx, oB, outB)
+	Pipe(ctx, outB, emA)
+
+	var (
+		lock sync.Mutex
+		got  []string
+	)
+	oA.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if env, ok := e.(Envelope); ok {
+			lock.Lock()
+			got = append(got, fmt.Sprintf("%v %v", env.Event, OriginOf(e)))
+			lock.Unlock()
+		}
+	}))
+
+	// without the hops, these would echo forever
+	emA.Emit(ctx, stringEvent("hello"))
+	emB.Emit(ctx, stringEvent("hi"))
+
+	Quiesce(ctx, oA, 50*time.Millisecond)
+	lock.Lock()
+	sort.Strings(got)
+	fmt.Println(got)
+	lock.Unlock()
+
+	// Output:
+	// [hello [a b] hi [b]]
+}
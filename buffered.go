@@ -0,0 +1,200 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrOverflow is returned by BufferedEmitter.TryEmit if the event was rejected because the buffer is full.
+var ErrOverflow = errors.New("voyeur: buffer full")
+
+// OverflowPolicy decides what a BufferedEmitter does with an event while its buffer is full.
+type OverflowPolicy int
+
+const (
+	// Block makes Emit wait until there is room.
+	Block OverflowPolicy = iota
+	// DropOldest drops the oldest buffered event to make room.
+	DropOldest
+	// DropNewest drops the event.
+	DropNewest
+	// Reject drops the event like DropNewest, but makes TryEmit return ErrOverflow.
+	Reject
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop oldest"
+	case DropNewest:
+		return "drop newest"
+	case Reject:
+		return "reject"
+	default:
+		return "unknown"
+	}
+}
+
+// BufferedEmitter is an Emitter that buffers events and passes them on to another Emitter on its own goroutine,
+// so a burst of events doesn't hold up the producer. What happens when the buffer is full is up to its
+// OverflowPolicy.
+type BufferedEmitter struct {
+	em     Emitter
+	size   int
+	policy OverflowPolicy
+
+	lock    sync.Mutex
+	cond    *sync.Cond
+	queue   []delivery
+	end     *delivery
+	dropped uint64
+}
+
+// NewBufferedEmitter returns a BufferedEmitter passing events on to em, buffering up to size of them.
+// size is at least one.
+func NewBufferedEmitter(em Emitter, size int, policy OverflowPolicy) *BufferedEmitter {
+	if size < 1 {
+		size = 1
+	}
+
+	b := &BufferedEmitter{
+		em:     emitterOrNop(em),
+		size:   size,
+		policy: policy,
+	}
+	b.cond = sync.NewCond(&b.lock)
+
+	go b.run()
+	return b
+}
+
+// Emit buffers e. End is never dropped and is passed on after the buffered events; events emitted after it are.
+func (b *BufferedEmitter) Emit(ctx context.Context, e Event) {
+	b.TryEmit(ctx, e)
+}
+
+// End ends the Emitter, see Emit.
+func (b *BufferedEmitter) End(ctx context.Context) {
+	b.Emit(ctx, End)
+}
+
+// TryEmit is like Emit, but returns ErrOverflow if e was rejected by the Reject policy, and the error of ctx
+// if it was cancelled while blocking.
+func (b *BufferedEmitter) TryEmit(ctx context.Context, e Event) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.end != nil {
+		return nil
+	}
+
+	if e == End {
+		b.end = &delivery{ctx: ctx, e: e}
+		b.cond.Broadcast()
+		return nil
+	}
+
+	if len(b.queue) >= b.size {
+		switch b.policy {
+		case DropOldest:
+			b.queue = b.queue[1:]
+			b.dropped++
+		case DropNewest:
+			b.dropped++
+			return nil
+		case Reject:
+			b.dropped++
+			return ErrOverflow
+		default:
+			if err := b.wait(ctx); err != nil {
+				return err
+			}
+			if b.end != nil {
+				return nil
+			}
+		}
+	}
+
+	b.queue = append(b.queue, delivery{ctx: ctx, e: e})
+	b.cond.Broadcast()
+	return nil
+}
+
+// wait waits for room in the buffer or End, or until ctx is cancelled. b.lock must be held.
+func (b *BufferedEmitter) wait(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		b.cond.Broadcast()
+	})
+	defer stop()
+
+	for len(b.queue) >= b.size && b.end == nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		b.cond.Wait()
+	}
+
+	return nil
+}
+
+// Len returns the number of buffered events.
+func (b *BufferedEmitter) Len() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return len(b.queue)
+}
+
+// Dropped returns the number of events that were dropped because the buffer was full.
+func (b *BufferedEmitter) Dropped() uint64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.dropped
+}
+
+func (b *BufferedEmitter) run() {
+	for {
+		b.lock.Lock()
+		for len(b.queue) == 0 && b.end == nil {
+			b.cond.Wait()
+		}
+
+		var d delivery
+		if len(b.queue) > 0 {
+			d = b.queue[0]
+			b.queue = b.queue[1:]
+			b.cond.Broadcast()
+		} else {
+			d = *b.end
+		}
+		b.lock.Unlock()
+
+		b.em.Emit(d.ctx, d.e)
+		if d.e == End {
+			return
+		}
+	}
+}
@@ -0,0 +1,101 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBufferedEmitterPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		policy OverflowPolicy
+		exp    string
+		err    error
+	}{
+		{DropOldest, "[0 3 4 End]", nil},
+		{DropNewest, "[0 1 2 End]", nil},
+		{Reject, "[0 1 2 End]", ErrOverflow},
+	} {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+			var got []Event
+			b := NewBufferedEmitter(EmitterFunc(func(ctx context.Context, e Event) {
+				got = append(got, e)
+				switch e {
+				case intEvent(0):
+					close(started)
+					<-release
+				case End:
+					close(done)
+				}
+			}), 2, tc.policy)
+
+			b.Emit(ctx, intEvent(0))
+			<-started
+
+			var err error
+			for i := 1; i < 5; i++ {
+				if e := b.TryEmit(ctx, intEvent(i)); e != nil {
+					err = e
+				}
+			}
+			b.End(ctx)
+			close(release)
+			<-done
+
+			if err != tc.err {
+				t.Errorf("expected error %v, got %v", tc.err, err)
+			}
+			if fmt.Sprint(got) != tc.exp {
+				t.Errorf("expected %s, got %v", tc.exp, got)
+			}
+			if n := b.Dropped(); n != 2 {
+				t.Errorf("expected 2 dropped events, got %d", n)
+			}
+		})
+	}
+}
+
+func TestBufferedEmitterBlock(t *testing.T) {
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	b := NewBufferedEmitter(EmitterFunc(func(ctx context.Context, e Event) {
+		<-release
+	}), 1, Block)
+
+	b.Emit(ctx, intEvent(0))
+	for b.Len() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	b.Emit(ctx, intEvent(1))
+
+	// the buffer is full, so this blocks until the context is cancelled
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := b.TryEmit(tctx, intEvent(2)); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	close(release)
+}
@@ -0,0 +1,110 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"fmt"
+)
+
+// Priority is the priority of an event, see EventBuilder.WithPriority.
+type Priority int
+
+const (
+	Low Priority = iota - 1
+	Normal
+	High
+)
+
+func (p Priority) String() string {
+	switch p {
+	case Low:
+		return "low"
+	case Normal:
+		return "normal"
+	case High:
+		return "high"
+	}
+
+	return fmt.Sprintf("priority(%d)", int(p))
+}
+
+// PayloadOf returns the Data of a DataEvent, like the ones built using NewEvent, also if it is wrapped in an Envelope.
+func PayloadOf(e Event) (interface{}, bool) {
+	if env, ok := e.(Envelope); ok {
+		e = env.Event
+	}
+
+	de, ok := e.(DataEvent)
+	return de.Data, ok
+}
+
+// PriorityOf returns the Priority of the Envelope e, or Normal if e is not an Envelope.
+func PriorityOf(e Event) Priority {
+	env, _ := e.(Envelope)
+	return env.Priority
+}
+
+// EventBuilder builds events without defining a type for each of them. See NewEvent.
+type EventBuilder struct {
+	env     Envelope
+	typ     string
+	payload interface{}
+}
+
+// NewEvent starts building an event of the given type.
+func NewEvent(typ string) *EventBuilder {
+	return &EventBuilder{typ: typ}
+}
+
+// WithKey sets the key of the event, e.g. the id of the entity it is about.
+func (b *EventBuilder) WithKey(key string) *EventBuilder {
+	b.env.Key = key
+	return b
+}
+
+// WithPayload sets the payload of the event.
+func (b *EventBuilder) WithPayload(v interface{}) *EventBuilder {
+	b.payload = v
+	return b
+}
+
+// WithPriority sets the priority of the event.
+func (b *EventBuilder) WithPriority(p Priority) *EventBuilder {
+	b.env.Priority = p
+	return b
+}
+
+// WithMeta adds metadata to the event.
+func (b *EventBuilder) WithMeta(key, value string) *EventBuilder {
+	meta := make(Meta, len(b.env.Meta)+1)
+	for k, v := range b.env.Meta {
+		meta[k] = v
+	}
+	meta[key] = value
+
+	b.env.Meta = meta
+	return b
+}
+
+// Build returns a DataEvent with the payload, wrapped in an Envelope holding the key, priority and metadata.
+func (b *EventBuilder) Build() Envelope {
+	env := b.env
+	env.Event = DataEvent{Type: b.typ, Data: b.payload}
+
+	return env
+}
@@ -0,0 +1,36 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"fmt"
+)
+
+func ExampleNewEvent() {
+	e := NewEvent("order.created").
+		WithKey("o-17").
+		WithPayload(map[string]int{"items": 3}).
+		WithPriority(High).
+		Build()
+
+	payload, _ := PayloadOf(e)
+	fmt.Println(e.EventType(), e.Key, e.Priority, payload)
+
+	// Output:
+	// order.created o-17 high map[items:3]
+}
@@ -0,0 +1,320 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+var defaultBus = NewBus()
+
+// Default returns the process-wide Bus used by the package-level Emit and On.
+func Default() *Bus {
+	return defaultBus
+}
+
+// Emit emits e on the given topic of the default Bus.
+func Emit(ctx context.Context, topic string, e Event) {
+	defaultBus.Emit(ctx, topic, e)
+}
+
+// On calls fn for each event emitted on the given topic of the default Bus.
+func On(ctx context.Context, topic string, fn func(context.Context, Event)) {
+	defaultBus.Register(ctx, topic, ObserverFunc(fn))
+}
+
+type busKey struct{}
+
+// WithBus returns a copy of ctx that carries bus.
+func WithBus(ctx context.Context, bus *Bus) context.Context {
+	return context.WithValue(ctx, busKey{}, bus)
+}
+
+// BusFrom returns the Bus carried by ctx. If there is none, it returns a nil *Bus, which drops all events.
+func BusFrom(ctx context.Context) *Bus {
+	bus, _ := ctx.Value(busKey{}).(*Bus)
+	return bus
+}
+
+// Bus routes events to observers by topic. Each topic is backed by its own Pair.
+// Observers can register on a single topic, or on all topics matching a pattern, see RegisterPattern.
+// A nil *Bus is valid and drops everything emitted on it.
+type Bus struct {
+	name string
+
+	lock       sync.Mutex
+	topics     map[string]*busTopic
+	namespaces map[string]*Bus
+
+	// watchers are called with the name of each new topic
+	watchers map[*func(string)]struct{}
+
+	// quota is set using SetQuota
+	quota atomic.Pointer[busQuota]
+}
+
+type busTopic struct {
+	bus  *Bus
+	name string
+	em   Emitter
+	o    Observable
+
+	emitted atomic.Uint64
+}
+
+func (t *busTopic) Emit(ctx context.Context, e Event) {
+	q := t.bus.quota.Load()
+	if q == nil || e == End {
+		t.emitted.Add(1)
+		t.em.Emit(ctx, e)
+		return
+	}
+
+	size, err := q.admit(e)
+	if err != nil {
+		q.reject(ctx, t.name, e, err)
+		return
+	}
+	defer q.release(size)
+
+	t.emitted.Add(1)
+	t.em.Emit(ctx, e)
+}
+
+func (t *busTopic) End(ctx context.Context) {
+	t.Emit(ctx, End)
+}
+
+// NewBus returns an empty Bus.
+func NewBus() *Bus {
+	return &Bus{
+		topics:     make(map[string]*busTopic),
+		namespaces: make(map[string]*Bus),
+		watchers:   make(map[*func(string)]struct{}),
+	}
+}
+
+// Name returns the name of the namespace, see Namespace. The name of a Bus returned by NewBus is empty.
+func (b *Bus) Name() string {
+	if b == nil {
+		return ""
+	}
+
+	return b.name
+}
+
+// Namespace returns the Bus for the given namespace, creating it if needed.
+// A namespace is a Bus of its own: its topics are separate from those of b and all other namespaces,
+// even if they have the same names. Events only cross namespaces if they are explicitly forwarded, see Forward.
+func (b *Bus) Namespace(name string) *Bus {
+	if b == nil {
+		return nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	ns, ok := b.namespaces[name]
+	if !ok {
+		ns = NewBus()
+		ns.name = name
+		if b.name != "" {
+			ns.name = b.name + "/" + name
+		}
+		b.namespaces[name] = ns
+	}
+
+	return ns
+}
+
+func (b *Bus) topic(name string) *busTopic {
+	var watchers []func(string)
+
+	b.lock.Lock()
+	t, ok := b.topics[name]
+	if !ok {
+		pairName := name
+		if b.name != "" {
+			pairName = b.name + "/" + name
+		}
+
+		em, o := Pair(WithName(pairName))
+		t = &busTopic{bus: b, name: name, em: em, o: o}
+		b.topics[name] = t
+
+		for w := range b.watchers {
+			watchers = append(watchers, *w)
+		}
+	}
+	b.lock.Unlock()
+
+	for _, w := range watchers {
+		w(name)
+	}
+
+	return t
+}
+
+// watch calls fn with the names of all existing and future topics, until ctx is cancelled.
+func (b *Bus) watch(ctx context.Context, fn func(string)) {
+	var names []string
+
+	b.lock.Lock()
+	b.watchers[&fn] = struct{}{}
+	for name := range b.topics {
+		names = append(names, name)
+	}
+	b.lock.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		delete(b.watchers, &fn)
+	})
+
+	for _, name := range names {
+		fn(name)
+	}
+}
+
+// Topic returns the Emitter and Observable backing the given topic.
+func (b *Bus) Topic(name string) (Emitter, Observable) {
+	if b == nil {
+		return NopEmitter, NopObservable
+	}
+
+	t := b.topic(name)
+	return t, t.o
+}
+
+// Emit emits e on the given topic.
+func (b *Bus) Emit(ctx context.Context, topic string, e Event) {
+	if b == nil {
+		return
+	}
+
+	b.topic(topic).Emit(ctx, e)
+}
+
+// Register registers oer on the given topic.
+func (b *Bus) Register(ctx context.Context, topic string, oer Observer) {
+	if b == nil {
+		return
+	}
+
+	b.topic(topic).o.Register(ctx, oer)
+}
+
+// RegisterPattern registers oer on all topics whose names match pattern, as in path.Match, e.g. "user.*".
+// This includes topics created later on, until ctx is cancelled. oer is called from the topics concurrently,
+// with a context that tells the topic, see TopicFrom. End is not passed on, because each topic ends by itself.
+func (b *Bus) RegisterPattern(ctx context.Context, pattern string, oer Observer) {
+	if b == nil {
+		return
+	}
+
+	b.watch(ctx, func(topic string) {
+		if ok, _ := path.Match(pattern, topic); !ok {
+			return
+		}
+
+		b.Register(ctx, topic, ObserverFunc(func(ctx context.Context, e Event) {
+			if e != End {
+				oer.OnEvent(context.WithValue(ctx, topicKey{}, topic), e)
+			}
+		}))
+	})
+}
+
+type topicKey struct{}
+
+// TopicFrom returns the topic of an event passed to an observer registered using RegisterPattern.
+func TopicFrom(ctx context.Context) string {
+	topic, _ := ctx.Value(topicKey{}).(string)
+	return topic
+}
+
+// Topics returns the names of the topics that have been used, in lexical order.
+func (b *Bus) Topics() []string {
+	if b == nil {
+		return nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	names := make([]string, 0, len(b.topics))
+	for name := range b.topics {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Forward emits all events of the given topic on the topic with the same name on dst, until ctx is cancelled.
+// Use it to share selected topics between namespaces.
+func (b *Bus) Forward(ctx context.Context, topic string, dst *Bus) {
+	_, from := b.Topic(topic)
+	to, _ := dst.Topic(topic)
+	Pipe(ctx, from, to)
+}
+
+// BusStats are statistics about a Bus.
+type BusStats struct {
+	// Topics is the number of topics that have been used.
+	Topics int
+
+	// Emitted is the number of events emitted on the topics of the bus, by topic.
+	Emitted map[string]uint64
+
+	// Rejected is the number of events rejected because the bus exceeded its quota, see SetQuota.
+	Rejected uint64
+
+	// Bytes and PeakBytes are the current and highest size of the events being delivered at once,
+	// if the bus has a quota.
+	Bytes, PeakBytes int
+}
+
+// Stats returns statistics about b. Namespaces are not included; each has its own stats.
+func (b *Bus) Stats() BusStats {
+	stats := BusStats{Emitted: make(map[string]uint64)}
+	if b == nil {
+		return stats
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	stats.Topics = len(b.topics)
+	for name, t := range b.topics {
+		stats.Emitted[name] = t.emitted.Load()
+	}
+
+	if q := b.quota.Load(); q != nil {
+		q.lock.Lock()
+		stats.Rejected, stats.Bytes, stats.PeakBytes = q.rejected, q.bytes, q.peak
+		q.lock.Unlock()
+	}
+
+	return stats
+}
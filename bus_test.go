@@ -0,0 +1,216 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func ExampleBus() {
+	ctx := context.Background()
+	bus := NewBus()
+
+	bus.Register(ctx, "orders", printObserver{})
+	bus.Register(ctx, "users", ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println("user:", e)
+	}))
+
+	bus.Emit(ctx, "orders", stringEvent("order 1"))
+	bus.Emit(ctx, "users", stringEvent("alice"))
+	bus.Emit(ctx, "payments", stringEvent("nobody listens"))
+
+	// Output:
+	// order 1
+	// user: alice
+}
+
+func ExampleOn() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	On(ctx, "example.on", func(ctx context.Context, e Event) {
+		fmt.Println("got", e)
+	})
+
+	Emit(ctx, "example.on", stringEvent("hello"))
+
+	// Output:
+	// got hello
+}
+
+func ExampleWithBus() {
+	bus := NewBus()
+	bus.Register(context.Background(), "log", printObserver{})
+
+	// somewhere deep in the call tree
+	logSomething := func(ctx context.Context) {
+		BusFrom(ctx).Emit(ctx, "log", stringEvent("something happened"))
+	}
+
+	logSomething(WithBus(context.Background(), bus))
+
+	// without a bus in the context this is a no-op
+	logSomething(context.Background())
+
+	// Output:
+	// something happened
+}
+
+func ExampleBus_Namespace() {
+	ctx := context.Background()
+	bus := NewBus()
+	a, b := bus.Namespace("tenantA"), bus.Namespace("tenantB")
+
+	a.Register(ctx, "orders", ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println("A:", e)
+	}))
+	b.Register(ctx, "orders", ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println("B:", e)
+	}))
+
+	// tenant B also sees the announcements of tenant A
+	a.Forward(ctx, "announcements", b)
+	b.Register(ctx, "announcements", ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println("B:", e)
+	}))
+
+	a.Emit(ctx, "orders", stringEvent("order 1"))
+	b.Emit(ctx, "orders", stringEvent("order 2"))
+	a.Emit(ctx, "announcements", stringEvent("maintenance"))
+
+	_, o := a.Topic("orders")
+	fmt.Println(o, a.Stats().Emitted["orders"])
+
+	// Output:
+	// A: order 1
+	// B: order 2
+	// B: maintenance
+	// tenantA/orders 1
+}
+
+func ExampleBus_RegisterPattern() {
+	ctx := context.Background()
+	bus := NewBus()
+
+	bus.Emit(ctx, "user.created", stringEvent("alice"))
+	bus.RegisterPattern(ctx, "user.*", ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println(TopicFrom(ctx), e)
+	}))
+
+	// matches existing and new topics
+	bus.Emit(ctx, "user.created", stringEvent("bob"))
+	bus.Emit(ctx, "user.deleted", stringEvent("alice"))
+	bus.Emit(ctx, "order.created", stringEvent("order 1"))
+
+	// Output:
+	// user.created bob
+	// user.deleted alice
+}
+
+func ExampleBus_SetQuota() {
+	ctx := context.Background()
+	clock := newFakeClock()
+	bus := NewBus()
+
+	noisy, quiet := bus.Namespace("noisy"), bus.Namespace("quiet")
+	for _, ns := range []*Bus{noisy, quiet} {
+		ns.SetQuota(&BusQuota{Rate: 2, Clock: clock, OnReject: func(ctx context.Context, topic string, e Event, err error) {
+			fmt.Println("rejected", e, "on", topic+":", err)
+		}})
+		ns.Register(ctx, "jobs", printObserver{})
+	}
+
+	for i := 1; i <= 3; i++ {
+		noisy.Emit(ctx, "jobs", intEvent(i))
+	}
+	quiet.Emit(ctx, "jobs", intEvent(10))
+
+	stats := noisy.Stats()
+	fmt.Println(stats.Emitted["jobs"], "emitted,", stats.Rejected, "rejected")
+
+	// Output:
+	// 1
+	// 2
+	// rejected 3 on jobs: voyeur: quota exceeded: 2 events per 1s
+	// 10
+	// 2 emitted, 1 rejected
+}
+
+func TestBusQuotaBytes(t *testing.T) {
+	ctx := context.Background()
+	bus := NewBus()
+
+	var rejected int
+	bus.SetQuota(&BusQuota{
+		MaxBytes: 10,
+		Size:     func(e Event) int { return len(e.(stringEvent)) },
+		OnReject: func(context.Context, string, Event, error) { rejected++ },
+	})
+
+	// while the first event is being delivered, only 4 more bytes fit
+	bus.Register(ctx, "t", ObserverFunc(func(ctx context.Context, e Event) {
+		if e == stringEvent("123456") {
+			bus.Emit(ctx, "u", stringEvent("12345"))
+			bus.Emit(ctx, "u", stringEvent("1234"))
+		}
+	}))
+	bus.Emit(ctx, "t", stringEvent("123456"))
+
+	stats := bus.Stats()
+	if rejected != 1 || stats.Rejected != 1 {
+		t.Errorf("expected one rejection, got %d and %d", rejected, stats.Rejected)
+	}
+	if stats.PeakBytes != 10 || stats.Bytes != 0 {
+		t.Errorf("expected peak 10 and 0 bytes in flight, got %d and %d", stats.PeakBytes, stats.Bytes)
+	}
+}
+
+func TestRegisterPatternWatchers(t *testing.T) {
+	bus := NewBus()
+	before := runtime.NumGoroutine()
+
+	// watching for new topics needs no goroutine, which would leak with a context that is never cancelled
+	for i := 0; i < 10; i++ {
+		bus.RegisterPattern(context.Background(), "user.*", ObserverFunc(func(context.Context, Event) {}))
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("RegisterPattern started goroutines: %d before, %d after", before, n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bus.RegisterPattern(ctx, "user.*", ObserverFunc(func(context.Context, Event) {}))
+	cancel()
+
+	for i := 0; ; i++ {
+		bus.lock.Lock()
+		n := len(bus.watchers)
+		bus.lock.Unlock()
+
+		if n == 10 {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("watcher not removed after cancel, %d watchers", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BusQuota limits the events emitted on the topics of a Bus, see SetQuota.
+type BusQuota struct {
+	// Rate is the number of events that may be emitted per Window. Zero means no limit.
+	Rate int
+
+	// Window is the length of the windows Rate is counted in. Defaults to one second.
+	Window time.Duration
+
+	// MaxBytes is the most bytes of events that may be delivered at once. Zero means no limit.
+	MaxBytes int
+
+	// Size returns the size of an event in bytes. Defaults to counting each event as one byte.
+	Size func(Event) int
+
+	// OnReject, if not nil, is called with each rejected event and an error wrapping ErrQuotaExceeded.
+	// Use it to log, divert or count rejections, or to back off the producer.
+	OnReject func(ctx context.Context, topic string, e Event, err error)
+
+	// Clock defaults to SystemClock.
+	Clock Clock
+}
+
+// busQuota enforces a BusQuota.
+type busQuota struct {
+	BusQuota
+
+	lock     sync.Mutex
+	window   quotaWindow
+	bytes    int
+	peak     int
+	rejected uint64
+}
+
+// SetQuota limits the events emitted on the topics of b. Events over the quota are dropped and passed to OnReject.
+// End is never rejected. Give each tenant a namespace with a quota of its own, so a burst of one tenant can't
+// starve the others, see Namespace. The quota replaces the previous one; nil removes it.
+func (b *Bus) SetQuota(q *BusQuota) {
+	if b == nil {
+		return
+	}
+	if q == nil {
+		b.quota.Store(nil)
+		return
+	}
+
+	bq := &busQuota{BusQuota: *q}
+	if bq.Window <= 0 {
+		bq.Window = time.Second
+	}
+	if bq.Size == nil {
+		bq.Size = func(Event) int { return 1 }
+	}
+	if bq.Clock == nil {
+		bq.Clock = SystemClock
+	}
+
+	b.quota.Store(bq)
+}
+
+// admit counts e against the quota, returning its size, or an error if it is over the quota.
+func (q *busQuota) admit(e Event) (int, error) {
+	size := q.Size(e)
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.MaxBytes > 0 && q.bytes+size > q.MaxBytes {
+		q.rejected++
+		return 0, fmt.Errorf("%w: %d of %d bytes in flight", ErrQuotaExceeded, q.bytes, q.MaxBytes)
+	}
+
+	if q.Rate > 0 {
+		now := q.Clock.Now()
+		if now.Sub(q.window.start) >= q.Window {
+			q.window = quotaWindow{start: now}
+		}
+		if q.window.count >= q.Rate {
+			q.rejected++
+			return 0, fmt.Errorf("%w: %d events per %v", ErrQuotaExceeded, q.Rate, q.Window)
+		}
+		q.window.count++
+	}
+
+	q.bytes += size
+	if q.bytes > q.peak {
+		q.peak = q.bytes
+	}
+
+	return size, nil
+}
+
+// release marks an admitted event of the given size as delivered.
+func (q *busQuota) release(size int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.bytes -= size
+}
+
+func (q *busQuota) reject(ctx context.Context, topic string, e Event, err error) {
+	if q.OnReject != nil {
+		q.OnReject(ctx, topic, e, err)
+	}
+}
@@ -0,0 +1,213 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Capabilities describe the delivery guarantees of a component.
+type Capabilities struct {
+	// Ordered is set if observers get the events in the order they were emitted. Fair streams only keep the
+	// order of the events of each producer.
+	Ordered bool
+
+	// Synchronous is set if Emit only returns after the observers handled the event.
+	Synchronous bool
+
+	// Buffered is set if events may wait in a queue before they are delivered.
+	Buffered bool
+
+	// Lossy is set if events may be dropped, e.g. because a buffer is full or on a preemptive end.
+	Lossy bool
+
+	// Replay is the number of past events passed to observers that register late. -1 means it is not limited.
+	Replay int
+}
+
+func (c Capabilities) String() string {
+	var props []string
+	for _, p := range []struct {
+		set  bool
+		name string
+	}{
+		{c.Ordered, "ordered"},
+		{c.Synchronous, "synchronous"},
+		{c.Buffered, "buffered"},
+		{c.Lossy, "lossy"},
+	} {
+		if p.set {
+			props = append(props, p.name)
+		}
+	}
+
+	switch {
+	case c.Replay < 0:
+		props = append(props, "replays all")
+	case c.Replay > 0:
+		props = append(props, fmt.Sprintf("replays %d", c.Replay))
+	}
+
+	return strings.Join(props, ", ")
+}
+
+// Then returns the capabilities of c followed by next, e.g. of two filters registered on each other.
+func (c Capabilities) Then(next Capabilities) Capabilities {
+	return Capabilities{
+		Ordered:     c.Ordered && next.Ordered,
+		Synchronous: c.Synchronous && next.Synchronous,
+		Buffered:    c.Buffered || next.Buffered,
+		Lossy:       c.Lossy || next.Lossy,
+		Replay:      next.Replay,
+	}
+}
+
+// Capable is implemented by components to report their delivery guarantees, so middleware and bridges can adapt
+// to them. The streams, filters, emitters and stores of this package are all Capable, and so are Bus, Group,
+// History and Scheduler.
+type Capable interface {
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf returns the capabilities of v, and whether v reported them.
+func CapabilitiesOf(v interface{}) (Capabilities, bool) {
+	if c, ok := v.(Capable); ok {
+		return c.Capabilities(), true
+	}
+
+	return Capabilities{}, false
+}
+
+// passThrough are the capabilities of components that pass events on right away.
+var passThrough = Capabilities{Ordered: true, Synchronous: true}
+
+// capabilitiesOr returns the capabilities of v, or those of a pass-through component if it didn't report them.
+func capabilitiesOr(v interface{}) Capabilities {
+	if c, ok := CapabilitiesOf(v); ok {
+		return c
+	}
+
+	return passThrough
+}
+
+func (o *observable) Capabilities() Capabilities {
+	synchronous := o.fair == nil && o.queue == nil && !o.cfg.async
+	return Capabilities{
+		Ordered:     o.fair == nil,
+		Synchronous: synchronous,
+		Buffered:    !synchronous,
+		Lossy:       o.cfg.preempt,
+	}
+}
+
+func (em *emitter) Capabilities() Capabilities {
+	return (*observable)(em).Capabilities()
+}
+
+func (m *mapFilter) Capabilities() Capabilities {
+	return m.o.Capabilities()
+}
+
+func (b *branch) Capabilities() Capabilities {
+	c := capabilitiesOr(b.then)
+	els := capabilitiesOr(b.els)
+	c.Ordered = c.Ordered && els.Ordered
+	c.Synchronous = c.Synchronous && els.Synchronous
+	c.Buffered = c.Buffered || els.Buffered
+	c.Lossy = c.Lossy || els.Lossy
+
+	return c.Then(b.o.Capabilities())
+}
+
+func (p *Pipeline) Capabilities() Capabilities {
+	caps := passThrough
+	for _, stage := range p.stages {
+		caps = caps.Then(capabilitiesOr(stage))
+	}
+
+	return caps
+}
+
+// Capabilities replay N events, or all of them if they are retained by key.
+func (r *retainer) Capabilities() Capabilities {
+	c := r.o.Capabilities()
+	c.Replay = r.opts.N
+	if r.opts.Key != nil {
+		c.Replay = -1
+	}
+
+	return c
+}
+
+func (l *limiter) Capabilities() Capabilities {
+	return Capabilities{Ordered: true, Buffered: true, Lossy: true}
+}
+
+func (db *debounce) Capabilities() Capabilities {
+	return Capabilities{Ordered: true, Lossy: true}
+}
+
+func (b *batch) Capabilities() Capabilities {
+	return Capabilities{Ordered: true, Buffered: true}.Then(b.o.Capabilities())
+}
+
+func (b *BufferedEmitter) Capabilities() Capabilities {
+	return Capabilities{Ordered: true, Buffered: true, Lossy: b.policy != Block}.Then(capabilitiesOr(b.em))
+}
+
+func (q *QuotaEmitter) Capabilities() Capabilities {
+	return Capabilities{Ordered: true, Synchronous: true, Lossy: q.opts.Limit > 0}.Then(capabilitiesOr(q.em))
+}
+
+func (a *aggregate[S]) Capabilities() Capabilities {
+	return a.o.Capabilities()
+}
+
+func (g *Group) Capabilities() Capabilities {
+	return capabilitiesOr(g.o)
+}
+
+// Capabilities are lossy if a quota is set, see SetQuota.
+func (b *Bus) Capabilities() Capabilities {
+	c := passThrough
+	if b != nil && b.quota.Load() != nil {
+		c.Lossy = true
+	}
+
+	return c
+}
+
+func (t *busTopic) Capabilities() Capabilities {
+	return t.bus.Capabilities().Then(capabilitiesOr(t.o))
+}
+
+// Capabilities are those of the stream of changes. The state passed by RegisterSnapshot is no replay of events.
+func (s *Store[V]) Capabilities() Capabilities {
+	return capabilitiesOr(s.o)
+}
+
+func (h *History) Capabilities() Capabilities {
+	return capabilitiesOr(h.em)
+}
+
+// Capabilities are neither ordered nor synchronous, as scheduled events are emitted when they are due, and
+// lossy, as events scheduled after End are dropped.
+func (s *Scheduler) Capabilities() Capabilities {
+	return Capabilities{Buffered: true, Lossy: true}.Then(capabilitiesOr(s.em))
+}
@@ -0,0 +1,64 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleCapabilitiesOf() {
+	_, sync := Pair()
+	_, async := Pair(WithAsync(), WithBuffer(8), WithPreemptiveEnd())
+	chain := Chain(Replay(3), Batch(10, time.Second))
+
+	for _, v := range []interface{}{sync, async, chain, NewBufferedEmitter(nil, 8, DropOldest)} {
+		caps, _ := CapabilitiesOf(v)
+		fmt.Println(caps)
+	}
+
+	// Output:
+	// ordered, synchronous
+	// ordered, buffered, lossy
+	// ordered, buffered
+	// ordered, buffered, lossy
+}
+
+func TestCapableComponents(t *testing.T) {
+	bus := NewBus()
+	topicEm, topicO := bus.Topic("a")
+
+	for _, v := range []interface{}{
+		Aggregate(0, func(n int, e Event) int { return n + 1 }),
+		NewGroup(),
+		bus, topicEm, topicO,
+		NewStore[int](),
+		NewHistory(nil),
+		NewScheduler(NopEmitter),
+	} {
+		if _, ok := CapabilitiesOf(v); !ok {
+			t.Errorf("%v didn't report its capabilities", NameOf(v))
+		}
+	}
+
+	bus.SetQuota(&BusQuota{})
+	if c, _ := CapabilitiesOf(topicEm); !c.Lossy {
+		t.Errorf("expected a topic of a bus with quota to be lossy, got %v", c)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+)
+
+// Child returns an Observable with the events of parent that ends when parent ends or ctx is cancelled,
+// whichever happens first. Observers of the child are thereby released with the scope that owns it.
+func Child(ctx context.Context, parent Observable, opts ...Option) Observable {
+	em, o := Pair(opts...)
+
+	var (
+		lock  sync.Mutex
+		ended = make(chan struct{})
+	)
+	emit := func(ctx context.Context, e Event) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		select {
+		case <-ended:
+			return
+		default:
+		}
+		if e == End {
+			close(ended)
+		}
+
+		em.Emit(ctx, e)
+	}
+
+	observableOrNop(parent).Register(ctx, ObserverFunc(emit))
+	go func() {
+		select {
+		case <-ctx.Done():
+			emit(ctx, End)
+		case <-ended:
+		}
+	}()
+
+	return o
+}
@@ -0,0 +1,51 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+)
+
+func ExampleChild() {
+	ctx := context.Background()
+	em, parent := Pair()
+
+	scope, cancel := context.WithCancel(ctx)
+	child := Child(scope, parent)
+
+	done := make(chan struct{})
+	child.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println(e)
+		if e == End {
+			close(done)
+		}
+	}))
+
+	em.Emit(ctx, stringEvent("a"))
+
+	// the child ends with its scope, while the parent goes on
+	cancel()
+	<-done
+
+	em.Emit(ctx, stringEvent("b"))
+
+	// Output:
+	// a
+	// End
+}
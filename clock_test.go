@@ -0,0 +1,166 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time only moves when told so.
+type fakeClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers map[*fakeTimer]struct{}
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:    time.Unix(0, 0),
+		timers: make(map[*fakeTimer]struct{}),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+// Advance moves the time forward by d and fires the timers and tickers that are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+	for t := range c.timers {
+		for !t.deadline.After(c.now) {
+			select {
+			case t.c <- t.deadline:
+			default:
+			}
+
+			if t.period == 0 {
+				delete(c.timers, t)
+				break
+			}
+			t.deadline = t.deadline.Add(t.period)
+		}
+	}
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	return c.add(d, 0)
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	return fakeTicker{c.add(d, d)}
+}
+
+func (c *fakeClock) add(d, period time.Duration) *fakeTimer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := &fakeTimer{
+		clock:    c,
+		c:        make(chan time.Time, 1),
+		deadline: c.now.Add(d),
+		period:   period,
+	}
+	c.timers[t] = struct{}{}
+	return t
+}
+
+type fakeTimer struct {
+	clock    *fakeClock
+	c        chan time.Time
+	deadline time.Time
+	period   time.Duration
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+
+	_, active := t.clock.timers[t]
+	delete(t.clock.timers, t)
+	return active
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+
+	_, active := t.clock.timers[t]
+	t.deadline = t.clock.now.Add(d)
+	t.clock.timers[t] = struct{}{}
+	return active
+}
+
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
+
+func TestPlayerClock(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	events := []Recorded{
+		{Time: clock.Now(), Event: stringEvent("a")},
+		{Time: clock.Now().Add(time.Hour), Event: stringEvent("b")},
+	}
+
+	em, o := Pair()
+	seen := make(chan Event, 2)
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		seen <- e
+	}))
+
+	done := make(chan error)
+	go func() { done <- NewPlayer(events, 1, WithClock(clock)).Play(ctx, em) }()
+
+	if e := <-seen; e != stringEvent("a") {
+		t.Fatalf("expected a, got %v", e)
+	}
+
+	// the player may not have started its timer yet, so keep advancing until it fires
+	for {
+		clock.Advance(time.Hour)
+		select {
+		case e := <-seen:
+			if e != stringEvent("b") {
+				t.Fatalf("expected b, got %v", e)
+			}
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,61 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+)
+
+// Closer is implemented by filters that hold resources like timers, files or connections.
+// Close is called once, when the filter is no longer used. See Connect.
+type Closer interface {
+	Close() error
+}
+
+// Connect registers f on from. If f is a Closer, it is closed after it observed End or when ctx is cancelled,
+// whichever happens first.
+func Connect(ctx context.Context, from Observable, f Filter) {
+	from = observableOrNop(from)
+	closer, ok := f.(Closer)
+	if !ok {
+		from.Register(ctx, f)
+		return
+	}
+
+	var closeOnce, doneOnce sync.Once
+	closeF := func() { closeOnce.Do(func() { closer.Close() }) }
+
+	// from may deliver End more than once, e.g. if it is a custom Observable
+	done := make(chan struct{})
+	from.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		f.OnEvent(ctx, e)
+		if e == End {
+			closeF()
+			doneOnce.Do(func() { close(done) })
+		}
+	}))
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			closeF()
+		case <-done:
+		}
+	}()
+}
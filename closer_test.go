@@ -0,0 +1,88 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleConnect() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	f := Map(func(ctx context.Context, em Emitter, e Event) {
+		em.Emit(ctx, e)
+	}, WithClose(func() error {
+		fmt.Println("closed")
+		return nil
+	}))
+
+	Connect(ctx, o, f)
+	f.Register(ctx, printObserver{})
+
+	em.Emit(ctx, stringEvent("a"))
+	em.End(ctx)
+
+	// Output:
+	// a
+	// End
+	// closed
+}
+
+func TestConnectCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, o := Pair()
+
+	closed := make(chan struct{})
+	f := Map(func(ctx context.Context, em Emitter, e Event) {}, WithClose(func() error {
+		close(closed)
+		return nil
+	}))
+
+	Connect(ctx, o, f)
+	cancel()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("filter not closed on cancel")
+	}
+}
+
+func TestConnectDoubleEnd(t *testing.T) {
+	ctx := context.Background()
+
+	closed := 0
+	f := Map(func(ctx context.Context, em Emitter, e Event) {}, WithClose(func() error {
+		closed++
+		return nil
+	}))
+
+	// a custom source that ends twice
+	Connect(ctx, ObservableFunc(func(ctx context.Context, oer Observer) {
+		oer.OnEvent(ctx, End)
+		oer.OnEvent(ctx, End)
+	}), f)
+
+	if closed != 1 {
+		t.Errorf("expected the filter to be closed once, got %d", closed)
+	}
+}
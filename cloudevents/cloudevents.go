@@ -0,0 +1,257 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+Package cloudevents converts between voyeur events and CloudEvents 1.0 (https://cloudevents.io),
+in the JSON format and the HTTP binary and structured content modes.
+Extension attributes are not supported.
+*/
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+// SpecVersion is the CloudEvents version implemented by this package.
+const SpecVersion = "1.0"
+
+// ContentType is the media type of structured mode messages.
+const ContentType = "application/cloudevents+json"
+
+// CloudEvent is a CloudEvent in its JSON format. Data is always JSON.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            *time.Time      `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// Validate checks that the required attributes are set. A nil event is invalid.
+func (ce *CloudEvent) Validate() error {
+	switch {
+	case ce == nil:
+		return fmt.Errorf("cloudevents: missing event")
+	case ce.SpecVersion != SpecVersion:
+		return fmt.Errorf("cloudevents: unsupported specversion %q", ce.SpecVersion)
+	case ce.ID == "":
+		return fmt.Errorf("cloudevents: missing id")
+	case ce.Source == "":
+		return fmt.Errorf("cloudevents: missing source")
+	case ce.Type == "":
+		return fmt.Errorf("cloudevents: missing type")
+	}
+
+	return nil
+}
+
+// Encoder turns voyeur events into CloudEvents. The CloudEvent type is the EventType of the event
+// and the data is the event marshaled to JSON.
+type Encoder struct {
+	// Source is used as source attribute of all CloudEvents.
+	Source string
+
+	// NewID returns the id attribute. If nil, random ids are used.
+	NewID func() string
+
+	// Clock sets the time attribute. If nil, voyeur.SystemClock is used.
+	Clock voyeur.Clock
+}
+
+func randomID() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(buf[:])
+}
+
+// Encode returns the CloudEvent for e.
+func (enc Encoder) Encode(e voyeur.Event) (*CloudEvent, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("cloudevents: marshaling data: %w", err)
+	}
+
+	newID, clock := enc.NewID, enc.Clock
+	if newID == nil {
+		newID = randomID
+	}
+	if clock == nil {
+		clock = voyeur.SystemClock
+	}
+
+	now := clock.Now().UTC()
+	return &CloudEvent{
+		SpecVersion:     SpecVersion,
+		ID:              newID(),
+		Source:          enc.Source,
+		Type:            e.EventType(),
+		Time:            &now,
+		DataContentType: "application/json",
+		Data:            data,
+	}, nil
+}
+
+// Decoder turns CloudEvents into voyeur events, using a TypeRegistry to find the Go type for the CloudEvent type.
+type Decoder struct {
+	// Types is used to look up Go types. If nil, the default registry is used, see voyeur.RegisterType.
+	Types *voyeur.TypeRegistry
+}
+
+// Decode unmarshals the data of ce into a value of the Go type registered for its type.
+func (dec Decoder) Decode(ce *CloudEvent) (voyeur.Event, error) {
+	if err := ce.Validate(); err != nil {
+		return nil, err
+	}
+
+	types := dec.Types
+	if types == nil {
+		types = voyeur.DefaultTypes()
+	}
+
+	e, err := types.Unmarshal(ce.Type, func(v interface{}) error {
+		if len(ce.Data) == 0 {
+			return nil
+		}
+		return json.Unmarshal(ce.Data, v)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cloudevents: %w", err)
+	}
+
+	return e, nil
+}
+
+// NewRequest returns a POST request to url carrying ce, in binary mode if binary is true and in structured mode otherwise.
+func NewRequest(ctx context.Context, url string, ce *CloudEvent, binary bool) (*http.Request, error) {
+	if !binary {
+		body, err := json.Marshal(ce)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", ContentType)
+		return req, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(ce.Data))
+	if err != nil {
+		return nil, err
+	}
+
+	h := req.Header
+	h.Set("ce-specversion", ce.SpecVersion)
+	h.Set("ce-id", ce.ID)
+	h.Set("ce-source", ce.Source)
+	h.Set("ce-type", ce.Type)
+	if ce.Subject != "" {
+		h.Set("ce-subject", ce.Subject)
+	}
+	if ce.Time != nil {
+		h.Set("ce-time", ce.Time.Format(time.RFC3339Nano))
+	}
+	if ce.DataContentType != "" {
+		h.Set("Content-Type", ce.DataContentType)
+	}
+
+	return req, nil
+}
+
+// ReadRequest reads the CloudEvent carried by req, in either binary or structured mode.
+func ReadRequest(req *http.Request) (*CloudEvent, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	ce := new(CloudEvent)
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == ContentType {
+		if err := json.Unmarshal(body, ce); err != nil {
+			return nil, fmt.Errorf("cloudevents: unmarshaling structured event: %w", err)
+		}
+
+		return ce, ce.Validate()
+	}
+
+	h := req.Header
+	ce.SpecVersion = h.Get("ce-specversion")
+	ce.ID = h.Get("ce-id")
+	ce.Source = h.Get("ce-source")
+	ce.Type = h.Get("ce-type")
+	ce.Subject = h.Get("ce-subject")
+	ce.DataContentType = h.Get("Content-Type")
+	ce.Data = body
+
+	if ts := h.Get("ce-time"); ts != "" {
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return nil, fmt.Errorf("cloudevents: parsing time: %w", err)
+		}
+		ce.Time = &t
+	}
+
+	if ce.DataContentType != "" && !strings.HasSuffix(mediaType, "json") {
+		return nil, fmt.Errorf("cloudevents: unsupported content type %q", ce.DataContentType)
+	}
+
+	return ce, ce.Validate()
+}
+
+// Handler returns an http.Handler that decodes CloudEvents from incoming requests and emits them on em.
+// It accepts events from anyone; see package auth for protecting it.
+func Handler(em voyeur.Emitter, dec Decoder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ce, err := ReadRequest(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		e, err := dec.Decode(ce)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		em.Emit(req.Context(), e)
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
@@ -0,0 +1,110 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cloudevents
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+type orderCreated struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+}
+
+func (orderCreated) EventType() string {
+	return "com.example.order.created"
+}
+
+func TestRoundTrip(t *testing.T) {
+	types := voyeur.NewTypeRegistry()
+	if err := types.Register(orderCreated{}); err != nil {
+		t.Fatal(err)
+	}
+
+	enc := Encoder{Source: "/orders", NewID: func() string { return "1" }}
+	dec := Decoder{Types: types}
+
+	for _, binary := range []bool{false, true} {
+		em, o := voyeur.Pair()
+		got := make(chan voyeur.Event, 1)
+		o.Register(context.Background(), voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+			got <- e
+		}))
+
+		srv := httptest.NewServer(Handler(em, dec))
+
+		ce, err := enc.Encode(orderCreated{ID: "a", Total: 42})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := NewRequest(context.Background(), srv.URL, ce, binary)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		srv.Close()
+
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("binary=%v: expected status 202, got %d", binary, resp.StatusCode)
+		}
+
+		select {
+		case e := <-got:
+			if e != (orderCreated{ID: "a", Total: 42}) {
+				t.Errorf("binary=%v: got %#v", binary, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("binary=%v: no event received", binary)
+		}
+	}
+}
+
+func TestReadRequestInvalid(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("ce-specversion", "0.3")
+	req.Header.Set("ce-id", "1")
+	req.Header.Set("ce-source", "/x")
+	req.Header.Set("ce-type", "x")
+
+	if _, err := ReadRequest(req); err == nil {
+		t.Error("expected error for unsupported specversion")
+	}
+}
+
+func TestValidateNil(t *testing.T) {
+	var ce *CloudEvent
+	if err := ce.Validate(); err == nil {
+		t.Error("nil event is valid")
+	}
+
+	if _, err := (Decoder{}).Decode(nil); err == nil {
+		t.Error("nil event decoded")
+	}
+}
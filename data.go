@@ -0,0 +1,79 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"fmt"
+)
+
+// DataEvent is an event of any type with a dynamic payload, for prototyping and for events from dynamic
+// sources like webhooks and scripts. Data typically is a map[string]interface{} as decoded by encoding/json.
+// Encoded, a DataEvent is just its Data. See TypeRegistry.SetFallback for decoding.
+type DataEvent struct {
+	Type string
+	Data interface{}
+}
+
+func (e DataEvent) EventType() string {
+	return e.Type
+}
+
+func (e DataEvent) String() string {
+	return fmt.Sprintf("%s %v", e.Type, e.Data)
+}
+
+// Get returns the value at path in nested maps, e.g. Get("user", "name").
+func (e DataEvent) Get(path ...string) (interface{}, bool) {
+	v := e.Data
+	for _, key := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+// GetString returns the string at path, or "" if there is none.
+func (e DataEvent) GetString(path ...string) string {
+	v, _ := e.Get(path...)
+	s, _ := v.(string)
+	return s
+}
+
+// GetFloat returns the number at path, or 0 if there is none.
+func (e DataEvent) GetFloat(path ...string) float64 {
+	v, _ := e.Get(path...)
+	switch v := v.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case interface{ Float64() (float64, error) }:
+		// a json.Number
+		f, _ := v.Float64()
+		return f
+	}
+
+	return 0
+}
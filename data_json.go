@@ -0,0 +1,38 @@
+//go:build !voyeurminimal
+
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"encoding/json"
+)
+
+func (e DataEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Data)
+}
+
+// Decode copies Data into v, as if Data was encoded to and decoded from JSON.
+func (e DataEvent) Decode(v interface{}) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,56 @@
+//go:build !voyeurminimal
+
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func ExampleDataEvent() {
+	types := NewTypeRegistry()
+	types.SetFallback(true)
+
+	// an event of a type nobody registered, e.g. from a webhook
+	data := []byte(`{"user": {"name": "alice"}, "amount": 12.5}`)
+	e, err := types.Unmarshal("payment.received", func(v interface{}) error {
+		return json.Unmarshal(data, v)
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	de := e.(DataEvent)
+	fmt.Println(de.EventType(), de.GetString("user", "name"), de.GetFloat("amount"))
+
+	var payment struct{ Amount float64 }
+	de.Decode(&payment)
+	fmt.Println(payment.Amount)
+
+	// encoded, it's just the data again
+	out, _ := json.Marshal(de)
+	fmt.Println(string(out))
+
+	// Output:
+	// payment.received alice 12.5
+	// 12.5
+	// {"amount":12.5,"user":{"name":"alice"}}
+}
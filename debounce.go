@@ -0,0 +1,168 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type debounce struct {
+	wait time.Duration
+	em   Emitter
+	o    *observable
+
+	lock    sync.Mutex
+	pending *delivery
+	ended   bool
+	wake    chan struct{}
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Debounce returns a Filter that coalesces bursts of events: it only emits an event once no other event followed
+// it for d, and drops the events it was followed by within d. Use it for rapid-fire events like file system
+// notifications, where only the state after the burst matters. End emits the pending event, if any, and is
+// passed on. The WithClock option sets the Clock used for waiting. The Filter is a Closer; closing it before End
+// stops its goroutine and drops the pending event.
+func Debounce(d time.Duration, opts ...Option) Filter {
+	em, o := Pair(opts...)
+	db := &debounce{
+		wait:   d,
+		em:     em,
+		o:      o.(*observable),
+		wake:   make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+
+	go db.run()
+	return db
+}
+
+func (db *debounce) OnEvent(ctx context.Context, e Event) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.ended {
+		return
+	}
+
+	if e == End {
+		db.ended = true
+		if db.pending != nil {
+			db.em.Emit(db.pending.ctx, db.pending.e)
+			db.pending = nil
+		}
+		db.em.End(ctx)
+	} else {
+		db.pending = &delivery{ctx: ctx, e: e}
+	}
+
+	select {
+	case db.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run emits the pending event once no event came in for the wait time, until End or Close.
+func (db *debounce) run() {
+	var (
+		t    Timer
+		fire <-chan time.Time
+	)
+
+	for {
+		select {
+		case <-db.wake:
+			if t != nil {
+				t.Stop()
+			}
+
+			db.lock.Lock()
+			ended := db.ended
+			db.lock.Unlock()
+			if ended {
+				return
+			}
+
+			t = db.o.cfg.clock.NewTimer(db.wait)
+			fire = t.C()
+		case <-fire:
+			t, fire = nil, nil
+
+			db.lock.Lock()
+			if db.pending != nil {
+				db.em.Emit(db.pending.ctx, db.pending.e)
+				db.pending = nil
+			}
+			db.lock.Unlock()
+		case <-db.closed:
+			if t != nil {
+				t.Stop()
+			}
+			return
+		}
+	}
+}
+
+func (db *debounce) Close() error {
+	db.closeOnce.Do(func() { close(db.closed) })
+	return nil
+}
+
+func (db *debounce) Register(ctx context.Context, oer Observer) {
+	db.o.Register(ctx, oer)
+}
+
+func (db *debounce) Name() string {
+	return db.o.Name()
+}
+
+func (db *debounce) String() string {
+	return nameOr(db.o.Name(), "debounce", db)
+}
+
+// Throttle returns a Filter that emits at most one event per d: an event is dropped if the previous emitted
+// event is less than d older. End is passed on. The WithClock option sets the Clock used for telling the time.
+func Throttle(d time.Duration, opts ...Option) Filter {
+	var (
+		lock sync.Mutex
+		last time.Time
+		sent bool
+	)
+	clock := newConfig(opts).clock
+
+	return Map(func(ctx context.Context, em Emitter, e Event) {
+		if e == End {
+			return
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		now := clock.Now()
+		if sent && now.Sub(last) < d {
+			return
+		}
+
+		last, sent = now, true
+		em.Emit(ctx, e)
+	}, opts...)
+}
@@ -0,0 +1,105 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestDebounce(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	em, o := Pair()
+
+	got := make(chan Event, 10)
+	db := Debounce(time.Second, WithClock(clock))
+	o.Register(ctx, db)
+	db.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		got <- e
+	}))
+
+	for i := 1; i <= 3; i++ {
+		em.Emit(ctx, intEvent(i))
+	}
+
+	// only the last event of the burst comes through once it is quiet
+	for waiting := true; waiting; {
+		clock.Advance(time.Second)
+		select {
+		case e := <-got:
+			if e != intEvent(3) {
+				t.Fatalf("expected 3, got %v", e)
+			}
+			waiting = false
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	// End flushes the pending event right away
+	em.Emit(ctx, intEvent(4))
+	em.End(ctx)
+	for _, exp := range []Event{intEvent(4), End} {
+		if e := <-got; e != exp {
+			t.Fatalf("expected %v, got %v", exp, e)
+		}
+	}
+}
+
+func TestDebounceClose(t *testing.T) {
+	ctx := context.Background()
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 10; i++ {
+		db := Debounce(time.Hour)
+		db.OnEvent(ctx, intEvent(i))
+
+		// End never comes
+		db.(Closer).Close()
+	}
+
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 100 {
+			t.Fatalf("goroutines leaked: %d before, %d after closing", before, runtime.NumGoroutine())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func ExampleThrottle() {
+	ctx := context.Background()
+	clock := newFakeClock()
+	em, o := Pair()
+
+	th := Throttle(time.Second, WithClock(clock))
+	o.Register(ctx, th)
+	th.Register(ctx, printObserver{})
+
+	em.Emit(ctx, intEvent(1))
+	em.Emit(ctx, intEvent(2))
+	clock.Advance(500 * time.Millisecond)
+	em.Emit(ctx, intEvent(3))
+	clock.Advance(500 * time.Millisecond)
+	em.Emit(ctx, intEvent(4))
+
+	// Output:
+	// 1
+	// 4
+}
@@ -0,0 +1,59 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// DebugBlockLimit is how long OnEvent may take before debug builds report a violation.
+var DebugBlockLimit = time.Second
+
+// DebugHandler is called by debug builds when an invariant is violated, with a description and the stack
+// where it was detected. It prints both to stderr by default.
+//
+// Debug builds are made using the voyeurdebug build tag. They check that
+//   - no event is emitted after End,
+//   - Register is not called with a nil Observer, and
+//   - OnEvent doesn't block longer than DebugBlockLimit.
+var DebugHandler = func(msg string, stack []byte) {
+	fmt.Fprintf(os.Stderr, "voyeur: %s\n%s", msg, stack)
+}
+
+// violation reports a violated invariant. Only call it if debugMode is set.
+func violation(format string, args ...interface{}) {
+	DebugHandler(fmt.Sprintf(format, args...), debug.Stack())
+}
+
+// callObserver passes e to oer, checking how long it takes in debug builds.
+func callObserver(ctx context.Context, oer Observer, e Event) {
+	if !debugMode {
+		oer.OnEvent(ctx, e)
+		return
+	}
+
+	start := time.Now()
+	oer.OnEvent(ctx, e)
+	if took := time.Since(start); took > DebugBlockLimit {
+		violation("OnEvent of %v blocked for %v handling %v", oer, took, e)
+	}
+}
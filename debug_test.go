@@ -0,0 +1,73 @@
+//go:build voyeurdebug
+
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugViolations(t *testing.T) {
+	var msgs []string
+	defer func(h func(string, []byte), limit time.Duration) {
+		DebugHandler, DebugBlockLimit = h, limit
+	}(DebugHandler, DebugBlockLimit)
+
+	DebugHandler = func(msg string, stack []byte) {
+		if len(stack) == 0 {
+			t.Error("no stack for", msg)
+		}
+		msgs = append(msgs, msg)
+	}
+	DebugBlockLimit = 10 * time.Millisecond
+
+	ctx := context.Background()
+
+	_, o := Pair(WithName("nil"))
+	o.Register(ctx, nil)
+
+	em, o := Pair(WithName("slow"))
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if e == stringEvent("slow") {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}))
+
+	em.Emit(ctx, stringEvent("fast"))
+	em.Emit(ctx, stringEvent("slow"))
+	em.End(ctx)
+	em.Emit(ctx, stringEvent("late"))
+
+	want := []string{
+		"Register called with nil Observer on nil",
+		"blocked for",
+		"late emitted after End on slow",
+	}
+	if len(msgs) != len(want) {
+		t.Fatalf("expected %d violations, got %q", len(want), msgs)
+	}
+	for i := range want {
+		if !strings.Contains(msgs[i], want[i]) {
+			t.Errorf("expected violation %q, got %q", want[i], msgs[i])
+		}
+	}
+}
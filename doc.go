@@ -17,5 +17,13 @@
 
 /*
 voyeur helps you build applications using the Observer pattern. If you want to know more about it, check out https://en.wikipedia.org/wiki/Observer_pattern.
+
+This package only depends on the standard library. Bridges to networks and cloud services, codecs, persistence
+and metrics live in subpackages, so they are only compiled in if they are used. For embedded targets, the
+voyeurminimal build tag also leaves out the JSON helpers of DataEvent and the IDGenerators, which pull in
+encoding/json and crypto/rand. The package builds with TinyGo, which CI checks, except for FilterBuilder, which
+calls functions through reflection and is left out by the tinygo build tag. Registering on and emitting to
+synchronous streams, including the ones of Map, starts no goroutines; only cancelling a registration context
+does. The voyeurdebug build tag enables runtime checks, see DebugHandler.
 */
 package voyeur
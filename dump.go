@@ -0,0 +1,60 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DumpOptions configure Dump.
+type DumpOptions struct {
+	// Prefix is written at the start of each dumped event, e.g. to tell several dumps apart.
+	Prefix string
+
+	// Color highlights the event type using ANSI escape codes.
+	Color bool
+}
+
+// Dump returns a Filter that writes a human-readable line for each event to w and passes the event on unchanged.
+// A line contains a sequence number, the EventType, the Go type and the event itself.
+func Dump(w io.Writer, opts DumpOptions) Filter {
+	var (
+		lock sync.Mutex
+		seq  int
+	)
+
+	typeFmt := "%s"
+	if opts.Color {
+		typeFmt = "\x1b[1;36m%s\x1b[0m"
+	}
+
+	return Map(func(ctx context.Context, em Emitter, e Event) {
+		func() {
+			lock.Lock()
+			defer lock.Unlock()
+
+			seq++
+			fmt.Fprintf(w, "%s#%d "+typeFmt+" %T: %+v\n", opts.Prefix, seq, e.EventType(), e, e)
+		}()
+
+		em.Emit(ctx, e)
+	})
+}
@@ -0,0 +1,41 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"os"
+)
+
+func ExampleDump() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	dump := Dump(os.Stdout, DumpOptions{Prefix: "in: "})
+	o.Register(ctx, dump)
+	dump.Register(ctx, printObserver{})
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, intEvent(2))
+
+	// Output:
+	// in: #1 string voyeur.stringEvent: a
+	// a
+	// in: #2 int voyeur.intEvent: 2
+	// 2
+}
@@ -0,0 +1,83 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrEvent reports a failure, e.g. an event that couldn't be decoded or an observer that panicked.
+// It is an error itself and unwraps to Err.
+type ErrEvent struct {
+	Err error
+
+	// Stack is the stack trace of where the failure was reported, if captured.
+	Stack []byte
+
+	// Event is the event that was being handled when the failure happened, if any.
+	Event Event
+}
+
+// NewErrEvent returns an ErrEvent for err, which happened while handling origin. origin may be nil.
+func NewErrEvent(err error, origin Event) ErrEvent {
+	return ErrEvent{Err: err, Event: origin}
+}
+
+// WithStack returns e with the current stack trace.
+func (e ErrEvent) WithStack() ErrEvent {
+	e.Stack = debug.Stack()
+	return e
+}
+
+func (ErrEvent) EventType() string {
+	return "error"
+}
+
+func (e ErrEvent) Error() string {
+	return e.Err.Error()
+}
+
+func (e ErrEvent) Unwrap() error {
+	return e.Err
+}
+
+// PanicError is the error of an observer that panicked, see WithRecovery.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprint("voyeur: observer panicked: ", err.Value)
+}
+
+// ErrorsTo returns a function that emits an ErrEvent for each error passed to it. Use it as the OnError
+// callback of sources and bridges, so failures end up on a stream of their own.
+func ErrorsTo(ctx context.Context, em Emitter) func(error) {
+	em = emitterOrNop(em)
+	return func(err error) {
+		if ee, ok := err.(ErrEvent); ok {
+			em.Emit(ctx, ee)
+			return
+		}
+
+		em.Emit(ctx, NewErrEvent(err, nil))
+	}
+}
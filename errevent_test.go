@@ -0,0 +1,69 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+func ExampleErrorsTo() {
+	ctx := context.Background()
+	em, o := Pair()
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		ee := e.(ErrEvent)
+		fmt.Println(ee.EventType(), ee, ee.Event, len(ee.Stack) > 0)
+	}))
+
+	onError := ErrorsTo(ctx, em)
+	onError(errors.New("decoding failed"))
+	onError(NewErrEvent(errors.New("observer panicked"), stringEvent("a")).WithStack())
+
+	// Output:
+	// error decoding failed <nil> false
+	// error observer panicked a true
+}
+
+func ExampleWithRecovery() {
+	ctx := context.Background()
+
+	errs, eo := Pair()
+	eo.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		var pe *PanicError
+		if errors.As(e.(ErrEvent), &pe) {
+			fmt.Println("recovered:", pe.Value, "while handling", e.(ErrEvent).Event)
+		}
+	}))
+
+	em, o := Pair(WithRecovery(ErrorsTo(ctx, errs)))
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if e == stringEvent("b") {
+			panic("boom")
+		}
+	}))
+	o.Register(ctx, printObserver{})
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("b"))
+
+	// Output:
+	// a
+	// recovered: boom while handling b
+	// b
+}
@@ -0,0 +1,134 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur_test
+
+import (
+	"context"
+	"fmt"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/voyeurtest"
+)
+
+// printObserver simply prints all received events to stdout
+type printObserver struct{}
+
+func (o printObserver) OnEvent(ctx context.Context, e voyeur.Event) {
+	fmt.Println(e)
+}
+
+// stringEvent is a very simple event type
+type stringEvent string
+
+func (e stringEvent) EventType() string {
+	return "string"
+}
+
+func (e stringEvent) String() string {
+	return string(e)
+}
+
+func Example() {
+	// build a context we can cancel
+	ctx := context.Background()
+	ctx, cancel := context.WithCancel(ctx)
+	em, o := voyeur.Pair()
+
+	// Sync makes cancellation take effect right away, so the example needn't wait for the scheduler
+	o = voyeurtest.Sync(o)
+
+	// create and register a very simple observer. It just prints events to stdout.
+	printer := printObserver{}
+	o.Register(ctx, printer)
+
+	// emit some events.
+	em.Emit(ctx, stringEvent("test"))
+	em.Emit(ctx, stringEvent("foo"))
+
+	// cancel the observation. This only affects observers that use the context returned by context.WithCancel(ctx)
+	cancel()
+
+	// this event is not seen by the observer anymore, because we cancelled the observation before.
+	em.Emit(ctx, stringEvent("bar"))
+
+	// let's reregister, this time without being able to cancel
+	ctx = context.Background()
+	o.Register(ctx, printer)
+
+	em.End(ctx)
+
+	// Output:
+	// test
+	// foo
+	// End
+}
+
+func ExampleFilter() {
+	var strEv stringEvent
+	ctx := context.Background()
+
+	m := voyeur.Map(func(ctx context.Context, em voyeur.Emitter, e voyeur.Event) {
+		strEv += e.(stringEvent)
+		em.Emit(ctx, strEv)
+	})
+
+	em, o := voyeur.Pair()
+
+	o.Register(ctx, m)
+	m.Register(ctx, printObserver{})
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("b"))
+	em.Emit(ctx, stringEvent("c"))
+
+	// Output:
+	// a
+	// ab
+	// abc
+}
+
+func ExampleObservableFunc() {
+	ctx := context.Background()
+
+	// a source that passes each observer the same two events
+	o := voyeur.ObservableFunc(func(ctx context.Context, oer voyeur.Observer) {
+		oer.OnEvent(ctx, stringEvent("hello"))
+		oer.OnEvent(ctx, voyeur.End)
+	})
+
+	o.Register(ctx, printObserver{})
+
+	// Output:
+	// hello
+	// End
+}
+
+func ExampleEmitterFunc() {
+	ctx := context.Background()
+
+	var em voyeur.Emitter = voyeur.EmitterFunc(func(ctx context.Context, e voyeur.Event) {
+		fmt.Println("emitted", e)
+	})
+
+	em.Emit(ctx, stringEvent("a"))
+	em.End(ctx)
+
+	// Output:
+	// emitted a
+	// emitted End
+}
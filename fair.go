@@ -0,0 +1,138 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+)
+
+type producerKey struct{}
+
+// WithProducer returns a context that identifies the producer of the events emitted with it.
+// Streams created with WithFairness use it to interleave the events of different producers.
+func WithProducer(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, producerKey{}, id)
+}
+
+func producerFrom(ctx context.Context) string {
+	id, _ := ctx.Value(producerKey{}).(string)
+	return id
+}
+
+// fairQueue queues the events of each producer separately and hands them out round-robin.
+type fairQueue struct {
+	lock sync.Mutex
+	cond *sync.Cond
+
+	// max is the number of queued events per producer
+	max    int
+	queues map[string][]delivery
+
+	// order holds the producers that have queued events, in the order they are served
+	order []string
+
+	// end is set when End was emitted. It is handed out after all queued events.
+	end *delivery
+}
+
+func newFairQueue(max int) *fairQueue {
+	q := &fairQueue{
+		max:    max,
+		queues: make(map[string][]delivery),
+	}
+	q.cond = sync.NewCond(&q.lock)
+
+	return q
+}
+
+// push queues d, blocking while the producer has max events queued. Events after End are dropped, and
+// push returns false for them.
+func (q *fairQueue) push(producer string, d delivery) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for q.end == nil && len(q.queues[producer]) >= q.max {
+		q.cond.Wait()
+	}
+	if q.end != nil {
+		return false
+	}
+
+	defer q.cond.Broadcast()
+
+	if d.e == End {
+		q.end = &d
+		return true
+	}
+
+	if len(q.queues[producer]) == 0 {
+		q.order = append(q.order, producer)
+	}
+	q.queues[producer] = append(q.queues[producer], d)
+	return true
+}
+
+// pop returns the next event, waiting for one if necessary.
+func (q *fairQueue) pop() delivery {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for len(q.order) == 0 && q.end == nil {
+		q.cond.Wait()
+	}
+	if len(q.order) == 0 {
+		return *q.end
+	}
+
+	defer q.cond.Broadcast()
+
+	p := q.order[0]
+	q.order = q.order[1:]
+
+	d := q.queues[p][0]
+	q.queues[p] = q.queues[p][1:]
+	if len(q.queues[p]) > 0 {
+		q.order = append(q.order, p)
+	} else {
+		delete(q.queues, p)
+	}
+
+	return d
+}
+
+// runFair delivers the events of the fair queue until End has been delivered.
+func (o *observable) runFair() {
+	for {
+		d := o.fair.pop()
+		if d.e != End && o.ending.Load() {
+			o.traceDiscarded(d.e)
+			if d.barrier != nil {
+				close(d.barrier)
+			}
+			o.dequeue()
+			continue
+		}
+
+		o.deliver(d)
+		o.dequeue()
+		if d.e == End {
+			return
+		}
+	}
+}
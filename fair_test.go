@@ -0,0 +1,154 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFairness(t *testing.T) {
+	ctx := context.Background()
+	em, o := Pair(WithFairness(), WithBuffer(2))
+
+	var got []string
+	started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if e == End {
+			close(done)
+			return
+		}
+
+		got = append(got, fmt.Sprint(e))
+		if len(got) == 1 {
+			close(started)
+			<-release
+		}
+	}))
+
+	a, b := WithProducer(ctx, "a"), WithProducer(ctx, "b")
+	em.Emit(a, stringEvent("a0"))
+	<-started
+
+	// while the observer is busy, both producers fill their queues
+	em.Emit(a, stringEvent("a1"))
+	em.Emit(a, stringEvent("a2"))
+	em.Emit(b, stringEvent("b0"))
+	em.Emit(b, stringEvent("b1"))
+
+	wrote := make(chan struct{})
+	go func() {
+		defer close(wrote)
+		for i := 3; i < 6; i++ {
+			em.Emit(a, stringEvent(fmt.Sprint("a", i)))
+		}
+	}()
+
+	close(release)
+	<-wrote
+	em.End(ctx)
+	<-done
+
+	if fmt.Sprint(got) != "[a0 a1 b0 a2 b1 a3 a4 a5]" {
+		t.Errorf("producers not interleaved: %v", got)
+	}
+}
+
+func TestConcurrentEmit(t *testing.T) {
+	modes := map[string][]Option{
+		"sync":     nil,
+		"buffered": {WithBuffer(16)},
+		"async":    {WithAsync(), WithBuffer(16)},
+		"fair":     {WithFairness(), WithBuffer(4)},
+	}
+
+	const producers, events = 32, 200
+
+	for name, opts := range modes {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			em, o := Pair(opts...)
+
+			var (
+				lock sync.Mutex
+				next = make(map[string]int)
+				n    int
+			)
+			done := make(chan struct{})
+			o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+				if e == End {
+					close(done)
+					return
+				}
+
+				lock.Lock()
+				defer lock.Unlock()
+
+				var p string
+				var i int
+				fmt.Sscanf(string(e.(stringEvent)), "%s %d", &p, &i)
+				if next[p] != i {
+					t.Errorf("producer %s: expected event %d, got %d", p, next[p], i)
+				}
+				next[p] = i + 1
+				n++
+			}))
+
+			var wg sync.WaitGroup
+			for p := 0; p < producers; p++ {
+				wg.Add(1)
+				go func(p string) {
+					defer wg.Done()
+					ctx := WithProducer(ctx, p)
+					for i := 0; i < events; i++ {
+						em.Emit(ctx, stringEvent(fmt.Sprint(p, " ", i)))
+					}
+				}(fmt.Sprint("p", p))
+			}
+
+			wg.Wait()
+			em.End(ctx)
+			<-done
+
+			if n != producers*events {
+				t.Errorf("expected %d events, got %d", producers*events, n)
+			}
+		})
+	}
+}
+
+func TestFairBarrierAfterEnd(t *testing.T) {
+	ctx := context.Background()
+	em, _ := Pair(WithFairness(), WithBarrier("int"))
+	em.End(ctx)
+
+	emitted := make(chan struct{})
+	go func() {
+		em.Emit(ctx, intEvent(1))
+		close(emitted)
+	}()
+
+	select {
+	case <-emitted:
+	case <-time.After(time.Second):
+		t.Fatal("Emit of a barrier event after End blocked")
+	}
+}
@@ -0,0 +1,108 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+)
+
+// FanInOptions configure FanIn.
+type FanInOptions struct {
+	// EndOnFirst makes the merged stream end when the first source ended, instead of the last one.
+	EndOnFirst bool
+
+	// Options configure the merged stream, see Pair.
+	Options []Option
+}
+
+// Merge returns an Observable with the events of all sources. It ends once all sources ended.
+func Merge(sources ...Observable) Observable {
+	return FanIn(FanInOptions{}, sources...)
+}
+
+// FanIn returns an Observable with the events of all sources. It ends once all sources ended, or with
+// EndOnFirst, once any of them ended; then it unregisters from the others. nil sources count as ended.
+// Events of different sources are passed on concurrently if they are emitted concurrently.
+func FanIn(opts FanInOptions, sources ...Observable) Observable {
+	em, o := Pair(opts.Options...)
+
+	var live []Observable
+	for _, src := range sources {
+		if src != nil {
+			live = append(live, src)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var (
+		lock  sync.RWMutex
+		left  = len(live)
+		ended bool
+	)
+	end := func(ectx context.Context) {
+		ended = true
+		cancel()
+		em.End(ectx)
+	}
+
+	if len(live) == 0 || (opts.EndOnFirst && len(live) < len(sources)) {
+		end(ctx)
+		return o
+	}
+
+	for _, src := range live {
+		var once sync.Once
+		src.Register(ctx, ObserverFunc(func(ectx context.Context, e Event) {
+			if e != End {
+				lock.RLock()
+				defer lock.RUnlock()
+				if !ended {
+					em.Emit(ectx, e)
+				}
+				return
+			}
+
+			once.Do(func() {
+				lock.Lock()
+				defer lock.Unlock()
+
+				left--
+				if !ended && (left == 0 || opts.EndOnFirst) {
+					end(ectx)
+				}
+			})
+		}))
+	}
+
+	return o
+}
+
+// FanOut splits o into n branches, each a stream of its own that passes on all events of o. Observers of one
+// branch don't affect those of the others: cancelling their registrations only ends them on their branch, and
+// with WithBuffer or WithAsync, which opts are applied to each branch, a slow branch doesn't hold up the others.
+func FanOut(o Observable, n int, opts ...Option) []Observable {
+	branches := make([]Observable, n)
+	for i := range branches {
+		em, branch := Pair(opts...)
+		Pipe(context.Background(), o, em)
+		branches[i] = branch
+	}
+
+	return branches
+}
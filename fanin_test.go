@@ -0,0 +1,105 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ExampleMerge() {
+	ctx := context.Background()
+	em1, o1 := Pair()
+	em2, o2 := Pair()
+
+	Merge(o1, o2, nil).Register(ctx, printObserver{})
+
+	em1.Emit(ctx, stringEvent("a"))
+	em2.Emit(ctx, stringEvent("b"))
+	em1.End(ctx)
+	em2.Emit(ctx, stringEvent("c"))
+	em2.End(ctx)
+
+	// Output:
+	// a
+	// b
+	// c
+	// End
+}
+
+func ExampleFanIn() {
+	ctx := context.Background()
+	em1, o1 := Pair()
+	em2, o2 := Pair()
+
+	FanIn(FanInOptions{EndOnFirst: true}, o1, o2).Register(ctx, printObserver{})
+
+	em1.Emit(ctx, stringEvent("a"))
+	em2.End(ctx)
+	em1.Emit(ctx, stringEvent("b"))
+
+	// Output:
+	// a
+	// End
+}
+
+func TestFanOut(t *testing.T) {
+	ctx := context.Background()
+	em, o := Pair()
+
+	branches := FanOut(o, 2, WithBuffer(4))
+
+	// the first branch is stuck, but the second one keeps going
+	stuck := make(chan struct{})
+	defer close(stuck)
+	branches[0].Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		<-stuck
+	}))
+
+	got := make(chan Event, 10)
+	bctx, cancel := context.WithCancel(ctx)
+	branches[1].Register(bctx, ObserverFunc(func(ctx context.Context, e Event) {
+		got <- e
+	}))
+
+	em.Emit(ctx, intEvent(1))
+	em.Emit(ctx, intEvent(2))
+	for _, exp := range []Event{intEvent(1), intEvent(2)} {
+		select {
+		case e := <-got:
+			if e != exp {
+				t.Fatalf("expected %v, got %v", exp, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %v", exp)
+		}
+	}
+
+	// cancelling a registration on one branch leaves the others alone
+	cancel()
+	var n atomic.Int32
+	branches[1].Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		n.Add(1)
+	}))
+	em.Emit(ctx, intEvent(3))
+	for n.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,112 @@
+//go:build !tinygo
+
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FilterBuilder is a function taking an arbitrary number of inputs and returns a Filter
+type FilterBuilder struct {
+	v interface{}
+}
+
+func NewFilterBuilder(f interface{}) *FilterBuilder {
+	return &FilterBuilder{f}
+}
+
+// Valid returns whether FilterBuilder is a valid FilterBuilder
+func (fb *FilterBuilder) Valid() bool {
+	t := reflect.TypeOf(fb.v)
+	if t.Kind() != reflect.Func {
+		return false
+	}
+
+	if t.NumOut() != 1 {
+		return false
+	}
+
+	if t.Out(0) != reflect.TypeOf(Filter(nil)) {
+		return false
+	}
+
+	return true
+}
+
+type paramMismatchError struct {
+	t  reflect.Type
+	vs []interface{}
+}
+
+func newParamMismatchError(t reflect.Type, vs interface{}) error {
+	return paramMismatchError{
+		t:  t,
+		vs: vs.([]interface{}),
+	}
+}
+
+func (err paramMismatchError) Error() string {
+	gotTypeStrs := make([]string, len(err.vs))
+	expTypeStrs := make([]string, err.t.NumIn())
+
+	for i, v := range err.vs {
+		t := reflect.TypeOf(v)
+		gotTypeStrs[i] = fmt.Sprintf("%s.%s", t.PkgPath(), t.Name())
+	}
+
+	for i := 0; i < err.t.NumIn(); i++ {
+		t := err.t.In(i)
+		gotTypeStrs[i] = fmt.Sprintf("%s.%s", t.PkgPath(), t.Name())
+	}
+
+	return fmt.Sprintf("parameter mismatch error: got (%s), expected (%s)", strings.Join(gotTypeStrs, " "), strings.Join(expTypeStrs, " "))
+}
+
+func (fb *FilterBuilder) Build(vs ...interface{}) (Filter, error) {
+	t := reflect.TypeOf(fb.v)
+
+	if len(vs) != t.NumIn() {
+		return nil, newParamMismatchError(t, vs)
+	}
+
+	for i := range vs {
+		tv := reflect.TypeOf(vs[i])
+		tp := t.In(i)
+		if tv != tp {
+			return nil, newParamMismatchError(t, vs)
+		}
+	}
+
+	var rvs = make([]reflect.Value, len(vs))
+
+	for i, v := range vs {
+		rvs[i] = reflect.ValueOf(v)
+	}
+
+	// spread this over several lines, because each of these may, but shouldn't panic.
+	// the resulting stack trace will be more informative/easier to read.
+	out := reflect.ValueOf(fb.v).Call(rvs)
+	filterValue := out[0]
+	filterIface := filterValue.Interface()
+
+	return filterIface.(Filter), nil
+}
@@ -0,0 +1,68 @@
+//go:build !tinygo
+
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+)
+
+func ExampleFilterBuilder() {
+	fb := NewFilterBuilder(
+		func(l int) Filter {
+			return Map(func(ctx context.Context, em Emitter, e Event) {
+				if se, ok := e.(stringEvent); ok && len(se) == l {
+					em.Emit(ctx, e)
+				}
+			})
+		})
+
+	// only forward events of length 4
+	m, err := fb.Build(4)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	ctx := context.Background()
+
+	em, o := Pair()
+
+	o.Register(ctx, m)
+	m.Register(ctx, printObserver{})
+
+	em.Emit(ctx, stringEvent("1"))
+	em.Emit(ctx, stringEvent("12"))
+	em.Emit(ctx, stringEvent("123"))
+	em.Emit(ctx, stringEvent("1234"))
+	em.Emit(ctx, stringEvent("12345"))
+	em.Emit(ctx, stringEvent("123456"))
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("ab"))
+	em.Emit(ctx, stringEvent("abc"))
+	em.Emit(ctx, stringEvent("abcd"))
+	em.Emit(ctx, stringEvent("abcde"))
+	em.Emit(ctx, stringEvent("abcdef"))
+
+	// Output:
+	// 1234
+	// abcd
+}
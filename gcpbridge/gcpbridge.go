@@ -0,0 +1,175 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+Package gcpbridge connects voyeur streams to Google Cloud Pub/Sub.
+
+To keep voyeur free of dependencies, the bridges talk to Pub/Sub through the small Publisher and Subscriber
+interfaces, which are easily implemented on top of the Pub/Sub client library. Events are sent as CloudEvents
+in structured JSON format, see package cloudevents.
+*/
+package gcpbridge
+
+import (
+	"context"
+	"encoding/json"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/cloudevents"
+)
+
+// Message is a Pub/Sub message.
+type Message struct {
+	ID          string
+	Data        []byte
+	Attributes  map[string]string
+	OrderingKey string
+
+	// Ack and Nack are set on received messages.
+	Ack, Nack func()
+}
+
+// Publisher publishes messages to a topic.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, msg *Message) error
+}
+
+// Subscriber receives messages of a subscription, calling fn for each of them until ctx is cancelled.
+// fn may be called concurrently, like in the Pub/Sub client library.
+type Subscriber interface {
+	Receive(ctx context.Context, subscription string, fn func(context.Context, *Message)) error
+}
+
+// Sink is an Observer that publishes events to a Pub/Sub topic.
+type Sink struct {
+	Client  Publisher
+	Topic   string
+	Encoder cloudevents.Encoder
+
+	// OrderingKey returns the ordering key of an event. Events with the same key are delivered in order.
+	// It may be nil, in which case no ordering key is set.
+	OrderingKey func(voyeur.Event) string
+
+	// OnError is called when an event can't be published. It may be nil.
+	OnError func(voyeur.Event, error)
+}
+
+// OnEvent publishes e. End is not published.
+func (s *Sink) OnEvent(ctx context.Context, e voyeur.Event) {
+	if e == voyeur.End {
+		return
+	}
+
+	err := func() error {
+		ce, err := s.Encoder.Encode(e)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(ce)
+		if err != nil {
+			return err
+		}
+
+		msg := &Message{
+			Data:       data,
+			Attributes: map[string]string{"content-type": cloudevents.ContentType},
+		}
+		if s.OrderingKey != nil {
+			msg.OrderingKey = s.OrderingKey(e)
+		}
+
+		return s.Client.Publish(ctx, s.Topic, msg)
+	}()
+
+	if err != nil && s.OnError != nil {
+		s.OnError(e, err)
+	}
+}
+
+// Source receives the messages of a subscription and emits the events they carry.
+// A message is acked after Emit returned, i.e. after all observers of a synchronous Pair processed it,
+// or, if Acks is set, after the observers acknowledged it.
+// If decoding fails or an observer panics, the message is nacked so Pub/Sub redelivers it.
+type Source struct {
+	Client       Subscriber
+	Subscription string
+	Decoder      cloudevents.Decoder
+
+	// Acks, if not zero, is the number of times voyeur.Ack must be called with the context of an event before its
+	// message is acked, e.g. once by each observer, or once by a single critical observer. voyeur.Nack nacks it.
+	Acks int
+
+	// OnError is called for messages that can't be decoded. It may be nil.
+	OnError func(*Message, error)
+}
+
+// Run emits the received events on em until ctx is cancelled or receiving fails. em is ended before Run returns.
+func (s *Source) Run(ctx context.Context, em voyeur.Emitter) error {
+	defer em.End(ctx)
+
+	return s.Client.Receive(ctx, s.Subscription, func(ctx context.Context, msg *Message) {
+		// acked is set if the observers ack the message themselves
+		delivered, acked := false, false
+		defer func() {
+			if acked {
+				return
+			}
+
+			if delivered {
+				msg.Ack()
+			} else {
+				msg.Nack()
+			}
+		}()
+
+		var ce cloudevents.CloudEvent
+		err := json.Unmarshal(msg.Data, &ce)
+		if err != nil {
+			s.error(msg, err)
+			return
+		}
+
+		e, err := s.Decoder.Decode(&ce)
+		if err != nil {
+			s.error(msg, err)
+			return
+		}
+
+		if s.Acks == 0 {
+			em.Emit(ctx, e)
+			delivered = true
+			return
+		}
+
+		ctx = voyeur.WithAck(ctx, s.Acks, func(nack error) {
+			if nack != nil {
+				msg.Nack()
+			} else {
+				msg.Ack()
+			}
+		})
+		em.Emit(ctx, e)
+		acked = true
+	})
+}
+
+func (s *Source) error(msg *Message, err error) {
+	if s.OnError != nil {
+		s.OnError(msg, err)
+	}
+}
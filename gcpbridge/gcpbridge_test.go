@@ -0,0 +1,146 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package gcpbridge
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/cloudevents"
+)
+
+type userEvent struct {
+	User string `json:"user"`
+	N    int    `json:"n"`
+}
+
+func (userEvent) EventType() string {
+	return "user"
+}
+
+// fakePubSub is a topic with a single subscription.
+type fakePubSub struct {
+	msgs          []*Message
+	acked, nacked []string
+}
+
+func (f *fakePubSub) Publish(ctx context.Context, topic string, msg *Message) error {
+	msg.ID = fmt.Sprint(len(f.msgs))
+	f.msgs = append(f.msgs, msg)
+	return nil
+}
+
+func (f *fakePubSub) Receive(ctx context.Context, subscription string, fn func(context.Context, *Message)) error {
+	for _, msg := range f.msgs {
+		id := msg.ID
+		msg.Ack = func() { f.acked = append(f.acked, id) }
+		msg.Nack = func() { f.nacked = append(f.nacked, id) }
+		fn(ctx, msg)
+	}
+
+	return nil
+}
+
+func TestBridge(t *testing.T) {
+	ctx := context.Background()
+	ps := &fakePubSub{}
+
+	types := voyeur.NewTypeRegistry()
+	types.Register(userEvent{})
+
+	sink := &Sink{
+		Client:      ps,
+		Topic:       "projects/p/topics/t",
+		Encoder:     cloudevents.Encoder{Source: "test"},
+		OrderingKey: func(e voyeur.Event) string { return e.(userEvent).User },
+	}
+
+	sink.OnEvent(ctx, userEvent{"alice", 1})
+	sink.OnEvent(ctx, userEvent{"bob", 2})
+	ps.msgs = append(ps.msgs, &Message{ID: "garbage", Data: []byte("{")})
+
+	if ps.msgs[0].OrderingKey != "alice" || ps.msgs[1].OrderingKey != "bob" {
+		t.Errorf("ordering keys not set")
+	}
+
+	var got []voyeur.Event
+	em, o := voyeur.Pair()
+	o.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		got = append(got, e)
+	}))
+
+	src := &Source{
+		Client:       ps,
+		Subscription: "projects/p/subscriptions/s",
+		Decoder:      cloudevents.Decoder{Types: types},
+	}
+
+	if err := src.Run(ctx, em); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 3 || got[1] != (userEvent{"bob", 2}) || got[2] != voyeur.End {
+		t.Errorf("unexpected events %v", got)
+	}
+
+	if fmt.Sprint(ps.acked) != "[0 1]" || fmt.Sprint(ps.nacked) != "[garbage]" {
+		t.Errorf("acked %v, nacked %v", ps.acked, ps.nacked)
+	}
+}
+
+func TestAcks(t *testing.T) {
+	ctx := context.Background()
+	ps := &fakePubSub{}
+
+	types := voyeur.NewTypeRegistry()
+	types.Register(userEvent{})
+
+	sink := &Sink{Client: ps, Encoder: cloudevents.Encoder{Source: "test"}}
+	sink.OnEvent(ctx, userEvent{"alice", 1})
+	sink.OnEvent(ctx, userEvent{"bob", 2})
+
+	// two observers, both have to acknowledge
+	em, o := voyeur.Pair()
+	o.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		voyeur.Ack(ctx)
+	}))
+	o.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		if e, ok := e.(userEvent); ok && e.User == "bob" {
+			voyeur.Nack(ctx, nil)
+			return
+		}
+		voyeur.Ack(ctx)
+	}))
+
+	src := &Source{
+		Client:       ps,
+		Subscription: "projects/p/subscriptions/s",
+		Decoder:      cloudevents.Decoder{Types: types},
+		Acks:         2,
+	}
+
+	if err := src.Run(ctx, em); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(ps.acked) != "[0]" || fmt.Sprint(ps.nacked) != "[1]" {
+		t.Errorf("acked %v, nacked %v", ps.acked, ps.nacked)
+	}
+}
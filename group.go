@@ -0,0 +1,174 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// MembershipEvent is emitted by a Group when a member joins or leaves.
+type MembershipEvent struct {
+	Member string
+	Joined bool
+
+	// Size is the number of members after the change.
+	Size int
+}
+
+func (MembershipEvent) EventType() string {
+	return "membership"
+}
+
+// Group is an Emitter that multicasts to its members. Joining and leaving are emitted as MembershipEvents on
+// Membership, so other components can react to subscriber churn, e.g. start an upstream feed when the first
+// member joins and stop it when the last one leaves.
+type Group struct {
+	em Emitter
+	o  Observable
+
+	memEm Emitter
+	memO  Observable
+
+	// emitLock keeps membership events in the order of the changes
+	emitLock sync.Mutex
+	lock     sync.Mutex
+	members  map[string]int
+	size     int
+	done     chan struct{}
+}
+
+// NewGroup returns an empty Group. The options configure the Pair the members are registered on.
+func NewGroup(opts ...Option) *Group {
+	g := &Group{
+		members: make(map[string]int),
+		done:    make(chan struct{}),
+	}
+	g.em, g.o = Pair(opts...)
+	g.memEm, g.memO = Pair()
+	return g
+}
+
+// Join registers oer as member id until ctx is cancelled. Several observers may join with the same id;
+// they count as separate members.
+func (g *Group) Join(ctx context.Context, id string, oer Observer) {
+	// registering under the lock makes sure the member gets End, either from the Group or below
+	g.lock.Lock()
+	select {
+	case <-g.done:
+		g.lock.Unlock()
+		oer.OnEvent(ctx, End)
+		return
+	default:
+	}
+
+	// left is set before the MembershipEvent is emitted, so members get no events after leaving
+	var left atomic.Bool
+	g.o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if !left.Load() {
+			oer.OnEvent(ctx, e)
+		}
+	}))
+	g.lock.Unlock()
+
+	g.change(ctx, id, true)
+
+	context.AfterFunc(ctx, func() {
+		left.Store(true)
+		g.change(context.WithoutCancel(ctx), id, false)
+	})
+}
+
+// change updates the members and emits the MembershipEvent.
+func (g *Group) change(ctx context.Context, id string, joined bool) {
+	g.emitLock.Lock()
+	defer g.emitLock.Unlock()
+
+	g.lock.Lock()
+	select {
+	case <-g.done:
+		g.lock.Unlock()
+		return
+	default:
+	}
+
+	if joined {
+		g.members[id]++
+		g.size++
+	} else {
+		g.members[id]--
+		g.size--
+		if g.members[id] == 0 {
+			delete(g.members, id)
+		}
+	}
+	e := MembershipEvent{Member: id, Joined: joined, Size: g.size}
+	g.lock.Unlock()
+
+	g.memEm.Emit(ctx, e)
+}
+
+// Members returns the ids of the current members in lexical order.
+func (g *Group) Members() []string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	ids := make([]string, 0, len(g.members))
+	for id := range g.members {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+// Membership returns the Observable of the MembershipEvents. It ends when the Group ends.
+func (g *Group) Membership() Observable {
+	return g.memO
+}
+
+// Emit passes e on to all members.
+func (g *Group) Emit(ctx context.Context, e Event) {
+	if e == End {
+		g.End(ctx)
+		return
+	}
+
+	g.em.Emit(ctx, e)
+}
+
+// End ends the Group. Members get End, no more MembershipEvents are emitted and Membership ends.
+func (g *Group) End(ctx context.Context) {
+	g.emitLock.Lock()
+	defer g.emitLock.Unlock()
+
+	g.lock.Lock()
+	select {
+	case <-g.done:
+		g.lock.Unlock()
+		return
+	default:
+		close(g.done)
+	}
+	g.lock.Unlock()
+
+	g.em.End(ctx)
+	g.memEm.End(ctx)
+}
@@ -0,0 +1,96 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleGroup() {
+	ctx := context.Background()
+	g := NewGroup()
+	left := make(chan struct{})
+
+	// start the feed when the first member joins and stop it when the last one leaves
+	g.Membership().Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		switch m, _ := e.(MembershipEvent); {
+		case e == End:
+			fmt.Println("group ended")
+		case m.Joined && m.Size == 1:
+			fmt.Println("start feed for", m.Member)
+		case !m.Joined && m.Size == 0:
+			fmt.Println("stop feed after", m.Member, "left")
+			close(left)
+		}
+	}))
+
+	aliceCtx, aliceLeave := context.WithCancel(ctx)
+	g.Join(aliceCtx, "alice", printObserver{})
+	g.Emit(ctx, stringEvent("hello"))
+	fmt.Println(g.Members())
+
+	aliceLeave()
+	<-left
+
+	g.Join(ctx, "bob", printObserver{})
+	g.End(ctx)
+
+	// Output:
+	// start feed for alice
+	// hello
+	// [alice]
+	// stop feed after alice left
+	// start feed for bob
+	// End
+	// group ended
+}
+
+func TestGroupJoinDuringEnd(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		ctx := context.Background()
+		g := NewGroup()
+
+		ends := make(chan struct{}, 2)
+		join := make(chan struct{})
+		go func() {
+			g.Join(ctx, "late", ObserverFunc(func(ctx context.Context, e Event) {
+				if e == End {
+					ends <- struct{}{}
+				}
+			}))
+			close(join)
+		}()
+
+		g.End(ctx)
+		<-join
+
+		select {
+		case <-ends:
+		case <-time.After(time.Second):
+			t.Fatal("member joining during End never got End")
+		}
+		select {
+		case <-ends:
+			t.Fatal("End delivered twice")
+		default:
+		}
+	}
+}
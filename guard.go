@@ -0,0 +1,225 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// GuardOptions configure Guard.
+type GuardOptions struct {
+	// Window is the number of recent deliveries the health score is computed from. Defaults to 20.
+	Window int
+
+	// Threshold is the score below which the observer is evicted. Defaults to 0.5.
+	Threshold float64
+
+	// Timeout, if not zero, makes deliveries that take longer count as failed.
+	Timeout time.Duration
+
+	// Notify, if not nil, gets an EvictionEvent when the observer is evicted.
+	Notify Emitter
+
+	// Clock defaults to SystemClock.
+	Clock Clock
+}
+
+// Outcomes of a delivery to a guarded observer.
+const (
+	outcomeOK = iota
+	outcomeFailed
+	outcomePanicked
+	outcomeTimedOut
+)
+
+// EvictionEvent is emitted when Guard evicted an observer.
+type EvictionEvent struct {
+	// Observer is the name of the observer, see NameOf.
+	Observer string
+
+	// Score is the health score the observer had when it was evicted.
+	Score float64
+
+	// Failed, Panicked and TimedOut count the bad deliveries in the window.
+	Failed, Panicked, TimedOut int
+}
+
+func (EvictionEvent) EventType() string {
+	return "eviction"
+}
+
+func (e EvictionEvent) String() string {
+	return fmt.Sprintf("evicted %s: score %.2f, %d failed, %d panicked, %d timed out",
+		e.Observer, e.Score, e.Failed, e.Panicked, e.TimedOut)
+}
+
+type guardKey struct{}
+
+// guardCall records whether the observer reported a failure while handling an event.
+type guardCall struct {
+	failed atomic.Bool
+}
+
+// Fail reports that the guarded observer failed to handle the event delivered with ctx, see Guard.
+// It does nothing if the observer is not guarded.
+func Fail(ctx context.Context, err error) {
+	if call, ok := ctx.Value(guardKey{}).(*guardCall); ok && err != nil {
+		call.failed.Store(true)
+	}
+}
+
+// ObserverHealth is the health of an observer registered using Guard.
+type ObserverHealth struct {
+	oer    Observer
+	opts   GuardOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock     sync.Mutex
+	outcomes []int
+	next     int
+	evicted  atomic.Bool
+}
+
+// Guard registers oer on o and keeps track of how well it handles events: deliveries fail if oer calls Fail,
+// panics or, with a Timeout, takes too long. Panics are recovered. The health score is the share of the recent
+// deliveries that went well. Once Window deliveries were made and the score drops below Threshold, oer is
+// unregistered and an EvictionEvent is emitted on Notify. The registration also ends when ctx is cancelled.
+func Guard(ctx context.Context, o Observable, oer Observer, opts GuardOptions) *ObserverHealth {
+	if opts.Window < 1 {
+		opts.Window = 20
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = 0.5
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	h := &ObserverHealth{
+		oer:      oer,
+		opts:     opts,
+		ctx:      ctx,
+		cancel:   cancel,
+		outcomes: make([]int, 0, opts.Window),
+	}
+
+	observableOrNop(o).Register(ctx, ObserverFunc(h.deliver))
+	return h
+}
+
+func (h *ObserverHealth) deliver(ctx context.Context, e Event) {
+	if h.evicted.Load() {
+		return
+	}
+
+	call := &guardCall{}
+	start := h.opts.Clock.Now()
+	outcome := outcomePanicked
+	defer func() {
+		if outcome == outcomePanicked {
+			recover()
+		}
+		if e != End {
+			h.record(ctx, outcome)
+		}
+	}()
+
+	h.oer.OnEvent(context.WithValue(ctx, guardKey{}, call), e)
+
+	switch {
+	case call.failed.Load():
+		outcome = outcomeFailed
+	case h.opts.Timeout > 0 && h.opts.Clock.Now().Sub(start) > h.opts.Timeout:
+		outcome = outcomeTimedOut
+	default:
+		outcome = outcomeOK
+	}
+}
+
+// record adds the outcome of a delivery and evicts the observer if it is unhealthy.
+func (h *ObserverHealth) record(ctx context.Context, outcome int) {
+	h.lock.Lock()
+	if len(h.outcomes) < h.opts.Window {
+		h.outcomes = append(h.outcomes, outcome)
+	} else {
+		h.outcomes[h.next] = outcome
+	}
+	h.next = (h.next + 1) % h.opts.Window
+
+	ev := h.summary()
+	evict := len(h.outcomes) == h.opts.Window && ev.Score < h.opts.Threshold
+	h.lock.Unlock()
+
+	if !evict || !h.evicted.CompareAndSwap(false, true) {
+		return
+	}
+
+	h.cancel()
+	if h.opts.Notify != nil {
+		h.opts.Notify.Emit(ctx, ev)
+	}
+}
+
+// summary counts the outcomes in the window. h.lock must be held.
+func (h *ObserverHealth) summary() EvictionEvent {
+	ev := EvictionEvent{Observer: NameOf(h.oer), Score: 1}
+	if len(h.outcomes) == 0 {
+		return ev
+	}
+
+	ok := 0
+	for _, outcome := range h.outcomes {
+		switch outcome {
+		case outcomeOK:
+			ok++
+		case outcomeFailed:
+			ev.Failed++
+		case outcomePanicked:
+			ev.Panicked++
+		case outcomeTimedOut:
+			ev.TimedOut++
+		}
+	}
+
+	ev.Score = float64(ok) / float64(len(h.outcomes))
+	return ev
+}
+
+// Score returns the share of the recent deliveries that went well, 1 if there were none yet.
+func (h *ObserverHealth) Score() float64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.summary().Score
+}
+
+// Evicted returns whether the observer was evicted.
+func (h *ObserverHealth) Evicted() bool {
+	return h.evicted.Load()
+}
+
+// Done returns a channel that is closed when the observer was evicted or ctx was cancelled.
+func (h *ObserverHealth) Done() <-chan struct{} {
+	return h.ctx.Done()
+}
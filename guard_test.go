@@ -0,0 +1,80 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleGuard() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	evictions, eo := Pair()
+	eo.Register(ctx, printObserver{})
+
+	flaky := ObserverFunc(func(ctx context.Context, e Event) {
+		if e == stringEvent("bad") {
+			Fail(ctx, errors.New("can't handle this"))
+			return
+		}
+		fmt.Println("handled", e)
+	})
+	h := Guard(ctx, o, flaky, GuardOptions{Window: 4, Threshold: 0.5, Notify: evictions})
+
+	for _, s := range []string{"good", "bad", "good", "bad", "bad", "good"} {
+		em.Emit(ctx, stringEvent(s))
+	}
+	fmt.Println(h.Evicted())
+
+	// Output:
+	// handled good
+	// handled good
+	// evicted voyeur.ObserverFunc: score 0.25, 3 failed, 0 panicked, 0 timed out
+	// true
+}
+
+func TestGuardPanicsAndTimeouts(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	em, o := Pair()
+
+	h := Guard(ctx, o, ObserverFunc(func(ctx context.Context, e Event) {
+		switch e {
+		case intEvent(1):
+			panic("boom")
+		case intEvent(2):
+			clock.Advance(time.Second)
+		}
+	}), GuardOptions{Window: 4, Threshold: 0.6, Timeout: 100 * time.Millisecond, Clock: clock})
+
+	for _, i := range []int{0, 1, 2, 0} {
+		em.Emit(ctx, intEvent(i))
+	}
+
+	if s := h.Score(); s != 0.5 {
+		t.Errorf("expected score 0.5, got %v", s)
+	}
+	if !h.Evicted() {
+		t.Error("expected the observer to be evicted")
+	}
+}
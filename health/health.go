@@ -0,0 +1,270 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+Package health monitors the health of the components of an application.
+
+Components add a Reporter to a Monitor. The Monitor periodically runs all reporters and emits the aggregated
+result as HealthEvent, so health can be observed like any other voyeur stream. The Monitor also is an
+http.Handler serving the latest HealthEvent.
+*/
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+// Status is the health of a component or the whole application.
+type Status int
+
+const (
+	// Up means everything works.
+	Up Status = iota
+	// Degraded means it works, but not as well as it should.
+	Degraded
+	// Down means it doesn't work.
+	Down
+)
+
+func (s Status) String() string {
+	switch s {
+	case Up:
+		return "up"
+	case Degraded:
+		return "degraded"
+	default:
+		return "down"
+	}
+}
+
+// MarshalText encodes the status as its name.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Reporter checks the health of a component. It returns nil if the component is up,
+// an error wrapped using Degrade if it is degraded, and any other error if it is down.
+type Reporter func(context.Context) error
+
+type degraded struct {
+	error
+}
+
+func (d degraded) Unwrap() error {
+	return d.error
+}
+
+// Degrade marks err as meaning degraded instead of down.
+func Degrade(err error) error {
+	return degraded{err}
+}
+
+// Check is the result of a Reporter.
+type Check struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// HealthEvent is the aggregated result of all reporters. The Status is the worst status of all checks.
+type HealthEvent struct {
+	Status Status    `json:"status"`
+	Time   time.Time `json:"time"`
+	Checks []Check   `json:"checks"`
+}
+
+func (HealthEvent) EventType() string {
+	return "health"
+}
+
+// Options configure a Monitor.
+type Options struct {
+	// Interval is the time between checks in Run. Defaults to 10 seconds.
+	Interval time.Duration
+
+	// Timeout limits how long a single Reporter may take. Defaults to Interval.
+	Timeout time.Duration
+
+	// Clock defaults to voyeur.SystemClock.
+	Clock voyeur.Clock
+}
+
+// Monitor runs reporters and emits their results.
+type Monitor struct {
+	opts Options
+
+	lock      sync.Mutex
+	reporters map[string]Reporter
+	latest    *HealthEvent
+
+	em voyeur.Emitter
+	o  voyeur.Observable
+}
+
+// NewMonitor returns a Monitor without reporters.
+func NewMonitor(opts Options) *Monitor {
+	if opts.Interval == 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = opts.Interval
+	}
+	if opts.Clock == nil {
+		opts.Clock = voyeur.SystemClock
+	}
+
+	em, o := voyeur.Pair(voyeur.WithName("health"))
+	m := &Monitor{
+		opts:      opts,
+		reporters: make(map[string]Reporter),
+		em:        em,
+		o:         o,
+	}
+
+	// the handler serves what has been emitted last
+	o.Register(context.Background(), voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		if he, ok := e.(HealthEvent); ok {
+			m.lock.Lock()
+			m.latest = &he
+			m.lock.Unlock()
+		}
+	}))
+
+	return m
+}
+
+// Add adds a reporter under name, replacing the one previously added under that name.
+func (m *Monitor) Add(name string, r Reporter) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.reporters[name] = r
+}
+
+// Remove removes the reporter added under name.
+func (m *Monitor) Remove(name string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.reporters, name)
+}
+
+// Check runs all reporters concurrently, emits the result and returns it.
+func (m *Monitor) Check(ctx context.Context) HealthEvent {
+	m.lock.Lock()
+	names := make([]string, 0, len(m.reporters))
+	reporters := make([]Reporter, 0, len(m.reporters))
+	for name := range m.reporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		reporters = append(reporters, m.reporters[name])
+	}
+	m.lock.Unlock()
+
+	checks := make([]Check, len(names))
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, m.opts.Timeout)
+			defer cancel()
+
+			checks[i] = Check{Name: names[i], Status: Up}
+			if err := reporters[i](ctx); err != nil {
+				checks[i].Status = Down
+				checks[i].Message = err.Error()
+				if errors.As(err, new(degraded)) {
+					checks[i].Status = Degraded
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	he := HealthEvent{Status: Up, Time: m.opts.Clock.Now(), Checks: checks}
+	for _, c := range checks {
+		if c.Status > he.Status {
+			he.Status = c.Status
+		}
+	}
+
+	m.em.Emit(ctx, he)
+	return he
+}
+
+// Run checks the health once per Interval until ctx is cancelled, and then ends the Monitor.
+func (m *Monitor) Run(ctx context.Context) {
+	t := m.opts.Clock.NewTicker(m.opts.Interval)
+	defer t.Stop()
+	defer m.em.End(context.Background())
+
+	m.Check(ctx)
+	for {
+		select {
+		case <-t.C():
+			m.Check(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Register registers oer for the HealthEvents.
+func (m *Monitor) Register(ctx context.Context, oer voyeur.Observer) {
+	m.o.Register(ctx, oer)
+}
+
+// Latest returns the last emitted HealthEvent, if there is one.
+func (m *Monitor) Latest() (HealthEvent, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.latest == nil {
+		return HealthEvent{}, false
+	}
+
+	return *m.latest, true
+}
+
+// ServeHTTP responds with the latest HealthEvent as JSON. The status code is 503 if the application is down
+// or hasn't been checked yet, and 200 otherwise.
+func (m *Monitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	he, ok := m.Latest()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ok || he.Status == Down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	if !ok {
+		he.Status = Down
+	}
+
+	json.NewEncoder(w).Encode(he)
+}
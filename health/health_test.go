@@ -0,0 +1,67 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+func TestMonitor(t *testing.T) {
+	ctx := context.Background()
+	m := NewMonitor(Options{})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 before the first check, got %d", rec.Code)
+	}
+
+	var seen []string
+	m.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		seen = append(seen, e.(HealthEvent).Status.String())
+	}))
+
+	dbErr := error(nil)
+	m.Add("db", func(ctx context.Context) error { return dbErr })
+	m.Add("cache", func(ctx context.Context) error { return Degrade(errors.New("slow")) })
+
+	he := m.Check(ctx)
+	if he.Status != Degraded || len(he.Checks) != 2 || he.Checks[0].Name != "cache" || he.Checks[0].Message != "slow" {
+		t.Errorf("unexpected result %+v", he)
+	}
+
+	dbErr = errors.New("connection refused")
+	m.Check(ctx)
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 when down, got %d", rec.Code)
+	}
+
+	if fmt.Sprint(seen) != "[degraded down]" {
+		t.Errorf("unexpected events %v", seen)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+)
+
+// Invertible is implemented by events that can be reversed.
+type Invertible interface {
+	Event
+
+	// Invert returns the event that reverses the effect of this one.
+	Invert() Invertible
+}
+
+// History is an Emitter that records the Invertible events emitted through it, so they can be undone and redone.
+// Events that are not Invertible are passed on but not recorded.
+type History struct {
+	em Emitter
+
+	lock       sync.Mutex
+	undo, redo []Invertible
+}
+
+// NewHistory returns a History that emits on em.
+func NewHistory(em Emitter) *History {
+	return &History{em: emitterOrNop(em)}
+}
+
+// Emit emits e. If e is Invertible it is recorded, and everything that could be redone is forgotten.
+func (h *History) Emit(ctx context.Context, e Event) {
+	if inv, ok := e.(Invertible); ok {
+		h.lock.Lock()
+		h.undo = append(h.undo, inv)
+		h.redo = nil
+		h.lock.Unlock()
+	}
+
+	h.em.Emit(ctx, e)
+}
+
+// End ends the underlying Emitter.
+func (h *History) End(ctx context.Context) {
+	h.em.End(ctx)
+}
+
+// Undo emits the inverse of the latest recorded event that has not been undone yet.
+// It returns false if there is nothing to undo.
+func (h *History) Undo(ctx context.Context) bool {
+	h.lock.Lock()
+	if len(h.undo) == 0 {
+		h.lock.Unlock()
+		return false
+	}
+
+	e := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, e)
+	h.lock.Unlock()
+
+	h.em.Emit(ctx, e.Invert())
+	return true
+}
+
+// Redo emits the latest undone event again. It returns false if there is nothing to redo.
+func (h *History) Redo(ctx context.Context) bool {
+	h.lock.Lock()
+	if len(h.redo) == 0 {
+		h.lock.Unlock()
+		return false
+	}
+
+	e := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, e)
+	h.lock.Unlock()
+
+	h.em.Emit(ctx, e)
+	return true
+}
@@ -0,0 +1,62 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+)
+
+// addEvent adds n to a counter
+type addEvent int
+
+func (addEvent) EventType() string {
+	return "add"
+}
+
+func (e addEvent) Invert() Invertible {
+	return -e
+}
+
+func ExampleHistory() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	var sum int
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		sum += int(e.(addEvent))
+		fmt.Println(sum)
+	}))
+
+	h := NewHistory(em)
+	h.Emit(ctx, addEvent(1))
+	h.Emit(ctx, addEvent(2))
+	h.Undo(ctx)
+	h.Undo(ctx)
+	h.Redo(ctx)
+	fmt.Println(h.Undo(ctx), h.Undo(ctx))
+
+	// Output:
+	// 1
+	// 3
+	// 1
+	// 0
+	// 1
+	// 0
+	// true false
+}
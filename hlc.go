@@ -0,0 +1,151 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// HLCTimestamp is a hybrid logical clock timestamp, see HLC.
+type HLCTimestamp struct {
+	// Wall is the physical time in nanoseconds since the Unix epoch.
+	Wall int64 `json:"wall"`
+
+	// Logical orders timestamps with the same Wall.
+	Logical uint32 `json:"logical"`
+
+	// Node is the node that issued the timestamp. It breaks ties between nodes.
+	Node string `json:"node,omitempty"`
+}
+
+// IsZero returns whether t is the zero timestamp.
+func (t HLCTimestamp) IsZero() bool {
+	return t == HLCTimestamp{}
+}
+
+// Compare returns -1, 0 or +1 depending on whether t is before, equal to or after u.
+func (t HLCTimestamp) Compare(u HLCTimestamp) int {
+	if c := cmp.Compare(t.Wall, u.Wall); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(t.Logical, u.Logical); c != 0 {
+		return c
+	}
+
+	return cmp.Compare(t.Node, u.Node)
+}
+
+// Before returns whether t is before u.
+func (t HLCTimestamp) Before(u HLCTimestamp) bool {
+	return t.Compare(u) < 0
+}
+
+func (t HLCTimestamp) String() string {
+	return fmt.Sprintf("%d.%d@%s", t.Wall, t.Logical, t.Node)
+}
+
+// HLC is a hybrid logical clock. Its timestamps stay close to physical time, but never go backwards and always
+// come after the timestamps it has seen from other nodes, so events that caused each other are ordered correctly
+// across processes, even if their clocks are skewed. Streams created WithHLC stamp their envelopes using one.
+type HLC struct {
+	node  string
+	clock Clock
+
+	lock sync.Mutex
+	last HLCTimestamp
+}
+
+// NewHLC returns an HLC for the given node, which should be unique among the processes exchanging events.
+// If clock is nil, SystemClock is used.
+func NewHLC(node string, clock Clock) *HLC {
+	if clock == nil {
+		clock = SystemClock
+	}
+
+	return &HLC{node: node, clock: clock}
+}
+
+// Now returns a timestamp for a local event.
+func (c *HLC) Now() HLCTimestamp {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if wall := c.clock.Now().UnixNano(); wall > c.last.Wall {
+		c.last = HLCTimestamp{Wall: wall}
+	} else {
+		c.last.Logical++
+	}
+
+	c.last.Node = c.node
+	return c.last
+}
+
+// Update merges a timestamp received from another node into the clock and returns a timestamp for receiving it,
+// which comes after both.
+func (c *HLC) Update(remote HLCTimestamp) HLCTimestamp {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	wall := max(c.last.Wall, remote.Wall, c.clock.Now().UnixNano())
+	switch {
+	case wall == c.last.Wall && wall == remote.Wall:
+		c.last.Logical = max(c.last.Logical, remote.Logical) + 1
+	case wall == c.last.Wall:
+		c.last.Logical++
+	case wall == remote.Wall:
+		c.last.Logical = remote.Logical + 1
+	default:
+		c.last.Logical = 0
+	}
+
+	c.last.Wall, c.last.Node = wall, c.node
+	return c.last
+}
+
+// stamp sets the timestamp of env, merging the timestamp it already has.
+func (c *HLC) stamp(env Envelope) Envelope {
+	if env.HLC.IsZero() {
+		env.HLC = c.Now()
+	} else {
+		env.HLC = c.Update(env.HLC)
+	}
+
+	return env
+}
+
+// HLCOf returns the timestamp of e if it is an Envelope stamped by an HLC.
+func HLCOf(e Event) (HLCTimestamp, bool) {
+	env, ok := e.(Envelope)
+	return env.HLC, ok && !env.HLC.IsZero()
+}
+
+// CompareHLC compares events by their HLC timestamps, see HLCTimestamp.Compare.
+// Events without a timestamp come first.
+func CompareHLC(a, b Event) int {
+	ta, _ := HLCOf(a)
+	tb, _ := HLCOf(b)
+	return ta.Compare(tb)
+}
+
+// SortByHLC sorts events by their HLC timestamps, keeping the order of events with equal timestamps.
+func SortByHLC(events []Event) {
+	slices.SortStableFunc(events, CompareHLC)
+}
@@ -0,0 +1,79 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHLCSkew(t *testing.T) {
+	// b's clock is an hour behind a's
+	ca, cb := newFakeClock(), newFakeClock()
+	ca.Advance(time.Hour)
+	a, b := NewHLC("a", ca), NewHLC("b", cb)
+
+	sent := a.Now()
+	received := b.Update(sent)
+	if !sent.Before(received) {
+		t.Fatalf("received %v not after sent %v", received, sent)
+	}
+
+	// b's local events after the receive are still ordered after it, although its clock lags
+	cb.Advance(time.Second)
+	if next := b.Now(); !received.Before(next) {
+		t.Errorf("local %v not after received %v", next, received)
+	}
+
+	// and a catches up with b, so the reply is ordered after both
+	if reply := a.Update(b.Now()); !received.Before(reply) {
+		t.Errorf("reply %v not after %v", reply, received)
+	}
+}
+
+func ExampleWithHLC() {
+	ctx := context.Background()
+
+	// two processes whose clocks are skewed, connected by a bridge
+	ca, cb := newFakeClock(), newFakeClock()
+	ca.Advance(time.Minute)
+	emA, oA := Pair(WithHLC(NewHLC("a", ca)))
+	emB, oB := Pair(WithHLC(NewHLC("b", cb)))
+	Pipe(ctx, oA, emB)
+
+	var events []Event
+	oB.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		events = append([]Event{e}, events...)
+	}))
+
+	// the response is stamped after the request, although b's clock is behind
+	emA.Emit(ctx, stringEvent("request"))
+	emB.Emit(ctx, stringEvent("response"))
+
+	SortByHLC(events)
+	for _, e := range events {
+		ts, _ := HLCOf(e)
+		fmt.Println(e.(Envelope).Event, ts.Node)
+	}
+
+	// Output:
+	// request b
+	// response b
+}
@@ -0,0 +1,166 @@
+//go:build !voyeurminimal
+
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// monotonic hands out millisecond timestamps with a sequence number that is unique for each timestamp.
+// If the sequence overflows or the clock goes backwards, the timestamp of the last id is continued.
+type monotonic struct {
+	clock  Clock
+	maxSeq uint64
+
+	lock sync.Mutex
+	ms   int64
+	seq  uint64
+}
+
+// next returns the timestamp and sequence number of the next id. first returns the sequence number
+// to start a new millisecond with.
+func (m *monotonic) next(first func() uint64) (int64, uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := m.clock.Now().UnixMilli()
+	switch {
+	case now > m.ms:
+		m.ms, m.seq = now, first()
+	case m.seq < m.maxSeq:
+		m.seq++
+	default:
+		m.ms, m.seq = m.ms+1, first()
+	}
+
+	return m.ms, m.seq
+}
+
+func newMonotonic(clock Clock, seqBits uint) *monotonic {
+	if clock == nil {
+		clock = SystemClock
+	}
+
+	return &monotonic{clock: clock, maxSeq: 1<<seqBits - 1, ms: -1}
+}
+
+func zero() uint64 {
+	return 0
+}
+
+func random(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+}
+
+type uuidV7 struct {
+	m *monotonic
+}
+
+// UUIDv7 returns an IDGenerator of version 7 UUIDs. Ids of the same millisecond are ordered using a
+// 12 bit counter. clock may be nil, in which case SystemClock is used.
+func UUIDv7(clock Clock) IDGenerator {
+	return uuidV7{newMonotonic(clock, 12)}
+}
+
+func (g uuidV7) NewID() string {
+	ms, seq := g.m.next(zero)
+
+	var u [16]byte
+	random(u[8:])
+	binary.BigEndian.PutUint64(u[:8], uint64(ms)<<16|0x7000|seq)
+	u[8] = u[8]&0x3f | 0x80
+
+	var s [36]byte
+	hex.Encode(s[0:8], u[0:4])
+	hex.Encode(s[9:13], u[4:6])
+	hex.Encode(s[14:18], u[6:8])
+	hex.Encode(s[19:23], u[8:10])
+	hex.Encode(s[24:], u[10:])
+	s[8], s[13], s[18], s[23] = '-', '-', '-', '-'
+
+	return string(s[:])
+}
+
+type ulid struct {
+	m *monotonic
+
+	lock sync.Mutex
+	// hi holds the upper 16 bits of the random part, the monotonic sequence the lower 64
+	hi uint16
+}
+
+// ULID returns an IDGenerator of ULIDs. Ids of the same millisecond are ordered by incrementing the random
+// part, like the monotonic ULID generators of other languages. clock may be nil, in which case SystemClock is used.
+func ULID(clock Clock) IDGenerator {
+	return &ulid{m: newMonotonic(clock, 64)}
+}
+
+func (g *ulid) NewID() string {
+	g.lock.Lock()
+	ms, seq := g.m.next(func() uint64 {
+		var b [10]byte
+		random(b[:])
+		g.hi = binary.BigEndian.Uint16(b[:2])
+		// leave room to increment the sequence
+		return binary.BigEndian.Uint64(b[2:]) >> 1
+	})
+	hi := g.hi
+	g.lock.Unlock()
+
+	const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+	// 128 bits: 48 bits timestamp, 80 bits random
+	upper, lower := uint64(ms)<<16|uint64(hi), seq
+
+	var s [26]byte
+	for i := len(s) - 1; i >= 0; i-- {
+		s[i] = alphabet[lower&31]
+		lower = lower>>5 | upper<<59
+		upper >>= 5
+	}
+
+	return string(s[:])
+}
+
+type snowflake struct {
+	m     *monotonic
+	node  int64
+	epoch int64
+}
+
+// Snowflake returns an IDGenerator of Twitter-style snowflake ids: 41 bits of milliseconds since epoch,
+// 10 bits of node and a 12 bit sequence, formatted as decimal. They sort numerically; node must be unique
+// among the processes generating ids and is truncated to 10 bits. clock may be nil, in which case
+// SystemClock is used.
+func Snowflake(node int64, epoch time.Time, clock Clock) IDGenerator {
+	return snowflake{m: newMonotonic(clock, 12), node: node & 0x3ff, epoch: epoch.UnixMilli()}
+}
+
+func (g snowflake) NewID() string {
+	ms, seq := g.m.next(zero)
+	return strconv.FormatInt((ms-g.epoch)<<22|g.node<<12|int64(seq), 10)
+}
@@ -0,0 +1,97 @@
+//go:build !voyeurminimal
+
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestIDGenerators(t *testing.T) {
+	tcs := []struct {
+		name    string
+		gen     func(Clock) IDGenerator
+		pattern string
+		less    func(a, b string) bool
+	}{
+		{
+			name:    "uuidv7",
+			gen:     UUIDv7,
+			pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`,
+		},
+		{
+			name:    "ulid",
+			gen:     ULID,
+			pattern: `^[0-7][0-9A-HJKMNP-TV-Z]{25}$`,
+		},
+		{
+			name: "snowflake",
+			gen: func(c Clock) IDGenerator {
+				return Snowflake(3, time.Unix(0, 0), c)
+			},
+			pattern: `^[0-9]+$`,
+			less: func(a, b string) bool {
+				x, _ := strconv.ParseInt(a, 10, 64)
+				y, _ := strconv.ParseInt(b, 10, 64)
+				return x < y
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := newFakeClock()
+			clock.Advance(time.Hour)
+			gen := tc.gen(clock)
+			less := tc.less
+			if less == nil {
+				less = func(a, b string) bool { return a < b }
+			}
+
+			// many ids in the same millisecond, overflowing the sequence of some generators
+			var ids []string
+			for i := 0; i < 5000; i++ {
+				ids = append(ids, gen.NewID())
+				if i%2000 == 0 {
+					clock.Advance(time.Millisecond)
+				}
+			}
+
+			re := regexp.MustCompile(tc.pattern)
+			seen := make(map[string]bool)
+			for _, id := range ids {
+				if !re.MatchString(id) {
+					t.Fatalf("malformed id %q", id)
+				}
+				if seen[id] {
+					t.Fatalf("duplicate id %q", id)
+				}
+				seen[id] = true
+			}
+
+			if !sort.SliceIsSorted(ids, func(i, j int) bool { return less(ids[i], ids[j]) }) {
+				t.Error("ids are not sorted")
+			}
+		})
+	}
+}
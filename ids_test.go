@@ -0,0 +1,49 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+)
+
+func ExampleWithIDs() {
+	ctx := context.Background()
+
+	var n int
+	gen := IDGeneratorFunc(func() string {
+		n++
+		return fmt.Sprintf("evt-%03d", n)
+	})
+
+	em, o := Pair(WithIDs(gen))
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if env, ok := e.(Envelope); ok {
+			fmt.Println(env.ID, env.Event)
+		}
+	}))
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, NewEvent("b").Build())
+	em.Emit(ctx, Envelope{Event: stringEvent("c"), ID: "mine"})
+
+	// Output:
+	// evt-001 a
+	// evt-002 b <nil>
+	// mine c
+}
@@ -0,0 +1,141 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+// Compact removes events that have been superseded by a newer event with the same key, for state-style streams
+// where only the latest event per key matters. key returns the key of an event; events with an empty key are kept.
+// Events younger than horizon are kept as well, so recent history stays complete.
+// The segment currently being appended to is not compacted. Compact returns the number of removed events.
+func (j *Journal) Compact(key func(voyeur.Event) string, horizon time.Duration) (int, error) {
+	newest, keys, err := j.findSuperseded(key)
+	if err != nil {
+		return 0, err
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	// segments closed after the keys were found have no keys, so their events are all kept
+	cutoff := j.opts.Clock.Now().Add(-horizon)
+	removed := 0
+	kept := j.segments[:0:0]
+
+	for _, seg := range j.segments[:len(j.segments)-1] {
+		var recs []Record
+		changed := false
+		err := readSegment(seg.path, func(rec Record) error {
+			k, keyed := keys[rec.Seq]
+			if keyed && newest[k] != rec.Seq && rec.Time.Before(cutoff) {
+				removed++
+				changed = true
+				return nil
+			}
+
+			recs = append(recs, rec)
+			return nil
+		})
+		if err != nil {
+			return removed, err
+		}
+
+		switch {
+		case len(recs) == 0:
+			if err := os.Remove(seg.path); err != nil {
+				return removed, err
+			}
+			continue
+		case changed:
+			if err := writeSegment(seg.path, recs); err != nil {
+				return removed, err
+			}
+		}
+		kept = append(kept, seg)
+	}
+
+	j.segments = append(kept, j.segments[len(j.segments)-1])
+	return removed, nil
+}
+
+// findSuperseded returns the newest sequence number of each key, and the keys of the events in closed segments.
+// The journal is only read-locked meanwhile, so appending goes on. Events appended afterwards only supersede more.
+func (j *Journal) findSuperseded(key func(voyeur.Event) string) (map[string]uint64, map[uint64]string, error) {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	newest := make(map[string]uint64)
+	keys := make(map[uint64]string)
+	active := j.segments[len(j.segments)-1].first
+
+	err := j.records(0, func(rec Record) error {
+		ent, err := j.decode(rec)
+		if err != nil {
+			return err
+		}
+
+		k := key(ent.Event)
+		if k == "" {
+			return nil
+		}
+
+		newest[k] = rec.Seq
+		if rec.Seq < active {
+			keys[rec.Seq] = k
+		}
+		return nil
+	})
+
+	return newest, keys, err
+}
+
+// writeSegment atomically replaces the segment at path with recs.
+func writeSegment(path string, recs []Record) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, rec := range recs {
+		if err := enc.Encode(rec); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,130 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+func TestCompact(t *testing.T) {
+	ctx := context.Background()
+	j := openTest(t, t.TempDir())
+	defer j.Close()
+
+	// notes are "key=value"
+	for _, n := range []string{"a=1", "b=1", "a=2", "c=1", "a=3", "b=2", "x", "x", "c=2"} {
+		j.Append(ctx, note(n))
+	}
+
+	key := func(e voyeur.Event) string {
+		k, _, _ := strings.Cut(string(e.(note)), "=")
+		if k == "x" {
+			return ""
+		}
+		return k
+	}
+
+	removed, err := j.Compact(key, -time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []voyeur.Event
+	if err := j.Replay(ctx, 0, collect(&got)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the last segment is not compacted, so the exact result depends on the segment size
+	for _, stale := range []string{"a=1", "b=1"} {
+		for _, e := range got {
+			if e == note(stale) {
+				t.Errorf("%s was not compacted away: %v", stale, got)
+			}
+		}
+	}
+
+	if removed != 9-len(got) {
+		t.Errorf("removed %d, but %d events are left", removed, len(got))
+	}
+
+	if last := got[len(got)-1]; last != note("c=2") {
+		t.Errorf("expected c=2 to be last, got %v", last)
+	}
+
+	// sequence numbers keep counting after compaction
+	if seq, _ := j.Append(ctx, note("d=1")); seq != 10 {
+		t.Errorf("expected sequence number 10, got %d", seq)
+	}
+}
+
+func TestCompactHorizon(t *testing.T) {
+	ctx := context.Background()
+	j := openTest(t, t.TempDir())
+	defer j.Close()
+
+	for i := 0; i < 10; i++ {
+		j.Append(ctx, note(fmt.Sprint("k=", i)))
+	}
+
+	key := func(e voyeur.Event) string { return "k" }
+	if removed, _ := j.Compact(key, time.Hour); removed != 0 {
+		t.Errorf("expected events within the horizon to be kept, removed %d", removed)
+	}
+}
+
+func TestCompactUnchanged(t *testing.T) {
+	ctx := context.Background()
+	j := openTest(t, t.TempDir())
+	defer j.Close()
+
+	for i := 0; i < 9; i++ {
+		j.Append(ctx, note(fmt.Sprint(i)))
+	}
+
+	j.lock.RLock()
+	first := j.segments[0].path
+	j.lock.RUnlock()
+	before, err := os.Stat(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every key is different, so nothing is superseded
+	removed, err := j.Compact(func(e voyeur.Event) string { return string(e.(note)) }, -time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 0 {
+		t.Errorf("expected nothing to be removed, got %d", removed)
+	}
+
+	after, err := os.Stat(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) {
+		t.Error("unchanged segment was rewritten")
+	}
+}
@@ -0,0 +1,159 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The export format is a stream of JSON values, one per line:
+//
+//	{"format":"voyeur-journal","version":1}
+//	{"seq":1,"time":"...","type":"...","data":"..."}
+//	...
+//	{"end":true,"count":2}
+//
+// The first line is the header. It is followed by the exported records, as stored in the journal, and a trailer
+// holding the number of records, which lets Import detect truncated exports.
+// Data is encoded by the Codec of the exporting journal; the importing journal needs a compatible one.
+
+// ExportFormat and ExportVersion identify the export format.
+const (
+	ExportFormat  = "voyeur-journal"
+	ExportVersion = 1
+)
+
+type exportHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+type exportTrailer struct {
+	End   bool   `json:"end"`
+	Count uint64 `json:"count"`
+}
+
+// Export writes the records with sequence numbers from from to to, both inclusive, to w.
+// If to is zero, everything from from on is exported. It returns the number of exported records.
+func (j *Journal) Export(ctx context.Context, w io.Writer, from, to uint64) (uint64, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	if err := enc.Encode(exportHeader{Format: ExportFormat, Version: ExportVersion}); err != nil {
+		return 0, err
+	}
+
+	var count uint64
+
+	j.lock.RLock()
+	err := j.records(from, func(rec Record) error {
+		if to != 0 && rec.Seq > to {
+			return errStop
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		count++
+		return enc.Encode(rec)
+	})
+	j.lock.RUnlock()
+
+	if err != nil {
+		return count, err
+	}
+
+	if err := enc.Encode(exportTrailer{End: true, Count: count}); err != nil {
+		return count, err
+	}
+
+	return count, bw.Flush()
+}
+
+// Import appends the records exported to r. They keep their time but get new sequence numbers.
+// The records are checked completely before anything is appended, so a broken export doesn't change the journal.
+// It returns the number of imported records.
+func (j *Journal) Import(ctx context.Context, r io.Reader) (uint64, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+
+	var hdr exportHeader
+	if err := dec.Decode(&hdr); err != nil {
+		return 0, fmt.Errorf("journal: reading export header: %w", err)
+	}
+
+	if hdr.Format != ExportFormat {
+		return 0, fmt.Errorf("journal: not a journal export: format %q", hdr.Format)
+	}
+	if hdr.Version != ExportVersion {
+		return 0, fmt.Errorf("journal: unsupported export version %d", hdr.Version)
+	}
+
+	var ents []Entry
+	var recs []Record
+	for {
+		var line struct {
+			Record
+			exportTrailer
+		}
+		if err := dec.Decode(&line); err != nil {
+			if err == io.EOF {
+				err = errors.New("missing trailer")
+			}
+			return 0, fmt.Errorf("journal: reading export: %w", err)
+		}
+
+		if line.End {
+			if line.Count != uint64(len(recs)) {
+				return 0, fmt.Errorf("journal: export has %d records, trailer says %d", len(recs), line.Count)
+			}
+			break
+		}
+
+		ent, err := j.decode(line.Record)
+		if err != nil {
+			return 0, err
+		}
+
+		recs = append(recs, line.Record)
+		ents = append(ents, ent)
+	}
+
+	j.emitLock.Lock()
+	defer j.emitLock.Unlock()
+
+	j.lock.Lock()
+	var err error
+	n := 0
+	for ; n < len(recs); n++ {
+		if ents[n].Seq, err = j.append(recs[n]); err != nil {
+			break
+		}
+	}
+	j.lock.Unlock()
+
+	for _, ent := range ents[:n] {
+		j.em.Emit(ctx, ent)
+	}
+
+	return uint64(n), err
+}
@@ -0,0 +1,74 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+
+	src := openTest(t, t.TempDir())
+	defer src.Close()
+	for i := 0; i < 5; i++ {
+		src.Append(ctx, note(fmt.Sprint(i)))
+	}
+
+	var buf bytes.Buffer
+	if n, err := src.Export(ctx, &buf, 2, 4); err != nil || n != 3 {
+		t.Fatalf("export: %d, %v", n, err)
+	}
+
+	dst := openTest(t, t.TempDir())
+	defer dst.Close()
+	dst.Append(ctx, note("x"))
+
+	// a truncated export is rejected as a whole
+	lines := strings.SplitAfter(buf.String(), "\n")
+	truncated := strings.Join(lines[:len(lines)-2], "")
+	if _, err := dst.Import(ctx, strings.NewReader(truncated)); err == nil {
+		t.Error("expected truncated export to fail")
+	}
+
+	if n, err := dst.Import(ctx, &buf); err != nil || n != 3 {
+		t.Fatalf("import: %d, %v", n, err)
+	}
+
+	var got []voyeur.Event
+	dst.Replay(ctx, 0, collect(&got))
+	if fmt.Sprint(got) != "[x 1 2 3]" {
+		t.Errorf("unexpected events after import %v", got)
+	}
+}
+
+func TestImportVersion(t *testing.T) {
+	j := openTest(t, t.TempDir())
+	defer j.Close()
+
+	_, err := j.Import(context.Background(), strings.NewReader(`{"format":"voyeur-journal","version":99}`))
+	if err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("expected version error, got %v", err)
+	}
+}
@@ -0,0 +1,530 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+Package journal persists events to disk and replays them.
+
+A journal is a directory of segment files. Each event appended to the journal gets the next sequence number,
+starting at 1, and is stored as a JSON line. How the event itself is encoded is up to the Codec.
+*/
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+// Codec encodes events for storage.
+type Codec interface {
+	Marshal(voyeur.Event) ([]byte, error)
+	Unmarshal(typ string, data []byte) (voyeur.Event, error)
+}
+
+// JSONCodec encodes events as JSON. Events are decoded to the Go type registered for their EventType.
+type JSONCodec struct {
+	// Types defaults to voyeur.DefaultTypes().
+	Types *voyeur.TypeRegistry
+}
+
+func (c JSONCodec) Marshal(e voyeur.Event) ([]byte, error) {
+	return json.Marshal(e)
+}
+
+func (c JSONCodec) Unmarshal(typ string, data []byte) (voyeur.Event, error) {
+	types := c.Types
+	if types == nil {
+		types = voyeur.DefaultTypes()
+	}
+
+	return types.Unmarshal(typ, func(v interface{}) error {
+		return json.Unmarshal(data, v)
+	})
+}
+
+// GobCodec encodes events using encoding/gob. Events are decoded to the Go type registered for their EventType.
+type GobCodec struct {
+	// Types defaults to voyeur.DefaultTypes().
+	Types *voyeur.TypeRegistry
+}
+
+func (c GobCodec) Marshal(e voyeur.Event) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(e)
+	return buf.Bytes(), err
+}
+
+func (c GobCodec) Unmarshal(typ string, data []byte) (voyeur.Event, error) {
+	types := c.Types
+	if types == nil {
+		types = voyeur.DefaultTypes()
+	}
+
+	return types.Unmarshal(typ, func(v interface{}) error {
+		return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+	})
+}
+
+// Record is an event as stored in the journal.
+type Record struct {
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	Data []byte    `json:"data"`
+}
+
+// Entry is a decoded Record.
+type Entry struct {
+	Seq   uint64
+	Time  time.Time
+	Event voyeur.Event
+}
+
+// EventType returns the EventType of the journaled event.
+func (e Entry) EventType() string {
+	return e.Event.EventType()
+}
+
+// Options configure a Journal.
+type Options struct {
+	// Codec defaults to JSONCodec.
+	Codec Codec
+
+	// SegmentSize is the size in bytes after which a new segment file is started. Defaults to 64 MiB.
+	SegmentSize int64
+
+	// Clock sets the time of records. Defaults to voyeur.SystemClock.
+	Clock voyeur.Clock
+
+	// Retention limits how much history is kept. The zero value keeps everything.
+	Retention Retention
+}
+
+const segmentExt = ".log"
+
+type segment struct {
+	first uint64
+	path  string
+}
+
+// Journal is an append-only, persistent log of events.
+// It is an Observer, which appends the observed events, and an Observable, which emits the appended events.
+// Observers of a Journal are called while appending is blocked, so they must not append or subscribe to it
+// themselves, but they may read it.
+type Journal struct {
+	dir  string
+	opts Options
+
+	// emitLock keeps the appended events in order and keeps them from being emitted while an observer catches up.
+	// It is taken before lock, which is not held while emitting.
+	emitLock sync.Mutex
+
+	lock     sync.RWMutex
+	segments []segment
+	f        *os.File
+	size     int64
+	last     uint64
+
+	em voyeur.Emitter
+	o  voyeur.Observable
+
+	dropsEm voyeur.Emitter
+	dropsO  voyeur.Observable
+	closed  chan struct{}
+}
+
+// Open opens the journal in dir, creating it if it doesn't exist.
+func Open(dir string, opts Options) (*Journal, error) {
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec{}
+	}
+	if opts.SegmentSize == 0 {
+		opts.SegmentSize = 64 << 20
+	}
+	if opts.Clock == nil {
+		opts.Clock = voyeur.SystemClock
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	em, o := voyeur.Pair(voyeur.WithName("journal " + dir))
+	dropsEm, dropsO := voyeur.Pair(voyeur.WithName("journal " + dir + " drops"))
+	j := &Journal{
+		dir:     dir,
+		opts:    opts,
+		em:      em,
+		o:       o,
+		dropsEm: dropsEm,
+		dropsO:  dropsO,
+		closed:  make(chan struct{}),
+	}
+
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+
+	if opts.Retention.Interval > 0 {
+		go j.prune()
+	}
+
+	return j, nil
+}
+
+// load finds the segments and the last sequence number and opens the last segment for appending.
+func (j *Journal) load() error {
+	names, err := filepath.Glob(filepath.Join(j.dir, "*"+segmentExt))
+	if err != nil {
+		return err
+	}
+
+	j.segments = j.segments[:0]
+	for _, name := range names {
+		first, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		j.segments = append(j.segments, segment{first: first, path: name})
+	}
+
+	sort.Slice(j.segments, func(i, k int) bool { return j.segments[i].first < j.segments[k].first })
+
+	if len(j.segments) == 0 {
+		return j.roll(1)
+	}
+
+	seg := j.segments[len(j.segments)-1]
+	j.last = seg.first - 1
+	intact, err := scanSegment(seg.path, func(rec Record) error {
+		j.last = rec.Seq
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(seg.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	// drop a torn record, so appended ones don't continue it
+	if fi.Size() > intact {
+		if err := f.Truncate(intact); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	j.f, j.size = f, intact
+	return nil
+}
+
+func segmentPath(dir string, first uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", first, segmentExt))
+}
+
+// roll closes the current segment and starts a new one with the given first sequence number.
+func (j *Journal) roll(first uint64) error {
+	if j.f != nil {
+		if err := j.f.Close(); err != nil {
+			return err
+		}
+	}
+
+	path := segmentPath(j.dir, first)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	j.f, j.size = f, 0
+	j.segments = append(j.segments, segment{first: first, path: path})
+	if j.last == 0 {
+		j.last = first - 1
+	}
+
+	return nil
+}
+
+// errStop stops reading records early, without error.
+var errStop = errors.New("stop")
+
+func readSegment(path string, fn func(Record) error) error {
+	_, err := scanSegment(path, fn)
+	return err
+}
+
+// scanSegment is like readSegment, but also returns the size of the segment without a torn record at its end.
+func scanSegment(path string, fn func(Record) error) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var intact int64
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var rec Record
+		err := dec.Decode(&rec)
+		if err == io.EOF {
+			fi, err := f.Stat()
+			if err != nil {
+				return 0, err
+			}
+			return fi.Size(), nil
+		}
+		if err == io.ErrUnexpectedEOF {
+			// a torn write at the end of the segment, e.g. after a crash.
+			// Records are written with their newline, so that of the last intact one is there.
+			if intact > 0 {
+				intact++
+			}
+			return intact, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("journal: reading %s: %w", path, err)
+		}
+
+		if err := fn(rec); err != nil {
+			return 0, err
+		}
+		intact = dec.InputOffset()
+	}
+}
+
+// Append stores e and returns its sequence number.
+func (j *Journal) Append(ctx context.Context, e voyeur.Event) (uint64, error) {
+	data, err := j.opts.Codec.Marshal(e)
+	if err != nil {
+		return 0, fmt.Errorf("journal: encoding %s: %w", e.EventType(), err)
+	}
+
+	j.emitLock.Lock()
+	defer j.emitLock.Unlock()
+
+	j.lock.Lock()
+	rec := Record{Time: j.opts.Clock.Now(), Type: e.EventType(), Data: data}
+	seq, err := j.append(rec)
+	j.lock.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	j.em.Emit(ctx, Entry{Seq: seq, Time: rec.Time, Event: e})
+	return seq, nil
+}
+
+// append writes rec with the next sequence number. j.lock must be held. The caller emits the entry after
+// releasing it, while still holding j.emitLock.
+func (j *Journal) append(rec Record) (uint64, error) {
+	if j.f == nil {
+		return 0, errors.New("journal: closed")
+	}
+
+	rec.Seq = j.last + 1
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return 0, err
+	}
+	line = append(line, '\n')
+
+	if j.size > 0 && j.size+int64(len(line)) > j.opts.SegmentSize {
+		if err := j.roll(rec.Seq); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := j.f.Write(line)
+	j.size += int64(n)
+	if err != nil {
+		return 0, err
+	}
+
+	j.last = rec.Seq
+	return rec.Seq, nil
+}
+
+// OnEvent appends e. End is not appended. Errors are dropped; use Append to see them.
+func (j *Journal) OnEvent(ctx context.Context, e voyeur.Event) {
+	if e != voyeur.End {
+		j.Append(ctx, e)
+	}
+}
+
+// Register registers oer for the events appended from now on.
+func (j *Journal) Register(ctx context.Context, oer voyeur.Observer) {
+	j.o.Register(ctx, unwrap(oer))
+}
+
+// unwrap passes on the journaled events instead of Entries.
+func unwrap(oer voyeur.Observer) voyeur.Observer {
+	return voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		if ent, ok := e.(Entry); ok {
+			e = ent.Event
+		}
+		oer.OnEvent(ctx, e)
+	})
+}
+
+// Last returns the sequence number of the last appended event, or 0 if the journal is empty.
+func (j *Journal) Last() uint64 {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	return j.last
+}
+
+// records calls fn for all records with a sequence number of at least from. j.lock must be held.
+func (j *Journal) records(from uint64, fn func(Record) error) error {
+	for i, seg := range j.segments {
+		if i+1 < len(j.segments) && j.segments[i+1].first <= from {
+			continue
+		}
+
+		err := readSegment(seg.path, func(rec Record) error {
+			if rec.Seq < from {
+				return nil
+			}
+			return fn(rec)
+		})
+		if err == errStop {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decode turns rec into an Entry.
+func (j *Journal) decode(rec Record) (Entry, error) {
+	e, err := j.opts.Codec.Unmarshal(rec.Type, rec.Data)
+	if err != nil {
+		return Entry{}, fmt.Errorf("journal: decoding event %d: %w", rec.Seq, err)
+	}
+
+	return Entry{Seq: rec.Seq, Time: rec.Time, Event: e}, nil
+}
+
+// entries calls fn for all entries with a sequence number of at least from. j.lock must be held.
+func (j *Journal) entries(ctx context.Context, from uint64, fn func(Entry) error) error {
+	return j.records(from, func(rec Record) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ent, err := j.decode(rec)
+		if err != nil {
+			return err
+		}
+
+		return fn(ent)
+	})
+}
+
+// Replay passes all stored events with a sequence number of at least from to oer.
+func (j *Journal) Replay(ctx context.Context, from uint64, oer voyeur.Observer) error {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	return j.entries(ctx, from, func(ent Entry) error {
+		oer.OnEvent(ctx, ent.Event)
+		return nil
+	})
+}
+
+// Recorded returns the stored events with a sequence number of at least from, with the time they were appended.
+// Use voyeur.MergeRecorded to replay several journals in the order of time.
+func (j *Journal) Recorded(ctx context.Context, from uint64) ([]voyeur.Recorded, error) {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	var recs []voyeur.Recorded
+	err := j.entries(ctx, from, func(ent Entry) error {
+		recs = append(recs, voyeur.Recorded{Time: ent.Time, Event: ent.Event})
+		return nil
+	})
+
+	return recs, err
+}
+
+// Backfill passes oer the stored events with a sequence number of at least from and then registers it for the
+// events appended later. As appending waits meanwhile, no event is missed or seen twice. See voyeur.WithBackfill.
+func (j *Journal) Backfill(ctx context.Context, from uint64, oer voyeur.Observer) error {
+	j.emitLock.Lock()
+	defer j.emitLock.Unlock()
+
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	err := j.entries(ctx, from, func(ent Entry) error {
+		oer.OnEvent(ctx, ent.Event)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	j.o.Register(ctx, unwrap(oer))
+	return nil
+}
+
+// Close closes the journal and ends its observers.
+func (j *Journal) Close() error {
+	j.emitLock.Lock()
+	defer j.emitLock.Unlock()
+
+	j.lock.Lock()
+	if j.f == nil {
+		j.lock.Unlock()
+		return nil
+	}
+
+	err := j.f.Close()
+	j.f = nil
+	close(j.closed)
+	j.lock.Unlock()
+
+	j.em.End(context.Background())
+	j.dropsEm.End(context.Background())
+	return err
+}
@@ -0,0 +1,381 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+type note string
+
+func (note) EventType() string {
+	return "note"
+}
+
+func testTypes() *voyeur.TypeRegistry {
+	types := voyeur.NewTypeRegistry()
+	types.Register(note(""))
+	return types
+}
+
+func openTest(t *testing.T, dir string) *Journal {
+	t.Helper()
+
+	j, err := Open(dir, Options{Codec: JSONCodec{Types: testTypes()}, SegmentSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return j
+}
+
+// collect appends all events but End to into.
+func collect(into *[]voyeur.Event) voyeur.Observer {
+	return voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		if e != voyeur.End {
+			*into = append(*into, e)
+		}
+	})
+}
+
+func TestJournalReopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	j := openTest(t, dir)
+	for i := 0; i < 10; i++ {
+		if _, err := j.Append(ctx, note(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+
+	j = openTest(t, dir)
+	defer j.Close()
+
+	if len(j.segments) < 2 {
+		t.Errorf("expected several segments, got %d", len(j.segments))
+	}
+
+	if seq, _ := j.Append(ctx, note("10")); seq != 11 {
+		t.Errorf("expected sequence number 11, got %d", seq)
+	}
+
+	var got []voyeur.Event
+	if err := j.Replay(ctx, 5, collect(&got)); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(got) != "[4 5 6 7 8 9 10]" {
+		t.Errorf("unexpected replay %v", got)
+	}
+}
+
+func TestJournalTornWrite(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// a single segment, so appending continues the torn one
+	open := func() *Journal {
+		j, err := Open(dir, Options{Codec: JSONCodec{Types: testTypes()}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return j
+	}
+
+	j := open()
+	for i := 0; i < 3; i++ {
+		if _, err := j.Append(ctx, note(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	last := j.segments[len(j.segments)-1].path
+	j.Close()
+
+	// simulate a crash while appending
+	f, err := os.OpenFile(last, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq":4,"ti`)
+	f.Close()
+
+	j = open()
+	if seq, err := j.Append(ctx, note("3")); err != nil || seq != 4 {
+		t.Fatalf("expected sequence number 4, got %d, %v", seq, err)
+	}
+	j.Close()
+
+	j = open()
+	defer j.Close()
+
+	var got []voyeur.Event
+	if err := j.Replay(ctx, 1, collect(&got)); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(got) != "[0 1 2 3]" {
+		t.Errorf("unexpected replay %v", got)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	dir := t.TempDir()
+
+	j := openTest(t, dir)
+	j.Append(ctx, note("a"))
+	j.Append(ctx, note("b"))
+
+	var got []voyeur.Event
+	if _, err := j.Subscribe(ctx, "printer", collect(&got)); err != nil {
+		t.Fatal(err)
+	}
+	j.Append(ctx, note("c"))
+	cancel()
+	j.Close()
+
+	// events appended while the consumer was gone are delivered after resubscribing
+	ctx = context.Background()
+	j = openTest(t, dir)
+	defer j.Close()
+	j.Append(ctx, note("d"))
+
+	if _, err := j.Subscribe(ctx, "printer", collect(&got)); err != nil {
+		t.Fatal(err)
+	}
+	j.Append(ctx, note("e"))
+
+	if fmt.Sprint(got) != "[a b c d e]" {
+		t.Errorf("unexpected events %v", got)
+	}
+
+	if off, _ := j.Offset("printer"); off != 5 {
+		t.Errorf("expected offset 5, got %d", off)
+	}
+}
+
+func TestReadFromObserver(t *testing.T) {
+	ctx := context.Background()
+	j := openTest(t, t.TempDir())
+	defer j.Close()
+
+	var sub *Subscription
+	lasts := make(chan uint64, 2)
+	sub, err := j.Subscribe(ctx, "reader", voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		if e != voyeur.End {
+			sub.Stats()
+			lasts <- j.Last()
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		j.Append(ctx, note("a"))
+		j.Append(ctx, note("b"))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reading the journal from an observer deadlocked")
+	}
+	if a, b := <-lasts, <-lasts; a != 1 || b != 2 {
+		t.Errorf("expected observers to see the last sequence numbers 1 and 2, got %d and %d", a, b)
+	}
+}
+
+func TestCommitModes(t *testing.T) {
+	ctx := context.Background()
+	j := openTest(t, t.TempDir())
+	defer j.Close()
+
+	for i := 0; i < 3; i++ {
+		j.Append(ctx, note(fmt.Sprint(i)))
+	}
+
+	var acks []context.Context
+	onAck, err := j.Subscribe(ctx, "acking", voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		acks = append(acks, ctx)
+	}), WithCommitMode(CommitOnAck, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manual, err := j.Subscribe(ctx, "manual", collect(new([]voyeur.Event)), WithCommitMode(CommitManually, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st := onAck.Stats(); st.Delivered != 3 || st.Committed != 0 || st.Lag != 3 {
+		t.Errorf("unexpected stats before ack %+v", st)
+	}
+
+	voyeur.Ack(acks[1])
+	if st := onAck.Stats(); st.Committed != 2 || st.Lag != 1 {
+		t.Errorf("unexpected stats after ack %+v", st)
+	}
+
+	voyeur.Nack(acks[2], nil)
+	if st := onAck.Stats(); st.Committed != 2 {
+		t.Errorf("nacked event was committed %+v", st)
+	}
+
+	j.Append(ctx, note("3"))
+	if st := manual.Stats(); st.Delivered != 4 || st.Committed != 0 || st.Lag != 4 {
+		t.Errorf("unexpected stats before commit %+v", st)
+	}
+
+	manual.Commit()
+	if off, _ := j.Offset("manual"); off != 4 {
+		t.Errorf("expected offset 4 after commit, got %d", off)
+	}
+}
+
+func TestCommitPeriodicallyDefaultInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := openTest(t, t.TempDir())
+	defer j.Close()
+
+	j.Append(ctx, note("a"))
+
+	// a zero interval means the default, it must not make the ticker panic
+	if _, err := j.Subscribe(ctx, "periodic", collect(new([]voyeur.Event)), WithCommitMode(CommitPeriodically, 0)); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	// the offset is committed when the subscription ends
+	for i := 0; ; i++ {
+		if off, _ := j.Offset("periodic"); off == 1 {
+			break
+		}
+		if i == 100 {
+			t.Fatal("offset not committed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	ctx := context.Background()
+	j := openTest(t, t.TempDir())
+	defer j.Close()
+
+	for i := 0; i < 50; i++ {
+		j.Append(ctx, note(fmt.Sprint(i)))
+	}
+
+	// keep appending while the observer switches from history to live events
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 50; i < 100; i++ {
+			j.Append(ctx, note(fmt.Sprint(i)))
+		}
+	}()
+
+	var (
+		lock sync.Mutex
+		got  []voyeur.Event
+	)
+	err := voyeur.Register(ctx, j, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		lock.Lock()
+		defer lock.Unlock()
+		if e != voyeur.End {
+			got = append(got, e)
+		}
+	}), voyeur.WithBackfill(j, 11))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(got) != 90 {
+		t.Fatalf("expected 90 events, got %d", len(got))
+	}
+	for i, e := range got {
+		if e != note(fmt.Sprint(i+10)) {
+			t.Fatalf("expected %d at %d, got %v", i+10, i, e)
+		}
+	}
+}
+
+// tickClock advances by a second each time it is asked for the time.
+type tickClock struct {
+	voyeur.Clock
+	now *time.Time
+}
+
+func (c tickClock) Now() time.Time {
+	*c.now = c.now.Add(time.Second)
+	return *c.now
+}
+
+func TestMergeRecorded(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Unix(0, 0)
+	clock := tickClock{Clock: voyeur.SystemClock, now: &now}
+	open := func() *Journal {
+		j, err := Open(t.TempDir(), Options{Codec: JSONCodec{Types: testTypes()}, Clock: clock})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return j
+	}
+
+	a, b := open(), open()
+	defer a.Close()
+	defer b.Close()
+
+	for i, j := range []*Journal{a, b, b, a, b} {
+		j.Append(ctx, note(fmt.Sprint(i)))
+	}
+
+	recA, err := a.Recorded(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recB, err := b.Recorded(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, rec := range voyeur.MergeRecorded(recA, recB) {
+		got = append(got, string(rec.Event.(note)))
+	}
+	if fmt.Sprint(got) != "[0 1 2 3 4]" {
+		t.Errorf("unexpected order %v", got)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+// Upcaster converts an event from an old schema version to the current one.
+// It returns events that don't need converting unchanged.
+type Upcaster func(voyeur.Event) (voyeur.Event, error)
+
+// Migrate copies all events of src to dst, which must be empty, e.g. to move to another Codec.
+// If up is not nil, every event is passed through it on the way. The events keep their sequence numbers and times,
+// and the offsets of durable consumers are copied, so consumers can continue on dst where they left off on src.
+// Afterwards dst is read back and compared to src, and an error is returned if they differ.
+// Neither journal may be appended to while migrating. Migrate returns the number of migrated events.
+func Migrate(ctx context.Context, src, dst *Journal, up Upcaster) (uint64, error) {
+	if dst.Last() != 0 {
+		return 0, errors.New("journal: migration target is not empty")
+	}
+
+	var migrated []Entry
+
+	src.lock.RLock()
+	err := src.entries(ctx, 0, func(ent Entry) error {
+		if up != nil {
+			e, err := up(ent.Event)
+			if err != nil {
+				return fmt.Errorf("journal: upcasting event %d: %w", ent.Seq, err)
+			}
+			ent.Event = e
+		}
+
+		data, err := dst.opts.Codec.Marshal(ent.Event)
+		if err != nil {
+			return fmt.Errorf("journal: encoding event %d: %w", ent.Seq, err)
+		}
+
+		dst.emitLock.Lock()
+		defer dst.emitLock.Unlock()
+
+		dst.lock.Lock()
+		dst.last = ent.Seq - 1
+		_, err = dst.append(Record{Time: ent.Time, Type: ent.Event.EventType(), Data: data})
+		dst.lock.Unlock()
+		if err != nil {
+			return err
+		}
+
+		dst.em.Emit(ctx, ent)
+		migrated = append(migrated, ent)
+		return nil
+	})
+	src.lock.RUnlock()
+
+	if err != nil {
+		return uint64(len(migrated)), err
+	}
+
+	if err := verify(ctx, dst, migrated); err != nil {
+		return uint64(len(migrated)), err
+	}
+
+	return uint64(len(migrated)), copyOffsets(src.dir, dst.dir)
+}
+
+// verify checks that j holds exactly the expected entries.
+func verify(ctx context.Context, j *Journal, exp []Entry) error {
+	i := 0
+
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	err := j.entries(ctx, 0, func(ent Entry) error {
+		if i >= len(exp) {
+			return fmt.Errorf("journal: migration verification: unexpected event %d", ent.Seq)
+		}
+
+		e := exp[i]
+		if ent.Seq != e.Seq || !ent.Time.Equal(e.Time) || !reflect.DeepEqual(ent.Event, e.Event) {
+			return fmt.Errorf("journal: migration verification: event %d differs", e.Seq)
+		}
+
+		i++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if i != len(exp) {
+		return fmt.Errorf("journal: migration verification: %d of %d events found", i, len(exp))
+	}
+
+	return nil
+}
+
+func copyOffsets(srcDir, dstDir string) error {
+	names, err := filepath.Glob(filepath.Join(srcDir, "offsets", "*"))
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(dstDir, "offsets"), 0o755); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filepath.Join(dstDir, "offsets", filepath.Base(name)), data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+// noteV2 is the new schema of note, split into key and value.
+type noteV2 struct {
+	Key, Value string
+}
+
+func (noteV2) EventType() string {
+	return "note.v2"
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	types := testTypes()
+	types.Register(noteV2{})
+
+	src, err := Open(t.TempDir(), Options{Codec: JSONCodec{Types: types}, SegmentSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	for _, n := range []string{"a=1", "b=2", "a=3"} {
+		src.Append(ctx, note(n))
+	}
+
+	sub, _ := src.Subscribe(ctx, "consumer", collect(new([]voyeur.Event)), WithCommitMode(CommitManually, 0))
+	sub.CommitSeq(2)
+
+	dst, err := Open(t.TempDir(), Options{Codec: GobCodec{Types: types}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	up := func(e voyeur.Event) (voyeur.Event, error) {
+		k, v, _ := strings.Cut(string(e.(note)), "=")
+		return noteV2{Key: k, Value: v}, nil
+	}
+
+	if n, err := Migrate(ctx, src, dst, up); err != nil || n != 3 {
+		t.Fatalf("migrate: %d, %v", n, err)
+	}
+
+	var got []voyeur.Event
+	if _, err := dst.Subscribe(ctx, "consumer", collect(&got)); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(got) != "[{a 3}]" {
+		t.Errorf("unexpected events for resumed consumer %v", got)
+	}
+
+	if _, err := Migrate(ctx, src, dst, up); err == nil {
+		t.Error("expected migrating into non-empty journal to fail")
+	}
+}
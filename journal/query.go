@@ -0,0 +1,112 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"context"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+// Query selects events from the journal. All set conditions must match. The zero Query matches everything.
+type Query struct {
+	// Types, if not empty, only matches events with one of these EventTypes.
+	Types []string
+
+	// FromSeq and ToSeq, if not zero, restrict the sequence numbers, both inclusive.
+	FromSeq, ToSeq uint64
+
+	// Since and Until, if not zero, restrict the time the events were appended at, both inclusive.
+	Since, Until time.Time
+
+	// KeyFunc and Key, if KeyFunc is not nil, only match events for which KeyFunc returns Key.
+	KeyFunc func(voyeur.Event) string
+	Key     string
+}
+
+// matchRecord checks the conditions that don't need the decoded event.
+func (q Query) matchRecord(rec Record) bool {
+	if q.ToSeq != 0 && rec.Seq > q.ToSeq {
+		return false
+	}
+
+	if !q.Since.IsZero() && rec.Time.Before(q.Since) {
+		return false
+	}
+
+	if !q.Until.IsZero() && rec.Time.After(q.Until) {
+		return false
+	}
+
+	if len(q.Types) == 0 {
+		return true
+	}
+
+	for _, typ := range q.Types {
+		if rec.Type == typ {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Find passes all events matching q to oer, in order.
+func (j *Journal) Find(ctx context.Context, q Query, oer voyeur.Observer) error {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	return j.records(q.FromSeq, func(rec Record) error {
+		if q.ToSeq != 0 && rec.Seq > q.ToSeq {
+			return errStop
+		}
+
+		if !q.matchRecord(rec) {
+			return nil
+		}
+
+		ent, err := j.decode(rec)
+		if err != nil {
+			return err
+		}
+
+		if q.KeyFunc != nil && q.KeyFunc(ent.Event) != q.Key {
+			return nil
+		}
+
+		oer.OnEvent(ctx, ent.Event)
+		return ctx.Err()
+	})
+}
+
+// Query returns an Observable that passes the events matching q to each observer registering on it, and then End.
+// Each registration runs the query again. Errors end the replay early; use Find to see them.
+func (j *Journal) Query(q Query) voyeur.Observable {
+	return query{j: j, q: q}
+}
+
+type query struct {
+	j *Journal
+	q Query
+}
+
+func (q query) Register(ctx context.Context, oer voyeur.Observer) {
+	q.j.Find(ctx, q.q, oer)
+	oer.OnEvent(ctx, voyeur.End)
+}
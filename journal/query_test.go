@@ -0,0 +1,87 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+type alarm string
+
+func (alarm) EventType() string {
+	return "alarm"
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	clock := &stepClock{now: time.Unix(1000, 0)}
+
+	types := testTypes()
+	types.Register(alarm(""))
+	j, err := Open(t.TempDir(), Options{Codec: JSONCodec{Types: types}, SegmentSize: 200, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	for _, e := range []voyeur.Event{note("a=1"), alarm("fire"), note("b=1"), note("a=2"), alarm("flood"), note("a=3")} {
+		j.Append(ctx, e)
+	}
+
+	key := func(e voyeur.Event) string {
+		k, _, _ := strings.Cut(fmt.Sprint(e), "=")
+		return k
+	}
+
+	for _, tc := range []struct {
+		q   Query
+		exp string
+	}{
+		{Query{}, "[a=1 fire b=1 a=2 flood a=3 End]"},
+		{Query{Types: []string{"alarm"}}, "[fire flood End]"},
+		{Query{FromSeq: 2, ToSeq: 4}, "[fire b=1 a=2 End]"},
+		{Query{Since: time.Unix(1002, 0), Until: time.Unix(1004, 0)}, "[fire b=1 a=2 End]"},
+		{Query{KeyFunc: key, Key: "a", FromSeq: 2}, "[a=2 a=3 End]"},
+	} {
+		var got []voyeur.Event
+		j.Query(tc.q).Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+			got = append(got, e)
+		}))
+
+		if fmt.Sprint(got) != tc.exp {
+			t.Errorf("query %+v: expected %s, got %v", tc.q, tc.exp, got)
+		}
+	}
+}
+
+// stepClock advances by a second each time it is read.
+type stepClock struct {
+	voyeur.Clock
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
@@ -0,0 +1,350 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+const queueExt = ".event"
+
+// QueueOptions configure a Queue.
+type QueueOptions struct {
+	// Codec defaults to JSONCodec.
+	Codec Codec
+
+	// OnError is called for events that can't be stored or read back. It may be nil.
+	OnError func(error)
+}
+
+// queued is an event as stored in the queue. The envelope of the event, if any, is stored next to it.
+type queued struct {
+	Seq      uint64          `json:"seq"`
+	Priority voyeur.Priority `json:"priority"`
+	Type     string          `json:"type"`
+	Data     []byte          `json:"data"`
+
+	Envelope bool                    `json:"envelope,omitempty"`
+	ID       string                  `json:"id,omitempty"`
+	Key      string                  `json:"key,omitempty"`
+	Meta     voyeur.Meta             `json:"meta,omitempty"`
+	HLC      *voyeur.HLCTimestamp    `json:"hlc,omitempty"`
+	Vector   *voyeur.VectorTimestamp `json:"vector,omitempty"`
+	Origin   []string                `json:"origin,omitempty"`
+	Parent   string                  `json:"parent,omitempty"`
+}
+
+// queueItem is a stored event waiting for delivery.
+type queueItem struct {
+	seq      uint64
+	priority voyeur.Priority
+	path     string
+}
+
+// Queue is an Emitter that stores events in a persistent priority queue. Run delivers them by priority, taken
+// from the voyeur.Envelope of an event and Normal for other events, and in the order they were emitted
+// within a priority. An event is removed after it was delivered, so events that were pending when the process
+// stopped are delivered after it restarted, high priority ones first.
+type Queue struct {
+	dir  string
+	opts QueueOptions
+
+	lock    sync.Mutex
+	pending queueHeap
+	last    uint64
+	ended   bool
+	wake    chan struct{}
+}
+
+// OpenQueue opens the queue in dir, creating it if it doesn't exist.
+func OpenQueue(dir string, opts QueueOptions) (*Queue, error) {
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec{}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	q := &Queue{dir: dir, opts: opts, wake: make(chan struct{}, 1)}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// queuePath returns the path of an event. The names sort by priority, highest first, and sequence number.
+func (q *Queue) queuePath(priority voyeur.Priority, seq uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%08x-%020d%s", ^(uint32(int32(priority))^1<<31), seq, queueExt))
+}
+
+// load finds the stored events.
+func (q *Queue) load() error {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, ent := range entries {
+		name := ent.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			// an event that wasn't stored completely
+			os.Remove(filepath.Join(q.dir, name))
+			continue
+		}
+		if !strings.HasSuffix(name, queueExt) {
+			continue
+		}
+
+		var (
+			key uint32
+			seq uint64
+		)
+		if _, err := fmt.Sscanf(strings.TrimSuffix(name, queueExt), "%08x-%020d", &key, &seq); err != nil {
+			return fmt.Errorf("journal: unexpected file %s in queue: %w", name, err)
+		}
+
+		priority := voyeur.Priority(int32(^key ^ 1<<31))
+		q.pending = append(q.pending, queueItem{seq: seq, priority: priority, path: filepath.Join(q.dir, name)})
+		if seq > q.last {
+			q.last = seq
+		}
+	}
+
+	heap.Init(&q.pending)
+	return nil
+}
+
+// Push stores e.
+func (q *Queue) Push(e voyeur.Event) error {
+	rec := queued{Priority: voyeur.Normal}
+	if env, ok := e.(voyeur.Envelope); ok {
+		rec.Envelope, rec.ID, rec.Key, rec.Meta, rec.Priority = true, env.ID, env.Key, env.Meta, env.Priority
+		if !env.HLC.IsZero() {
+			rec.HLC = &env.HLC
+		}
+		if env.Vector.Node != "" {
+			rec.Vector = &env.Vector
+		}
+		rec.Origin, rec.Parent = env.Origin, env.Parent
+		e = env.Event
+	}
+
+	data, err := q.opts.Codec.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("journal: encoding %s: %w", e.EventType(), err)
+	}
+	rec.Type, rec.Data = e.EventType(), data
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.ended {
+		return errors.New("journal: queue ended")
+	}
+
+	rec.Seq = q.last + 1
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	path := q.queuePath(rec.Priority, rec.Seq)
+	if err := os.WriteFile(path+".tmp", buf, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+
+	q.last = rec.Seq
+	heap.Push(&q.pending, queueItem{seq: rec.Seq, priority: rec.Priority, path: path})
+	q.notify()
+	return nil
+}
+
+// Emit pushes e. End makes Run end its Emitter once the queue is empty. Errors are passed to OnError.
+func (q *Queue) Emit(ctx context.Context, e voyeur.Event) {
+	if e == voyeur.End {
+		q.End(ctx)
+		return
+	}
+
+	if err := q.Push(e); err != nil {
+		q.error(err)
+	}
+}
+
+// End makes Run end its Emitter once the queue is empty. Nothing can be pushed afterwards.
+func (q *Queue) End(ctx context.Context) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.ended = true
+	q.notify()
+}
+
+// Len returns the number of events waiting for delivery.
+func (q *Queue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return len(q.pending)
+}
+
+// notify wakes Run. q.lock must be held.
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run emits the queued events on em, one at a time, removing each after Emit returned. Only one Run may be active.
+// It returns when ctx is cancelled, or after the queue was ended and is empty, in which case em is ended.
+func (q *Queue) Run(ctx context.Context, em voyeur.Emitter) error {
+	for {
+		q.lock.Lock()
+		var (
+			item  queueItem
+			ok    = len(q.pending) > 0
+			ended = q.ended
+		)
+		if ok {
+			item = q.pending[0]
+		}
+		q.lock.Unlock()
+
+		if !ok {
+			if ended {
+				em.End(ctx)
+				return nil
+			}
+
+			select {
+			case <-q.wake:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		e, err := q.read(item.path)
+		if err != nil {
+			q.error(err)
+		} else {
+			em.Emit(ctx, e)
+		}
+
+		// events pushed meanwhile may have been sorted before item
+		q.lock.Lock()
+		for i := range q.pending {
+			if q.pending[i].seq == item.seq {
+				heap.Remove(&q.pending, i)
+				break
+			}
+		}
+		q.lock.Unlock()
+
+		if err := os.Remove(item.path); err != nil {
+			q.error(err)
+		}
+	}
+}
+
+// read decodes a stored event.
+func (q *Queue) read(path string) (voyeur.Event, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rec queued
+	if err := json.Unmarshal(buf, &rec); err != nil {
+		return nil, fmt.Errorf("journal: reading %s: %w", path, err)
+	}
+
+	e, err := q.opts.Codec.Unmarshal(rec.Type, rec.Data)
+	if err != nil {
+		return nil, fmt.Errorf("journal: decoding queued event %d: %w", rec.Seq, err)
+	}
+
+	if rec.Envelope {
+		env := voyeur.Envelope{Event: e, ID: rec.ID, Key: rec.Key, Meta: rec.Meta, Priority: rec.Priority, Origin: rec.Origin, Parent: rec.Parent}
+		if rec.HLC != nil {
+			env.HLC = *rec.HLC
+		}
+		if rec.Vector != nil {
+			env.Vector = *rec.Vector
+		}
+		e = env
+	}
+
+	return e, nil
+}
+
+func (q *Queue) error(err error) {
+	if q.opts.OnError != nil {
+		q.opts.OnError(err)
+	}
+}
+
+// queueHeap orders the pending events by priority and sequence number.
+type queueHeap []queueItem
+
+func (h queueHeap) Len() int {
+	return len(h)
+}
+
+func (h queueHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+
+	return h[i].seq < h[j].seq
+}
+
+func (h queueHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *queueHeap) Push(x interface{}) {
+	*h = append(*h, x.(queueItem))
+}
+
+func (h *queueHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
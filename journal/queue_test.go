@@ -0,0 +1,84 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	opts := QueueOptions{
+		Codec:   JSONCodec{Types: testTypes()},
+		OnError: func(err error) { t.Error(err) },
+	}
+
+	q, err := OpenQueue(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q.Emit(ctx, note("backlog 1"))
+	q.Emit(ctx, note("backlog 2"))
+	q.Emit(ctx, voyeur.Envelope{Event: note("urgent"), Priority: voyeur.High, Key: "k"})
+	q.Emit(ctx, voyeur.Envelope{Event: note("whenever"), Priority: voyeur.Low})
+
+	// restart, delivering the first event only
+	ctx1, cancel := context.WithCancel(ctx)
+	em, o := voyeur.Pair()
+	var got []voyeur.Event
+	o.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		got = append(got, e)
+		cancel()
+	}))
+	q, err = OpenQueue(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := q.Len(); n != 4 {
+		t.Fatalf("expected 4 pending events, got %d", n)
+	}
+	q.Run(ctx1, em)
+
+	q, err = OpenQueue(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Emit(ctx, note("new"))
+	q.End(ctx)
+
+	em, o = voyeur.Pair()
+	o.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		got = append(got, e)
+	}))
+	if err := q.Run(ctx, em); err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := "[{urgent map[]  0.0@ { map[]} []  k high} backlog 1 backlog 2 new {whenever map[]  0.0@ { map[]} []   low} End]"; fmt.Sprint(got) != exp {
+		t.Errorf("expected %s, got %v", exp, got)
+	}
+	if n := q.Len(); n != 0 {
+		t.Errorf("expected empty queue, got %d events", n)
+	}
+}
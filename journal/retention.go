@@ -0,0 +1,313 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+// Retention limits how much history a journal keeps. History is dropped a segment at a time, oldest first;
+// the segment currently being appended to is never dropped.
+type Retention struct {
+	// MaxAge drops segments whose newest event is older than this. Zero means no limit.
+	MaxAge time.Duration
+
+	// MaxBytes drops segments while the journal is larger than this. Zero means no limit.
+	MaxBytes int64
+
+	// Interval is the time between background prunes. If zero, only explicit calls to Prune drop history.
+	Interval time.Duration
+
+	// Types sets the retention of single streams, by event type. Their events are dropped individually,
+	// which rewrites the segments, in addition to the segments dropped because of MaxAge and MaxBytes.
+	Types map[string]TypeRetention
+}
+
+// TypeRetention limits how much history of a single event type a journal keeps. Events are dropped oldest first;
+// those in the segment currently being appended to are never dropped.
+type TypeRetention struct {
+	// MaxAge drops events older than this. Zero means no limit.
+	MaxAge time.Duration
+
+	// MaxBytes drops events while those of the type take more than this. Zero means no limit.
+	MaxBytes int64
+}
+
+// SegmentDropped is emitted on the Drops Observable when a segment is dropped because of the Retention.
+type SegmentDropped struct {
+	// First and Last are the sequence numbers of the first and last dropped event.
+	First, Last uint64
+
+	// Reason is "age" or "size".
+	Reason string
+}
+
+func (SegmentDropped) EventType() string {
+	return "journal.segment-dropped"
+}
+
+// EventsDropped is emitted on the Drops Observable when events are dropped because of the retention of their type.
+type EventsDropped struct {
+	// Type is the event type.
+	Type string
+
+	// Count is the number of dropped events.
+	Count int
+
+	// Reason is "age" or "size".
+	Reason string
+}
+
+func (EventsDropped) EventType() string {
+	return "journal.events-dropped"
+}
+
+// Drops returns an Observable emitting a SegmentDropped event for every dropped segment, and an EventsDropped
+// event for the events dropped because of the retention of their type.
+func (j *Journal) Drops() voyeur.Observable {
+	return j.dropsO
+}
+
+func (j *Journal) prune() {
+	t := j.opts.Clock.NewTicker(j.opts.Retention.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C():
+			j.Prune(context.Background())
+		case <-j.closed:
+			return
+		}
+	}
+}
+
+// Prune drops the segments and events exceeding the Retention and returns how many segments were dropped.
+func (j *Journal) Prune(ctx context.Context) (int, error) {
+	var (
+		drops      []SegmentDropped
+		eventDrops []EventsDropped
+	)
+	defer func() {
+		for _, d := range drops {
+			j.dropsEm.Emit(ctx, d)
+		}
+		for _, d := range eventDrops {
+			j.dropsEm.Emit(ctx, d)
+		}
+	}()
+
+	ret := j.opts.Retention
+	if err := j.pruneSegments(ret, &drops); err != nil {
+		return len(drops), err
+	}
+
+	if len(ret.Types) > 0 {
+		var err error
+		eventDrops, err = j.pruneTypes(ret.Types)
+		if err != nil {
+			return len(drops), err
+		}
+	}
+
+	return len(drops), nil
+}
+
+// pruneSegments drops the oldest segments while they exceed ret, adding them to drops.
+func (j *Journal) pruneSegments(ret Retention, drops *[]SegmentDropped) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	cutoff := j.opts.Clock.Now().Add(-ret.MaxAge)
+
+	var sizes []int64
+	var total int64
+	for _, seg := range j.segments {
+		fi, err := os.Stat(seg.path)
+		if err != nil {
+			return err
+		}
+		sizes = append(sizes, fi.Size())
+		total += fi.Size()
+	}
+
+	for len(j.segments) > 1 {
+		seg := j.segments[0]
+		last := j.segments[1].first - 1
+
+		reason := ""
+		if ret.MaxBytes > 0 && total > ret.MaxBytes {
+			reason = "size"
+		} else if ret.MaxAge > 0 {
+			var newest time.Time
+			err := readSegment(seg.path, func(rec Record) error {
+				newest = rec.Time
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if newest.Before(cutoff) {
+				reason = "age"
+			}
+		}
+
+		if reason == "" {
+			break
+		}
+
+		if err := os.Remove(seg.path); err != nil {
+			return err
+		}
+
+		total -= sizes[0]
+		sizes = sizes[1:]
+		j.segments = j.segments[1:]
+		*drops = append(*drops, SegmentDropped{First: seg.first, Last: last, Reason: reason})
+	}
+
+	return nil
+}
+
+// pruneTypes drops the events exceeding the retention of their type from the closed segments. The journal is
+// only read-locked while looking for them, so appending goes on meanwhile.
+func (j *Journal) pruneTypes(types map[string]TypeRetention) ([]EventsDropped, error) {
+	dropped, err := j.findTypeDrops(types)
+	if err != nil || len(dropped) == 0 {
+		return nil, err
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	// rewrite the segments without the dropped events, like Compact does. Segments may have been dropped
+	// meanwhile, so only the events that are actually removed are counted.
+	counts := make(map[EventsDropped]int)
+	kept := j.segments[:0:0]
+	for _, seg := range j.segments[:len(j.segments)-1] {
+		var recs []Record
+		changed := false
+		err := readSegment(seg.path, func(rec Record) error {
+			if reason, ok := dropped[rec.Seq]; ok {
+				counts[EventsDropped{Type: rec.Type, Reason: reason}]++
+				changed = true
+				return nil
+			}
+
+			recs = append(recs, rec)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case len(recs) == 0:
+			if err := os.Remove(seg.path); err != nil {
+				return nil, err
+			}
+			continue
+		case changed:
+			if err := writeSegment(seg.path, recs); err != nil {
+				return nil, err
+			}
+		}
+		kept = append(kept, seg)
+	}
+	j.segments = append(kept, j.segments[len(j.segments)-1])
+
+	drops := make([]EventsDropped, 0, len(counts))
+	for d, n := range counts {
+		d.Count = n
+		drops = append(drops, d)
+	}
+	sort.Slice(drops, func(a, b int) bool {
+		if drops[a].Type != drops[b].Type {
+			return drops[a].Type < drops[b].Type
+		}
+		return drops[a].Reason < drops[b].Reason
+	})
+
+	return drops, nil
+}
+
+// findTypeDrops returns the events in closed segments exceeding the retention of their type, with the reason.
+func (j *Journal) findTypeDrops(types map[string]TypeRetention) (map[uint64]string, error) {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	now := j.opts.Clock.Now()
+	active := j.segments[len(j.segments)-1].first
+
+	type record struct {
+		seq  uint64
+		time time.Time
+		size int64
+	}
+
+	// find the events of the types with a retention and how much space they take, oldest first
+	byType := make(map[string][]record)
+	totals := make(map[string]int64)
+	err := j.records(0, func(rec Record) error {
+		if _, ok := types[rec.Type]; !ok {
+			return nil
+		}
+
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+
+		size := int64(len(line)) + 1
+		totals[rec.Type] += size
+		if rec.Seq < active {
+			byType[rec.Type] = append(byType[rec.Type], record{seq: rec.Seq, time: rec.Time, size: size})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dropped := make(map[uint64]string)
+	for typ, recs := range byType {
+		ret, total := types[typ], totals[typ]
+
+		for _, rec := range recs {
+			switch {
+			case ret.MaxBytes > 0 && total > ret.MaxBytes:
+				dropped[rec.seq] = "size"
+			case ret.MaxAge > 0 && rec.time.Before(now.Add(-ret.MaxAge)):
+				dropped[rec.seq] = "age"
+			default:
+				continue
+			}
+
+			total -= rec.size
+		}
+	}
+
+	return dropped, nil
+}
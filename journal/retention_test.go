@@ -0,0 +1,193 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	clock := &stepClock{now: time.Unix(0, 0)}
+
+	j, err := Open(t.TempDir(), Options{
+		Codec:       JSONCodec{Types: testTypes()},
+		SegmentSize: 100,
+		Clock:       clock,
+		Retention:   Retention{MaxAge: 5 * time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	var drops []voyeur.Event
+	j.Drops().Register(ctx, collect(&drops))
+
+	for i := 0; i < 10; i++ {
+		j.Append(ctx, note(fmt.Sprint(i)))
+	}
+
+	n, err := j.Prune(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n == 0 || n != len(drops) {
+		t.Fatalf("expected drops to be reported, dropped %d, got %v", n, drops)
+	}
+
+	if d := drops[0].(SegmentDropped); d.First != 1 || d.Reason != "age" {
+		t.Errorf("unexpected first drop %+v", d)
+	}
+
+	var got []voyeur.Event
+	j.Replay(ctx, 0, collect(&got))
+	if len(got) == 0 || len(got) == 10 || got[len(got)-1] != note("9") {
+		t.Errorf("unexpected events after pruning %v", got)
+	}
+}
+
+func TestPruneSize(t *testing.T) {
+	ctx := context.Background()
+
+	j, err := Open(t.TempDir(), Options{
+		Codec:       JSONCodec{Types: testTypes()},
+		SegmentSize: 100,
+		Retention:   Retention{MaxBytes: 200},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	for i := 0; i < 10; i++ {
+		j.Append(ctx, note(fmt.Sprint(i)))
+	}
+
+	if _, err := j.Prune(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []voyeur.Event
+	j.Replay(ctx, 0, collect(&got))
+	if len(got) > 3 || got[len(got)-1] != note("9") {
+		t.Errorf("unexpected events after pruning %v", got)
+	}
+}
+
+func TestPruneTypes(t *testing.T) {
+	ctx := context.Background()
+	clock := &stepClock{now: time.Unix(0, 0)}
+
+	types := testTypes()
+	types.Register(alarm(""))
+	j, err := Open(t.TempDir(), Options{
+		Codec:       JSONCodec{Types: types},
+		SegmentSize: 200,
+		Clock:       clock,
+		Retention:   Retention{Types: map[string]TypeRetention{"note": {MaxAge: 5 * time.Second}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	var drops []voyeur.Event
+	j.Drops().Register(ctx, collect(&drops))
+
+	for i := 0; i < 10; i++ {
+		j.Append(ctx, note(fmt.Sprint(i)))
+		j.Append(ctx, alarm(fmt.Sprint(i)))
+	}
+
+	if _, err := j.Prune(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(drops) != 1 {
+		t.Fatalf("expected one drop event, got %v", drops)
+	}
+	d := drops[0].(EventsDropped)
+	if d.Type != "note" || d.Reason != "age" || d.Count == 0 {
+		t.Errorf("unexpected drop %+v", d)
+	}
+
+	var notes, alarms int
+	j.Replay(ctx, 0, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		switch e.(type) {
+		case note:
+			notes++
+		case alarm:
+			alarms++
+		}
+	}))
+
+	if alarms != 10 || notes != 10-d.Count {
+		t.Errorf("expected only old notes to be dropped, got %d notes and %d alarms", notes, alarms)
+	}
+
+	// the journal is still readable and appendable after rewriting the segments
+	if seq, err := j.Append(ctx, note("10")); err != nil || seq != 21 {
+		t.Errorf("expected sequence number 21, got %d, %v", seq, err)
+	}
+}
+
+func TestPruneTypesSize(t *testing.T) {
+	ctx := context.Background()
+
+	types := testTypes()
+	types.Register(alarm(""))
+	j, err := Open(t.TempDir(), Options{
+		Codec:       JSONCodec{Types: types},
+		SegmentSize: 200,
+		Retention:   Retention{Types: map[string]TypeRetention{"alarm": {MaxBytes: 300}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	for i := 0; i < 10; i++ {
+		j.Append(ctx, note(fmt.Sprint(i)))
+		j.Append(ctx, alarm(fmt.Sprint(i)))
+	}
+
+	if _, err := j.Prune(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []voyeur.Event
+	j.Replay(ctx, 0, collect(&got))
+
+	var alarms []voyeur.Event
+	for _, e := range got {
+		if _, ok := e.(alarm); ok {
+			alarms = append(alarms, e)
+		}
+	}
+
+	if len(got)-len(alarms) != 10 || len(alarms) == 0 || len(alarms) == 10 || alarms[len(alarms)-1] != alarm("9") {
+		t.Errorf("expected only the oldest alarms to be dropped, got %v", got)
+	}
+}
@@ -0,0 +1,253 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+func (j *Journal) offsetPath(name string) string {
+	return filepath.Join(j.dir, "offsets", name)
+}
+
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// Offset returns the sequence number of the last event acknowledged by the named consumer, or 0 if there is none.
+func (j *Journal) Offset(name string) (uint64, error) {
+	if !validName(name) {
+		return 0, fmt.Errorf("journal: invalid consumer name %q", name)
+	}
+
+	data, err := os.ReadFile(j.offsetPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// commit persists the offset of the named consumer.
+func (j *Journal) commit(name string, seq uint64) error {
+	path := j.offsetPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(seq, 10)+"\n"), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// CommitMode says when the offset of a durable consumer is persisted.
+type CommitMode int
+
+const (
+	// CommitAfterDelivery commits each event once OnEvent returned. This is the default.
+	CommitAfterDelivery CommitMode = iota
+
+	// CommitPeriodically commits the last delivered event at a fixed interval and when the subscription ends.
+	// Fewer writes, but after a crash the events since the last commit are delivered again.
+	CommitPeriodically
+
+	// CommitOnAck commits an event when the consumer calls voyeur.Ack with the context it received the event with.
+	// This allows acknowledging events after processing them asynchronously. Nacked events are not committed,
+	// and neither are events whose commit failed, so they are delivered again after a restart, unless a later
+	// event was committed.
+	CommitOnAck
+
+	// CommitManually only commits when Subscription.Commit is called.
+	CommitManually
+)
+
+// SubscribeOption configures Subscribe.
+type SubscribeOption func(*subscribeConfig)
+
+type subscribeConfig struct {
+	mode     CommitMode
+	interval time.Duration
+}
+
+// WithCommitMode sets the CommitMode. interval is only used by CommitPeriodically and defaults to 5 seconds.
+func WithCommitMode(mode CommitMode, interval time.Duration) SubscribeOption {
+	return func(cfg *subscribeConfig) {
+		cfg.mode = mode
+		if interval > 0 {
+			cfg.interval = interval
+		}
+	}
+}
+
+// Subscription is a durable consumer registered using Subscribe.
+type Subscription struct {
+	j    *Journal
+	name string
+
+	lock      sync.Mutex
+	delivered uint64
+	committed uint64
+}
+
+// SubscriptionStats describe the progress of a Subscription.
+type SubscriptionStats struct {
+	// Delivered is the sequence number of the last event passed to the consumer.
+	Delivered uint64
+
+	// Committed is the persisted offset.
+	Committed uint64
+
+	// Lag is the number of events appended to the journal after the committed offset.
+	Lag uint64
+}
+
+// Stats returns the progress of the subscription.
+func (s *Subscription) Stats() SubscriptionStats {
+	last := s.j.Last()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := SubscriptionStats{Delivered: s.delivered, Committed: s.committed}
+	if last > s.committed {
+		stats.Lag = last - s.committed
+	}
+
+	return stats
+}
+
+// Commit persists the sequence number of the last delivered event as offset.
+func (s *Subscription) Commit() error {
+	s.lock.Lock()
+	seq := s.delivered
+	s.lock.Unlock()
+
+	return s.CommitSeq(seq)
+}
+
+// CommitSeq persists seq as offset. Offsets never move backwards, so committing an older seq does nothing.
+func (s *Subscription) CommitSeq(seq uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if seq <= s.committed {
+		return nil
+	}
+
+	if err := s.j.commit(s.name, seq); err != nil {
+		return err
+	}
+
+	s.committed = seq
+	return nil
+}
+
+// Subscribe registers oer as the named durable consumer. It first passes oer the events appended after the
+// consumer's offset and then the events appended from now on, without gaps or duplicates.
+// When the offset is persisted depends on the CommitMode. After a restart, subscribing with the same name
+// continues after the persisted offset.
+func (j *Journal) Subscribe(ctx context.Context, name string, oer voyeur.Observer, opts ...SubscribeOption) (*Subscription, error) {
+	cfg := subscribeConfig{interval: 5 * time.Second}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	offset, err := j.Offset(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Subscription{j: j, name: name, delivered: offset, committed: offset}
+
+	deliver := func(ctx context.Context, ent Entry) error {
+		if cfg.mode == CommitOnAck {
+			ctx = voyeur.WithAck(ctx, 1, func(err error) {
+				if err == nil {
+					s.CommitSeq(ent.Seq)
+				}
+			})
+		}
+
+		oer.OnEvent(ctx, ent.Event)
+
+		s.lock.Lock()
+		s.delivered = ent.Seq
+		s.lock.Unlock()
+
+		if cfg.mode == CommitAfterDelivery {
+			return s.CommitSeq(ent.Seq)
+		}
+		return nil
+	}
+
+	j.emitLock.Lock()
+	defer j.emitLock.Unlock()
+
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	err = j.entries(ctx, offset+1, func(ent Entry) error {
+		return deliver(ctx, ent)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	j.o.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		if ent, ok := e.(Entry); ok {
+			deliver(ctx, ent)
+		} else {
+			oer.OnEvent(ctx, e)
+		}
+	}))
+
+	if cfg.mode == CommitPeriodically {
+		go func() {
+			t := j.opts.Clock.NewTicker(cfg.interval)
+			defer t.Stop()
+
+			for {
+				select {
+				case <-t.C():
+					s.Commit()
+				case <-ctx.Done():
+					s.Commit()
+					return
+				}
+			}
+		}()
+	}
+
+	return s, nil
+}
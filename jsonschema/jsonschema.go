@@ -0,0 +1,318 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+Package jsonschema generates JSON Schemas (draft 2020-12) for event types and validates events against them.
+
+Schemas are derived from the Go types using reflection and follow the rules of encoding/json,
+including the json struct tags: renamed and skipped fields, omitempty fields are not required, and
+the ",string" option.
+*/
+package jsonschema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+// Schema is a JSON Schema. Only the keywords needed to describe Go types are supported.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Draft is the JSON Schema dialect of generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// For returns the schema of the Go type of e, titled with its EventType.
+func For(e voyeur.Event) *Schema {
+	s := Generate(reflect.TypeOf(e))
+	s.Schema = Draft
+	s.Title = e.EventType()
+	return s
+}
+
+// Generate returns the schema of values of type t when encoded using encoding/json.
+// Types implementing json.Marshaler and recursive types can't be described and get an empty schema, which allows anything.
+func Generate(t reflect.Type) *Schema {
+	return generate(t, make(map[reflect.Type]bool))
+}
+
+func generate(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		return &Schema{}
+	case visiting[t]:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		}
+
+		visiting[t] = true
+		defer delete(visiting, t)
+		return &Schema{Type: "array", Items: generate(t.Elem(), visiting)}
+	case reflect.Map:
+		visiting[t] = true
+		defer delete(visiting, t)
+		return &Schema{Type: "object", AdditionalProperties: generate(t.Elem(), visiting)}
+	case reflect.Struct:
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addFields(s, t, visiting)
+		sort.Strings(s.Required)
+		return s
+	default:
+		return &Schema{}
+	}
+}
+
+// addFields adds the fields of the struct type t to s, flattening embedded structs like encoding/json.
+func addFields(s *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			addFields(s, ft, visiting)
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := generate(f.Type, visiting)
+		if hasOpt(opts, "string") {
+			switch fs.Type {
+			case "integer", "number", "boolean":
+				fs = &Schema{Type: "string"}
+			}
+		}
+
+		s.Properties[name] = fs
+		if !hasOpt(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func hasOpt(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks that v, a value as decoded by encoding/json into an interface{}, matches s.
+func (s *Schema) Validate(v interface{}) error {
+	return s.validate("", v)
+}
+
+func (s *Schema) validate(path string, v interface{}) error {
+	fail := func(format string, args ...interface{}) error {
+		if path == "" {
+			path = "/"
+		}
+		return fmt.Errorf("jsonschema: %s: %s", path, fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fail("expected boolean, got %T", v)
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fail("expected string, got %T", v)
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return fail("expected number, got %T", v)
+		}
+	case "integer":
+		f, ok := v.(float64)
+		if !ok || f != math.Trunc(f) {
+			return fail("expected integer, got %v", v)
+		}
+	case "array":
+		if v == nil {
+			// encoding/json encodes nil slices as null
+			return nil
+		}
+
+		items, ok := v.([]interface{})
+		if !ok {
+			return fail("expected array, got %T", v)
+		}
+
+		for i, item := range items {
+			if err := s.Items.validate(fmt.Sprintf("%s/%d", path, i), item); err != nil {
+				return err
+			}
+		}
+	case "object":
+		if v == nil && s.Properties == nil {
+			// encoding/json encodes nil maps as null
+			return nil
+		}
+
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fail("expected object, got %T", v)
+		}
+
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fail("missing required property %q", name)
+			}
+		}
+
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			ps, ok := s.Properties[k]
+			if !ok {
+				ps = s.AdditionalProperties
+			}
+
+			if ps == nil {
+				continue
+			}
+
+			if err := ps.validate(path+"/"+k, obj[k]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateEvent checks that the JSON encoding of e matches s.
+func (s *Schema) ValidateEvent(e voyeur.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	return s.Validate(v)
+}
+
+// Filter returns a Filter that only passes on events that match the schema returned by schema for their EventType.
+// Events without schema and End are passed on. onInvalid, if not nil, is called for dropped events.
+func Filter(schema func(typ string) *Schema, onInvalid func(voyeur.Event, error)) voyeur.Filter {
+	return voyeur.Map(func(ctx context.Context, em voyeur.Emitter, e voyeur.Event) {
+		if e != voyeur.End {
+			if s := schema(e.EventType()); s != nil {
+				if err := s.ValidateEvent(e); err != nil {
+					if onInvalid != nil {
+						onInvalid(e, err)
+					}
+					return
+				}
+			}
+		}
+
+		em.Emit(ctx, e)
+	})
+}
+
+// Registered returns a function for Filter that returns the schema of the Go type registered for an EventType in types.
+// The schemas are generated once, when Registered is called.
+func Registered(types *voyeur.TypeRegistry) func(typ string) *Schema {
+	schemas := make(map[string]*Schema)
+	for _, typ := range types.Types() {
+		t, _ := types.Lookup(typ)
+		s := Generate(t)
+		s.Schema, s.Title = Draft, typ
+		schemas[typ] = s
+	}
+
+	return func(typ string) *Schema {
+		return schemas[typ]
+	}
+}
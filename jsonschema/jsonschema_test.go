@@ -0,0 +1,167 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package jsonschema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+type Meta struct {
+	Source string `json:"source"`
+}
+
+type userCreated struct {
+	Meta
+	Name     string            `json:"name"`
+	Email    string            `json:"email,omitempty"`
+	Age      int               `json:"age,string"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Created  time.Time         `json:"created"`
+	Manager  *userCreated      `json:"manager,omitempty"`
+	Internal string            `json:"-"`
+	secret   string
+}
+
+func (userCreated) EventType() string {
+	return "user.created"
+}
+
+func ExampleFor() {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(For(userCreated{}))
+
+	// Output:
+	// {
+	//   "$schema": "https://json-schema.org/draft/2020-12/schema",
+	//   "title": "user.created",
+	//   "type": "object",
+	//   "properties": {
+	//     "age": {
+	//       "type": "string"
+	//     },
+	//     "created": {
+	//       "type": "string",
+	//       "format": "date-time"
+	//     },
+	//     "email": {
+	//       "type": "string"
+	//     },
+	//     "labels": {
+	//       "type": "object",
+	//       "additionalProperties": {
+	//         "type": "string"
+	//       }
+	//     },
+	//     "manager": {},
+	//     "name": {
+	//       "type": "string"
+	//     },
+	//     "source": {
+	//       "type": "string"
+	//     },
+	//     "tags": {
+	//       "type": "array",
+	//       "items": {
+	//         "type": "string"
+	//       }
+	//     }
+	//   },
+	//   "required": [
+	//     "age",
+	//     "created",
+	//     "name",
+	//     "source",
+	//     "tags"
+	//   ]
+	// }
+}
+
+func TestValidate(t *testing.T) {
+	s := For(userCreated{})
+
+	for _, tc := range []struct {
+		doc string
+		ok  bool
+	}{
+		{`{"source":"x","name":"a","age":"3","tags":null,"created":"2020-01-01T00:00:00Z"}`, true},
+		{`{"source":"x","name":"a","age":"3","tags":["a"],"created":"2020-01-01T00:00:00Z","labels":{"a":"b"}}`, true},
+		{`{"source":"x","age":"3","tags":null,"created":"2020-01-01T00:00:00Z"}`, false},
+		{`{"source":"x","name":1,"age":"3","tags":null,"created":"2020-01-01T00:00:00Z"}`, false},
+		{`{"source":"x","name":"a","age":"3","tags":[1],"created":"2020-01-01T00:00:00Z"}`, false},
+		{`{"source":"x","name":"a","age":"3","tags":null,"created":"2020-01-01T00:00:00Z","labels":{"a":1}}`, false},
+	} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(tc.doc), &v); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.Validate(v); (err == nil) != tc.ok {
+			t.Errorf("%s: expected ok=%v, got %v", tc.doc, tc.ok, err)
+		}
+	}
+}
+
+type reading struct {
+	Value float64 `json:"value"`
+}
+
+func (reading) EventType() string {
+	return "reading"
+}
+
+// looseReading has the same EventType as reading, but a different shape, like an event from an outdated producer.
+type looseReading struct {
+	Value string `json:"value"`
+}
+
+func (looseReading) EventType() string {
+	return "reading"
+}
+
+func TestFilter(t *testing.T) {
+	ctx := context.Background()
+	types := voyeur.NewTypeRegistry()
+	types.Register(reading{})
+
+	var invalid []voyeur.Event
+	f := Filter(Registered(types), func(e voyeur.Event, err error) {
+		invalid = append(invalid, e)
+	})
+
+	var got []voyeur.Event
+	f.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		got = append(got, e)
+	}))
+
+	f.OnEvent(ctx, reading{1.5})
+	f.OnEvent(ctx, looseReading{"high"})
+	f.OnEvent(ctx, voyeur.End)
+
+	if fmt.Sprint(got) != "[{1.5} End]" || fmt.Sprint(invalid) != "[{high}]" {
+		t.Errorf("got %v, invalid %v", got, invalid)
+	}
+}
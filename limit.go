@@ -0,0 +1,172 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LimitOptions configure Limit.
+type LimitOptions struct {
+	// Interval is the minimum time between two events passed to the observer. Zero means no limit.
+	Interval time.Duration
+
+	// Buffer is the number of events waiting for the observer. Further events are dropped. Defaults to 1.
+	Buffer int
+
+	// LatestOnly replaces the waiting events with the newest one, instead of dropping the newest one.
+	LatestOnly bool
+
+	// Key, if not nil, conflates waiting events by key: a new event replaces the waiting event with the same key,
+	// keeping its place in the queue. Then Buffer is the number of keys that can have an event waiting.
+	Key func(Event) string
+
+	// Clock defaults to SystemClock.
+	Clock Clock
+}
+
+type limiter struct {
+	oer  Observer
+	opts LimitOptions
+
+	lock  sync.Mutex
+	queue []delivery
+	end   *delivery
+	wake  chan struct{}
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Limit returns an Observer that passes events on to oer on its own goroutine, at most one per Interval.
+// Only a bounded number of events wait for oer; the rest is dropped, so a slow observer can't make memory grow.
+// End is never dropped and is passed on after the waiting events, which ends the goroutine.
+// The Observer is a Closer. Close it to end the goroutine if End might not come, e.g. when a connection drops.
+func Limit(oer Observer, opts LimitOptions) Observer {
+	if opts.Buffer < 1 {
+		opts.Buffer = 1
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+
+	l := &limiter{
+		oer:    oer,
+		opts:   opts,
+		wake:   make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+
+	go l.run()
+	return l
+}
+
+func (l *limiter) OnEvent(ctx context.Context, e Event) {
+	func() {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+
+		d := delivery{ctx: ctx, e: e}
+		switch {
+		case l.end != nil:
+		case e == End:
+			l.end = &d
+		case l.opts.LatestOnly:
+			l.queue = append(l.queue[:0], d)
+		case l.opts.Key != nil && l.replace(d):
+		case len(l.queue) < l.opts.Buffer:
+			l.queue = append(l.queue, d)
+		}
+	}()
+
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// replace replaces the waiting event with the key of d and reports whether there was one. l.lock must be held.
+func (l *limiter) replace(d delivery) bool {
+	key := l.opts.Key(d.e)
+	for i, waiting := range l.queue {
+		if l.opts.Key(waiting.e) == key {
+			l.queue[i] = d
+			return true
+		}
+	}
+
+	return false
+}
+
+// next returns the next event to pass on, if there is one.
+func (l *limiter) next() (delivery, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if len(l.queue) > 0 {
+		d := l.queue[0]
+		l.queue = l.queue[1:]
+		return d, true
+	}
+
+	if l.end != nil {
+		return *l.end, true
+	}
+
+	return delivery{}, false
+}
+
+func (l *limiter) run() {
+	for {
+		select {
+		case <-l.wake:
+		case <-l.closed:
+			return
+		}
+
+		for {
+			d, ok := l.next()
+			if !ok {
+				break
+			}
+
+			l.oer.OnEvent(d.ctx, d.e)
+			if d.e == End {
+				return
+			}
+
+			if l.opts.Interval > 0 {
+				t := l.opts.Clock.NewTimer(l.opts.Interval)
+				select {
+				case <-t.C():
+				case <-l.closed:
+					t.Stop()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close ends the goroutine. Waiting events are dropped, and so are events passed on afterwards.
+func (l *limiter) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return nil
+}
@@ -0,0 +1,145 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLimitLatestOnly(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+
+	got := make(chan Event, 10)
+	l := Limit(ObserverFunc(func(ctx context.Context, e Event) {
+		got <- e
+	}), LimitOptions{Interval: time.Second, LatestOnly: true, Clock: clock})
+
+	l.OnEvent(ctx, intEvent(1))
+	if e := <-got; e != intEvent(1) {
+		t.Fatalf("expected 1, got %v", e)
+	}
+
+	// the limiter is waiting for the interval to pass, so these are conflated
+	l.OnEvent(ctx, intEvent(2))
+	l.OnEvent(ctx, intEvent(3))
+	l.OnEvent(ctx, End)
+
+	for _, exp := range []Event{intEvent(3), End} {
+		for waiting := true; waiting; {
+			clock.Advance(time.Second)
+			select {
+			case e := <-got:
+				if e != exp {
+					t.Fatalf("expected %v, got %v", exp, e)
+				}
+				waiting = false
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+}
+
+func TestLimitDropsWhenFull(t *testing.T) {
+	ctx := context.Background()
+
+	block := make(chan struct{})
+	got := make(chan Event, 10)
+	l := Limit(ObserverFunc(func(ctx context.Context, e Event) {
+		<-block
+		got <- e
+	}), LimitOptions{Buffer: 2})
+
+	l.OnEvent(ctx, intEvent(1))
+	time.Sleep(10 * time.Millisecond) // let the limiter pick up the first event
+	for i := 2; i <= 5; i++ {
+		l.OnEvent(ctx, intEvent(i))
+	}
+	l.OnEvent(ctx, End)
+	close(block)
+
+	for _, exp := range []Event{intEvent(1), intEvent(2), intEvent(3), End} {
+		select {
+		case e := <-got:
+			if e != exp {
+				t.Fatalf("expected %v, got %v", exp, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %v", exp)
+		}
+	}
+}
+
+func TestLimitConflatesByKey(t *testing.T) {
+	ctx := context.Background()
+
+	started, block := make(chan struct{}), make(chan struct{})
+	got := make(chan Event, 10)
+	l := Limit(ObserverFunc(func(ctx context.Context, e Event) {
+		if e == stringEvent("x") {
+			close(started)
+			<-block
+		}
+		got <- e
+	}), LimitOptions{Buffer: 2, Key: func(e Event) string { return string(e.(stringEvent))[:1] }})
+
+	l.OnEvent(ctx, stringEvent("x"))
+	<-started
+
+	// while the observer is busy, only the newest event per key is kept
+	for _, s := range []string{"a1", "b1", "a2", "b2", "a3", "c1"} {
+		l.OnEvent(ctx, stringEvent(s))
+	}
+	l.OnEvent(ctx, End)
+	close(block)
+
+	for _, exp := range []Event{stringEvent("x"), stringEvent("a3"), stringEvent("b2"), End} {
+		select {
+		case e := <-got:
+			if e != exp {
+				t.Fatalf("expected %v, got %v", exp, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %v", exp)
+		}
+	}
+}
+
+func TestLimitClose(t *testing.T) {
+	ctx := context.Background()
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 10; i++ {
+		l := Limit(ObserverFunc(func(ctx context.Context, e Event) {}), LimitOptions{Interval: time.Hour})
+		l.OnEvent(ctx, intEvent(i))
+		l.OnEvent(ctx, intEvent(i))
+
+		// End never comes
+		l.(Closer).Close()
+	}
+
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 100 {
+			t.Fatalf("goroutines leaked: %d before, %d after closing", before, runtime.NumGoroutine())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
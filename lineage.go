@@ -0,0 +1,140 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+)
+
+type parentKey struct{}
+
+// WithParent returns a context that makes streams created WithLineage record id as the parent of the events
+// emitted with it. Streams created WithLineage set it on the contexts they pass to observers.
+func WithParent(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, parentKey{}, id)
+}
+
+// ParentFrom returns the parent id set on ctx using WithParent.
+func ParentFrom(ctx context.Context) string {
+	id, _ := ctx.Value(parentKey{}).(string)
+	return id
+}
+
+// adopt sets the parent of env from ctx, and returns a context for delivering env that makes it the parent
+// of the events emitted while handling it. Envelopes that already have a parent keep it.
+func adopt(ctx context.Context, env Envelope) (Envelope, context.Context) {
+	if parent := ParentFrom(ctx); env.Parent == "" && parent != env.ID {
+		env.Parent = parent
+	}
+	if env.ID != "" {
+		ctx = WithParent(ctx, env.ID)
+	}
+
+	return env, ctx
+}
+
+// LineageNode is an event in a derivation tree, see Lineage.Tree.
+type LineageNode struct {
+	Event    Envelope
+	Children []*LineageNode
+}
+
+// Lineage is an Observer that keeps the envelopes with ids that it observes, to reconstruct where events were
+// derived from. Register it on all streams of interest, created WithLineage and WithIDs. It keeps all events,
+// so use it for debugging.
+type Lineage struct {
+	lock     sync.Mutex
+	events   map[string]Envelope
+	children map[string][]string
+}
+
+// NewLineage returns an empty Lineage.
+func NewLineage() *Lineage {
+	return &Lineage{
+		events:   make(map[string]Envelope),
+		children: make(map[string][]string),
+	}
+}
+
+// OnEvent keeps e if it is an Envelope with an id. Events observed on several streams are kept once.
+func (l *Lineage) OnEvent(ctx context.Context, e Event) {
+	env, ok := e.(Envelope)
+	if !ok || env.ID == "" {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, ok := l.events[env.ID]; ok {
+		return
+	}
+
+	l.events[env.ID] = env
+	if env.Parent != "" {
+		l.children[env.Parent] = append(l.children[env.Parent], env.ID)
+	}
+}
+
+// Ancestors returns the event with the given id and the events it was derived from, oldest first.
+// The chain ends at an event without a parent, or one that wasn't observed.
+func (l *Lineage) Ancestors(id string) []Envelope {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var chain []Envelope
+	for seen := make(map[string]bool); !seen[id]; {
+		env, ok := l.events[id]
+		if !ok {
+			break
+		}
+
+		seen[id] = true
+		chain = append([]Envelope{env}, chain...)
+		id = env.Parent
+	}
+
+	return chain
+}
+
+// Tree returns the derivation tree that the event with the given id is part of: the oldest of its ancestors,
+// and all events derived from it, in the order they were observed. It returns nil if the event wasn't observed.
+func (l *Lineage) Tree(id string) *LineageNode {
+	ancestors := l.Ancestors(id)
+	if len(ancestors) == 0 {
+		return nil
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.tree(ancestors[0].ID, make(map[string]bool))
+}
+
+func (l *Lineage) tree(id string, seen map[string]bool) *LineageNode {
+	seen[id] = true
+	node := &LineageNode{Event: l.events[id]}
+	for _, child := range l.children[id] {
+		if !seen[child] {
+			node.Children = append(node.Children, l.tree(child, seen))
+		}
+	}
+
+	return node
+}
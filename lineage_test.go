@@ -0,0 +1,73 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func ExampleLineage() {
+	ctx := context.Background()
+
+	var n int
+	ids := IDGeneratorFunc(func() string {
+		n++
+		return fmt.Sprint("e", n)
+	})
+
+	em, o := Pair(WithIDs(ids), WithLineage())
+
+	// split orders into their items
+	items := Map(func(ctx context.Context, em Emitter, e Event) {
+		if e == End {
+			return
+		}
+		for _, item := range strings.Fields(e.(Envelope).Event.(stringEvent).String()) {
+			em.Emit(ctx, stringEvent(item))
+		}
+	}, WithIDs(ids), WithLineage())
+	o.Register(ctx, items)
+
+	l := NewLineage()
+	o.Register(ctx, l)
+	items.Register(ctx, l)
+
+	em.Emit(ctx, stringEvent("apple pear"))
+
+	for _, env := range l.Ancestors("e3") {
+		fmt.Println("ancestor:", env.ID, env.Event)
+	}
+
+	var print func(*LineageNode, string)
+	print = func(node *LineageNode, indent string) {
+		fmt.Println(indent+node.Event.ID, node.Event.Event)
+		for _, child := range node.Children {
+			print(child, indent+"  ")
+		}
+	}
+	print(l.Tree("e3"), "")
+
+	// Output:
+	// ancestor: e1 apple pear
+	// ancestor: e3 pear
+	// e1 apple pear
+	//   e2 apple
+	//   e3 pear
+}
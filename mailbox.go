@@ -0,0 +1,98 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"sync"
+)
+
+// defaultMailbox is the number of events queued for each observer of an async stream, unless set WithBuffer.
+const defaultMailbox = 64
+
+// mailbox queues the events for an observer of an async stream.
+type mailbox struct {
+	lock sync.Mutex
+	cond *sync.Cond
+
+	max    int
+	queue  []delivery
+	closed bool
+}
+
+// mailboxSize returns the size of the mailboxes of an async stream with the given buffer option.
+func mailboxSize(buffer int) int {
+	if buffer < 1 {
+		return defaultMailbox
+	}
+
+	return buffer
+}
+
+func newMailbox(max int) *mailbox {
+	mb := &mailbox{max: max}
+	mb.cond = sync.NewCond(&mb.lock)
+
+	return mb
+}
+
+// push queues d, blocking while the mailbox is full. It returns false if the mailbox was closed.
+func (mb *mailbox) push(d delivery) bool {
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+
+	for !mb.closed && len(mb.queue) >= mb.max {
+		mb.cond.Wait()
+	}
+	if mb.closed {
+		return false
+	}
+
+	mb.queue = append(mb.queue, d)
+	mb.cond.Broadcast()
+	return true
+}
+
+// pop returns the next event, waiting for one if necessary. Once the mailbox is closed, the queued
+// events are still returned, and then false.
+func (mb *mailbox) pop() (delivery, bool) {
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+
+	for len(mb.queue) == 0 && !mb.closed {
+		mb.cond.Wait()
+	}
+	if len(mb.queue) == 0 {
+		return delivery{}, false
+	}
+
+	d := mb.queue[0]
+	mb.queue[0] = delivery{}
+	mb.queue = mb.queue[1:]
+	mb.cond.Broadcast()
+
+	return d, true
+}
+
+// close makes push fail and pop return false once the mailbox is empty.
+func (mb *mailbox) close() {
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+
+	mb.closed = true
+	mb.cond.Broadcast()
+}
@@ -0,0 +1,91 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"container/heap"
+)
+
+// MergeRecorded interleaves several recordings, each ordered by time, into one recording ordered by time,
+// e.g. to replay the journals of several services as one stream using a Player. Events recorded at the
+// same time keep the order of the recordings they come from.
+func MergeRecorded(recordings ...[]Recorded) []Recorded {
+	var (
+		n int
+		h = make(recordedHeap, 0, len(recordings))
+	)
+	for i, rec := range recordings {
+		n += len(rec)
+		if len(rec) > 0 {
+			h = append(h, recordedCursor{source: i, events: rec})
+		}
+	}
+	heap.Init(&h)
+
+	merged := make([]Recorded, 0, n)
+	for len(h) > 0 {
+		cur := &h[0]
+		merged = append(merged, cur.events[0])
+
+		cur.events = cur.events[1:]
+		if len(cur.events) == 0 {
+			heap.Pop(&h)
+		} else {
+			heap.Fix(&h, 0)
+		}
+	}
+
+	return merged
+}
+
+// recordedCursor is the rest of a recording that is being merged.
+type recordedCursor struct {
+	source int
+	events []Recorded
+}
+
+// recordedHeap is a heap of cursors ordered by the time of their next event.
+type recordedHeap []recordedCursor
+
+func (h recordedHeap) Len() int {
+	return len(h)
+}
+
+func (h recordedHeap) Less(i, j int) bool {
+	ti, tj := h[i].events[0].Time, h[j].events[0].Time
+	if ti.Equal(tj) {
+		return h[i].source < h[j].source
+	}
+
+	return ti.Before(tj)
+}
+
+func (h recordedHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *recordedHeap) Push(x interface{}) {
+	*h = append(*h, x.(recordedCursor))
+}
+
+func (h *recordedHeap) Pop() interface{} {
+	old := *h
+	cur := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return cur
+}
@@ -0,0 +1,47 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"time"
+)
+
+func ExampleMergeRecorded() {
+	ctx := context.Background()
+	at := func(sec int64, word string) Recorded {
+		return Recorded{Time: time.Unix(sec, 0), Event: stringEvent(word)}
+	}
+
+	orders := []Recorded{at(1, "order placed"), at(4, "order shipped")}
+	payments := []Recorded{at(2, "payment received"), at(4, "receipt sent")}
+	stock := []Recorded{at(3, "stock reserved")}
+
+	em, o := Pair()
+	o.Register(ctx, printObserver{})
+
+	p := NewPlayer(MergeRecorded(orders, payments, stock), 0)
+	p.Play(ctx, em)
+
+	// Output:
+	// order placed
+	// payment received
+	// stock reserved
+	// order shipped
+	// receipt sent
+}
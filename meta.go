@@ -0,0 +1,141 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+)
+
+// Meta is metadata that travels with events, e.g. a tenant or a trace id.
+type Meta map[string]string
+
+type metaKey struct{}
+
+// WithMeta returns a context carrying the metadata of ctx plus key set to value.
+func WithMeta(ctx context.Context, key, value string) context.Context {
+	prev := MetaFrom(ctx)
+	meta := make(Meta, len(prev)+1)
+	for k, v := range prev {
+		meta[k] = v
+	}
+	meta[key] = value
+
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+// MetaFrom returns the metadata set on ctx using WithMeta. It must not be modified.
+func MetaFrom(ctx context.Context) Meta {
+	meta, _ := ctx.Value(metaKey{}).(Meta)
+	return meta
+}
+
+// Envelope is an event together with metadata. Streams created with WithEnvelope wrap events in envelopes,
+// so the metadata stays with the event after the context it was emitted with is gone, e.g. when an observer
+// stores the event or hands it to another goroutine.
+type Envelope struct {
+	Event
+	Meta Meta
+
+	// ID is set by streams created WithIDs.
+	ID string
+
+	// HLC is set by streams created WithHLC.
+	HLC HLCTimestamp
+
+	// Vector is set by streams created WithVectorClock.
+	Vector VectorTimestamp
+
+	// Origin is the chain of bridges the event passed, see BridgeHop.
+	Origin []string
+
+	// Parent is the id of the event this one was derived from, set by streams created WithLineage.
+	Parent string
+
+	// Key and Priority are set by EventBuilder.
+	Key      string
+	Priority Priority
+}
+
+// Context returns ctx with the metadata of the envelope added.
+func (env Envelope) Context(ctx context.Context) context.Context {
+	for k, v := range env.Meta {
+		ctx = WithMeta(ctx, k, v)
+	}
+
+	return ctx
+}
+
+// seal wraps e in an Envelope with the metadata of ctx. If keys are given, only those are copied.
+// If ids is not nil, envelopes without an id get one. If hlc is not nil, envelopes are stamped using it.
+func seal(ctx context.Context, e Event, keys []string, ids IDGenerator, hlc *HLC) Event {
+	if e == End {
+		return e
+	}
+	if env, ok := e.(Envelope); ok {
+		if ids != nil && env.ID == "" {
+			env.ID = ids.NewID()
+		}
+		if hlc != nil {
+			env = hlc.stamp(env)
+		}
+		return env
+	}
+
+	all := MetaFrom(ctx)
+	meta := make(Meta, len(all))
+	if len(keys) == 0 {
+		for k, v := range all {
+			meta[k] = v
+		}
+	}
+	for _, k := range keys {
+		if v, ok := all[k]; ok {
+			meta[k] = v
+		}
+	}
+
+	env := Envelope{Event: e, Meta: meta}
+	if ids != nil {
+		env.ID = ids.NewID()
+	}
+	if hlc != nil {
+		env = hlc.stamp(env)
+	}
+
+	return env
+}
+
+// Wrap returns a Filter that wraps events in an Envelope with the metadata of the context they are emitted with,
+// like a stream created using WithEnvelope. If keys are given, only those are copied. Envelopes pass unchanged.
+func Wrap(keys ...string) Filter {
+	return Map(func(ctx context.Context, em Emitter, e Event) {
+		em.Emit(ctx, seal(ctx, e, keys, nil, nil))
+	})
+}
+
+// Unwrap returns a Filter that emits the events inside of Envelopes, with the metadata of the envelope
+// added to the context. Other events pass unchanged.
+func Unwrap() Filter {
+	return Map(func(ctx context.Context, em Emitter, e Event) {
+		if env, ok := e.(Envelope); ok {
+			ctx, e = env.Context(ctx), env.Event
+		}
+
+		em.Emit(ctx, e)
+	})
+}
@@ -0,0 +1,74 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+)
+
+func ExampleWithMeta() {
+	ctx := WithMeta(context.Background(), "tenant", "acme")
+	ctx = WithMeta(ctx, "trace", "t1")
+
+	fmt.Println(MetaFrom(ctx))
+
+	// Output:
+	// map[tenant:acme trace:t1]
+}
+
+func ExampleWithEnvelope() {
+	ctx := context.Background()
+	em, o := Pair(WithEnvelope("tenant"))
+
+	// the observer keeps events for later, when the emit context is gone
+	var kept []Event
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		kept = append(kept, e)
+	}))
+
+	emitCtx := WithMeta(WithMeta(ctx, "tenant", "acme"), "trace", "t1")
+	em.Emit(emitCtx, stringEvent("a"))
+
+	for _, e := range kept {
+		env := e.(Envelope)
+		fmt.Println(env.Event, MetaFrom(env.Context(ctx)))
+	}
+
+	// Output:
+	// a map[tenant:acme]
+}
+
+func ExampleUnwrap() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	// a segment producing envelopes followed by one expecting plain events
+	wrap, unwrap := Wrap(), Unwrap()
+	o.Register(ctx, wrap)
+	wrap.Register(ctx, unwrap)
+	unwrap.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		_, isEnv := e.(Envelope)
+		fmt.Println(e, isEnv, MetaFrom(ctx))
+	}))
+
+	em.Emit(WithMeta(ctx, "tenant", "acme"), stringEvent("a"))
+
+	// Output:
+	// a false map[tenant:acme]
+}
@@ -0,0 +1,43 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import "fmt"
+
+// Namer is implemented by streams that have a name, see WithName.
+type Namer interface {
+	Name() string
+}
+
+// NameOf returns a name for v to be used in diagnostics.
+// If v is a Namer with a non-empty name, that name is returned. Otherwise it falls back to the type of v.
+func NameOf(v interface{}) string {
+	if n, ok := v.(Namer); ok && n.Name() != "" {
+		return n.Name()
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+func nameOr(name, kind string, v interface{}) string {
+	if name != "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s(%p)", kind, v)
+}
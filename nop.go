@@ -0,0 +1,60 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+)
+
+// NopEmitter, NopObservable and NopObserver do nothing. Use them as defaults for optional eventing, so code
+// doesn't need to check whether a stream was configured.
+// The helpers of this package, like Pipe, Connect or ErrorsTo, treat a nil Emitter or Observable the same way.
+var (
+	NopEmitter    Emitter    = nopEmitter{}
+	NopObservable Observable = nopObservable{}
+	NopObserver   Observer   = nopObserver{}
+)
+
+type nopEmitter struct{}
+
+func (nopEmitter) Emit(context.Context, Event) {}
+func (nopEmitter) End(context.Context)         {}
+
+type nopObservable struct{}
+
+func (nopObservable) Register(context.Context, Observer) {}
+
+type nopObserver struct{}
+
+func (nopObserver) OnEvent(context.Context, Event) {}
+
+// emitterOrNop returns em, or NopEmitter if em is nil.
+func emitterOrNop(em Emitter) Emitter {
+	if em == nil {
+		return NopEmitter
+	}
+	return em
+}
+
+// observableOrNop returns o, or NopObservable if o is nil.
+func observableOrNop(o Observable) Observable {
+	if o == nil {
+		return NopObservable
+	}
+	return o
+}
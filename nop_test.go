@@ -0,0 +1,60 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"errors"
+)
+
+// worker is a library type with optional eventing.
+type worker struct {
+	Events Emitter
+}
+
+func newWorker(events Emitter) worker {
+	if events == nil {
+		events = NopEmitter
+	}
+	return worker{Events: events}
+}
+
+func (w worker) run(ctx context.Context) {
+	ErrorsTo(ctx, w.Events)(errors.New("disk full"))
+	w.Events.Emit(ctx, stringEvent("done"))
+}
+
+func ExampleNopEmitter() {
+	ctx := context.Background()
+
+	// no events configured
+	newWorker(nil).run(ctx)
+
+	em, o := Pair()
+	o.Register(ctx, printObserver{})
+	newWorker(em).run(ctx)
+
+	// nil streams are fine for the helpers, too
+	Pipe(ctx, nil, em)
+	Pipe(ctx, o, nil)
+	<-WaitAll(ctx, nil)
+
+	// Output:
+	// disk full
+	// done
+}
@@ -0,0 +1,250 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Tap returns a Filter that calls fn for each event and then passes the event on unchanged.
+// Use it for side effects like logging, where Map would allow altering the stream.
+func Tap(fn func(context.Context, Event), opts ...Option) Filter {
+	return Map(func(ctx context.Context, em Emitter, e Event) {
+		fn(ctx, e)
+		em.Emit(ctx, e)
+	}, opts...)
+}
+
+// Where returns a Filter that only passes on the events for which pred returns true. End is passed on.
+func Where(pred func(Event) bool, opts ...Option) Filter {
+	return Map(func(ctx context.Context, em Emitter, e Event) {
+		if e != End && pred(e) {
+			em.Emit(ctx, e)
+		}
+	}, opts...)
+}
+
+// MapEvent returns a Filter that passes on fn(e) for each event e. End is passed on.
+func MapEvent(fn func(Event) Event, opts ...Option) Filter {
+	return Map(func(ctx context.Context, em Emitter, e Event) {
+		if e != End {
+			em.Emit(ctx, fn(e))
+		}
+	}, opts...)
+}
+
+// Reduce returns a Filter that folds the observed events into a state using fn, starting with init, and emits
+// the final state as a Snapshot before End. Use Aggregate to get the state after each event.
+func Reduce[S any](init S, fn func(S, Event) S, opts ...Option) Filter {
+	var (
+		lock  sync.Mutex
+		state = init
+	)
+
+	f := Map(func(ctx context.Context, em Emitter, e Event) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if e == End {
+			em.Emit(ctx, Snapshot[S]{State: state})
+			return
+		}
+
+		state = fn(state, e)
+	}, opts...)
+
+	mapFilterOf(f).waits = true
+	return f
+}
+
+// Distinct returns a Filter that drops events equal to the event passed on before them, according to equal.
+// If equal is nil, events are compared using reflect.DeepEqual. End is passed on.
+func Distinct(equal func(a, b Event) bool, opts ...Option) Filter {
+	if equal == nil {
+		equal = func(a, b Event) bool {
+			return reflect.DeepEqual(a, b)
+		}
+	}
+
+	var (
+		lock sync.Mutex
+		last Event
+	)
+
+	return Map(func(ctx context.Context, em Emitter, e Event) {
+		if e == End {
+			return
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		if last != nil && equal(last, e) {
+			return
+		}
+
+		last = e
+		em.Emit(ctx, e)
+	}, opts...)
+}
+
+// Take returns a Filter that passes on the first n events and then ends. If n is not positive, it ends on the
+// first event.
+func Take(n int, opts ...Option) Filter {
+	var (
+		lock  sync.Mutex
+		taken int
+		done  bool
+	)
+
+	f := Map(func(ctx context.Context, em Emitter, e Event) {
+		if e == End {
+			return
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		if done {
+			return
+		}
+
+		if taken < n {
+			taken++
+			em.Emit(ctx, e)
+		}
+		if taken >= n {
+			done = true
+			em.End(ctx)
+		}
+	}, opts...)
+
+	mapFilterOf(f).ends = true
+	return f
+}
+
+// Skip returns a Filter that drops the first n events and passes on the rest. End is passed on.
+func Skip(n int, opts ...Option) Filter {
+	var skipped atomic.Int64
+
+	return Map(func(ctx context.Context, em Emitter, e Event) {
+		if e != End && skipped.Add(1) > int64(n) {
+			em.Emit(ctx, e)
+		}
+	}, opts...)
+}
+
+// Chain returns a Filter that passes events through the filters, in order: each filter is registered on the one
+// before it, and the chain emits what the last one emits. nil filters are skipped, and a chain without filters
+// passes events on unchanged. The chain is a Pipeline without a source, which is fed using OnEvent.
+func Chain(filters ...Filter) Filter {
+	var stages []Filter
+	for _, f := range filters {
+		if f != nil {
+			stages = append(stages, f)
+		}
+	}
+
+	return NewPipeline(context.Background(), NopObservable, stages...)
+}
+
+type branch struct {
+	pred      func(Event) bool
+	then, els Filter
+	em        Emitter
+	o         *observable
+
+	lock sync.Mutex
+	// left is the number of branches that haven't ended yet
+	left int
+}
+
+// Branch returns a Filter that passes events for which pred returns true to then, and the others to els,
+// and emits the events of both. A nil branch drops its events. End is passed to both branches and emitted
+// once both ended.
+func Branch(pred func(Event) bool, then, els Filter, opts ...Option) Filter {
+	em, o := Pair(opts...)
+	b := &branch{
+		pred: pred,
+		then: then,
+		els:  els,
+		em:   em,
+		o:    o.(*observable),
+		left: 2,
+	}
+
+	for _, f := range []Filter{then, els} {
+		if f != nil {
+			f.Register(context.Background(), ObserverFunc(b.merge))
+		}
+	}
+
+	return b
+}
+
+func (b *branch) merge(ctx context.Context, e Event) {
+	if e != End {
+		b.em.Emit(ctx, e)
+		return
+	}
+
+	b.lock.Lock()
+	b.left--
+	last := b.left == 0
+	b.lock.Unlock()
+
+	if last {
+		b.em.End(ctx)
+	}
+}
+
+func (b *branch) OnEvent(ctx context.Context, e Event) {
+	if e == End {
+		for _, f := range []Filter{b.then, b.els} {
+			if f != nil {
+				f.OnEvent(ctx, End)
+			} else {
+				b.merge(ctx, End)
+			}
+		}
+		return
+	}
+
+	f := b.els
+	if b.pred(e) {
+		f = b.then
+	}
+	if f != nil {
+		f.OnEvent(ctx, e)
+	}
+}
+
+func (b *branch) Register(ctx context.Context, oer Observer) {
+	b.o.Register(ctx, oer)
+}
+
+func (b *branch) Name() string {
+	return b.o.Name()
+}
+
+func (b *branch) String() string {
+	return nameOr(b.o.Name(), "branch", b)
+}
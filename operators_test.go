@@ -0,0 +1,161 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func ExampleTap() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	n := 0
+	tap := Tap(func(ctx context.Context, e Event) {
+		n++
+	})
+	o.Register(ctx, tap)
+	tap.Register(ctx, printObserver{})
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("b"))
+	fmt.Println(n, "events")
+
+	// Output:
+	// a
+	// b
+	// 2 events
+}
+
+func ExampleBranch() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	forward := func(prefix string) Filter {
+		return Map(func(ctx context.Context, em Emitter, e Event) {
+			if e != End {
+				e = stringEvent(prefix + fmt.Sprint(e))
+			}
+			em.Emit(ctx, e)
+		})
+	}
+
+	b := Branch(func(e Event) bool {
+		_, ok := e.(intEvent)
+		return ok
+	}, forward("number "), forward("other "))
+	o.Register(ctx, b)
+	b.Register(ctx, printObserver{})
+
+	em.Emit(ctx, intEvent(1))
+	em.Emit(ctx, stringEvent("a"))
+	em.End(ctx)
+
+	// Output:
+	// number 1
+	// other a
+	// End
+}
+
+func ExampleChain() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	prefix := func(p string) Filter {
+		return MapT(func(ctx context.Context, e stringEvent) (stringEvent, bool) {
+			return stringEvent(p + string(e)), true
+		})
+	}
+
+	c := Chain(prefix("a"), nil, prefix("b"), prefix("c"))
+	o.Register(ctx, c)
+	c.Register(ctx, printObserver{})
+
+	em.Emit(ctx, stringEvent("!"))
+	em.End(ctx)
+
+	// Output:
+	// cba!
+	// End
+}
+
+func ExampleTake() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	c := Chain(
+		Skip(1),
+		Where(func(e Event) bool { return e != stringEvent("b") }),
+		MapEvent(func(e Event) Event { return stringEvent(fmt.Sprint(e, e)) }),
+		Distinct(nil),
+		Take(2),
+	)
+	o.Register(ctx, c)
+	c.Register(ctx, printObserver{})
+
+	for _, s := range []string{"a", "b", "c", "c", "d", "e"} {
+		em.Emit(ctx, stringEvent(s))
+	}
+
+	// Output:
+	// cc
+	// dd
+	// End
+}
+
+func ExampleReduce() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	sum := Reduce(0, func(sum int, e Event) int {
+		return sum + int(e.(intEvent))
+	})
+	o.Register(ctx, sum)
+	sum.Register(ctx, printObserver{})
+
+	for i := 1; i <= 4; i++ {
+		em.Emit(ctx, intEvent(i))
+	}
+	em.End(ctx)
+
+	// Output:
+	// {10 false}
+	// End
+}
+
+func TestTakeZero(t *testing.T) {
+	ctx := context.Background()
+	em, o := Pair()
+
+	take := Take(0)
+	o.Register(ctx, take)
+
+	var got []Event
+	take.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		got = append(got, e)
+	}))
+
+	em.Emit(ctx, intEvent(1))
+	em.Emit(ctx, intEvent(2))
+
+	if fmt.Sprint(got) != "[End]" {
+		t.Errorf("expected Take(0) to end right away, got %v", got)
+	}
+}
@@ -0,0 +1,313 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures the Emitter and Observable returned by Pair.
+type Option func(*config)
+
+type config struct {
+	name      string
+	buffer    int
+	async     bool
+	clock     Clock
+	regCtx    bool
+	envelope  []string
+	sealing   bool
+	ids       IDGenerator
+	hlc       *HLC
+	vector    string
+	lineage   bool
+	recover   func(error)
+	supervise bool
+	preempt   bool
+	fair      bool
+	doubleEnd func(context.Context)
+	manualEnd bool
+	close     func() error
+	trace     int
+	reports   bool
+	snapshots time.Duration
+	barriers  map[string]bool
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{
+		clock: SystemClock,
+		trace: defaultTrace,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
+
+// WithName names the stream.
+func WithName(name string) Option {
+	return func(cfg *config) {
+		cfg.name = name
+	}
+}
+
+// WithBuffer makes Emit queue up to n events instead of delivering them right away.
+// Queued events are delivered in order by a separate goroutine, so Emit only blocks when the queue is full.
+// If combined with WithAsync, n is the size of the queue of each observer instead, 64 by default.
+func WithBuffer(n int) Option {
+	return func(cfg *config) {
+		cfg.buffer = n
+	}
+}
+
+// WithAsync gives each observer its own goroutine and queue that events are delivered through,
+// so a slow observer does not hold up the others, nor Emit until its queue is full, see WithBuffer.
+// Use Drain to wait for the observers to handle End.
+func WithAsync() Option {
+	return func(cfg *config) {
+		cfg.async = true
+	}
+}
+
+// WithRegistrationContext makes observers receive events with a context that carries the values of the context
+// passed to Emit, but is cancelled with the context the observer was registered with. Without it, observers
+// receive the context passed to Emit and can't tell whether their registration was cancelled meanwhile.
+func WithRegistrationContext() Option {
+	return func(cfg *config) {
+		cfg.regCtx = true
+	}
+}
+
+// WithEnvelope makes Emit wrap events in an Envelope carrying the metadata of the context, see WithMeta.
+// If keys are given, only those are copied.
+func WithEnvelope(keys ...string) Option {
+	return func(cfg *config) {
+		cfg.sealing = true
+		cfg.envelope = keys
+	}
+}
+
+// WithIDs makes Emit wrap events in an Envelope like WithEnvelope, and stamp each envelope with an id
+// returned by gen. Envelopes that already have an id keep it.
+func WithIDs(gen IDGenerator) Option {
+	return func(cfg *config) {
+		cfg.sealing = true
+		cfg.ids = gen
+	}
+}
+
+// WithHLC makes Emit wrap events in an Envelope like WithEnvelope, and stamp each envelope with a timestamp
+// of c. Envelopes that already have a timestamp, e.g. because they were received from another process,
+// get a new one that is merged into c, so events stay ordered across processes, see HLC.
+func WithHLC(c *HLC) Option {
+	return func(cfg *config) {
+		cfg.sealing = true
+		cfg.hlc = c
+	}
+}
+
+// WithVectorClock makes Emit wrap events in an Envelope like WithEnvelope, and stamp each envelope with the
+// vector clock of the stream, which counts as the given node. Envelopes stamped by another node are merged
+// into the clock, so events can be checked for causal order, see CheckCausality.
+func WithVectorClock(node string) Option {
+	return func(cfg *config) {
+		cfg.sealing = true
+		cfg.vector = node
+	}
+}
+
+// WithLineage makes Emit wrap events in an Envelope like WithEnvelope, and record the id of the event that was
+// being handled when they were emitted as their parent, so derived events can be traced back, see Lineage.
+// Use it together with WithIDs, on the streams of filters and of the sources they observe.
+func WithLineage() Option {
+	return func(cfg *config) {
+		cfg.sealing = true
+		cfg.lineage = true
+	}
+}
+
+// WithPreemptiveEnd makes End overtake queued events in buffered and async streams: once End was emitted,
+// events still waiting for delivery are discarded. Use it for emergency shutdown. Without it, End is always
+// delivered after all events emitted before it.
+func WithPreemptiveEnd() Option {
+	return func(cfg *config) {
+		cfg.preempt = true
+	}
+}
+
+// WithFairness makes Emit queue events per producer and deliver the queues in turn, so a busy producer can't
+// starve the others. Producers are told apart by the context passed to Emit, see WithProducer. Each producer
+// may have as many events queued as set using WithBuffer, at least one. Events of the same producer are
+// delivered in order, and End is delivered after all events emitted before it.
+func WithFairness() Option {
+	return func(cfg *config) {
+		cfg.fair = true
+	}
+}
+
+// WithDoubleEndHook sets a function that is called when End is emitted on a stream that already ended.
+// Ending twice does nothing else, but usually is a bug worth reporting.
+func WithDoubleEndHook(fn func(context.Context)) Option {
+	return func(cfg *config) {
+		cfg.doubleEnd = fn
+	}
+}
+
+// WithManualEnd makes Map leave ending the filter to its function, which then has to emit End itself.
+func WithManualEnd() Option {
+	return func(cfg *config) {
+		cfg.manualEnd = true
+	}
+}
+
+// WithClose makes Map return a Filter that is a Closer, calling fn when closed. Use it to release the
+// resources used by the function of the filter, see Connect.
+func WithClose(fn func() error) Option {
+	return func(cfg *config) {
+		cfg.close = fn
+	}
+}
+
+// defaultTrace is the number of events traced unless set WithTrace.
+const defaultTrace = 8
+
+// WithTrace keeps a summary of the last n events of the stream, see Tracer. Streams keep the last 8 by default,
+// which is cheap enough for production use; n of zero turns tracing off.
+func WithTrace(n int) Option {
+	return func(cfg *config) {
+		cfg.trace = n
+	}
+}
+
+// WithReports makes the stream report each delivery to an observer, see Reporter.
+func WithReports() Option {
+	return func(cfg *config) {
+		cfg.reports = true
+	}
+}
+
+// WithSnapshots makes aggregating filters like Aggregate also emit their state every interval.
+func WithSnapshots(interval time.Duration) Option {
+	return func(cfg *config) {
+		cfg.snapshots = interval
+	}
+}
+
+// WithBarrier makes events of the given types barriers: Emit only returns, and the next event is only
+// delivered, once all observers handled them, also in buffered and async streams. Use it e.g. for
+// configuration changes that must be fully applied before proceeding.
+func WithBarrier(types ...string) Option {
+	return func(cfg *config) {
+		if cfg.barriers == nil {
+			cfg.barriers = make(map[string]bool)
+		}
+		for _, typ := range types {
+			cfg.barriers[typ] = true
+		}
+	}
+}
+
+// WithRecovery makes the stream recover from observers that panic: the panic is passed to onError as an
+// ErrEvent wrapping a *PanicError, with the stack trace of the panic, and the event is still delivered to the
+// other observers. Use ErrorsTo to get the panics on a stream. Without it, a panic unwinds Emit.
+func WithRecovery(onError func(error)) Option {
+	return func(cfg *config) {
+		cfg.recover = onError
+	}
+}
+
+// WithSupervisor makes the stream call its observers on a goroutine of its own, which is replaced if an
+// observer ends it, e.g. using runtime.Goexit. Panics are recovered like with WithRecovery, and both are passed
+// to onError as an ErrEvent, so a crashing observer is contained and the stream keeps serving the others.
+// Async streams, whose observers have goroutines of their own anyway, only recover panics.
+// The goroutine runs until End was emitted.
+func WithSupervisor(onError func(error)) Option {
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	return func(cfg *config) {
+		cfg.recover = onError
+		cfg.supervise = true
+	}
+}
+
+// WithClock sets the Clock used by the stream.
+func WithClock(c Clock) Option {
+	return func(cfg *config) {
+		cfg.clock = c
+	}
+}
+
+// Clock tells the time and makes timers. All time-dependent parts of voyeur use a Clock,
+// so tests can replace the system clock with a fake one.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is like a time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is like a time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock is the Clock backed by package time.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
@@ -0,0 +1,316 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ExampleWithAsync() {
+	ctx := context.Background()
+	em, o := Pair(WithName("orders"), WithAsync(), WithBuffer(16))
+
+	done := make(chan struct{})
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		// slow observers only hold up themselves
+		time.Sleep(time.Millisecond)
+		fmt.Println(e)
+		if e == End {
+			close(done)
+		}
+	}))
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("b"))
+	em.End(ctx)
+
+	<-done
+
+	// Output:
+	// a
+	// b
+	// End
+}
+
+func ExampleWithBuffer() {
+	ctx := context.Background()
+	em, o := Pair(WithBuffer(2))
+
+	done := make(chan struct{})
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println(e)
+		if e == End {
+			close(done)
+		}
+	}))
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("b"))
+	em.Emit(ctx, stringEvent("c"))
+	em.End(ctx)
+
+	<-done
+
+	// Output:
+	// a
+	// b
+	// c
+	// End
+}
+
+func ExampleWithRegistrationContext() {
+	type key struct{}
+
+	em, o := Pair(WithRegistrationContext())
+
+	regCtx, cancel := context.WithCancel(context.Background())
+	o.Register(regCtx, ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println(e, ctx.Value(key{}), ctx.Err())
+
+		// e.g. the owner of the observer shuts it down while it is working
+		cancel()
+		fmt.Println(ctx.Err())
+	}))
+
+	emitCtx := context.WithValue(context.Background(), key{}, "value")
+	em.Emit(emitCtx, stringEvent("a"))
+
+	// Output:
+	// a value <nil>
+	// context canceled
+}
+
+func TestEndOrdering(t *testing.T) {
+	modes := map[string][]Option{
+		"buffered":         {WithBuffer(8)},
+		"async":            {WithAsync(), WithBuffer(8)},
+		"unbuffered async": {WithAsync()},
+	}
+
+	for name, opts := range modes {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			em, o := Pair(opts...)
+
+			var got []Event
+			done := make(chan struct{})
+			o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+				got = append(got, e)
+				if e == End {
+					close(done)
+				}
+			}))
+
+			for i := 0; i < 1000; i++ {
+				em.Emit(ctx, intEvent(i))
+			}
+			em.End(ctx)
+			<-done
+
+			if len(got) != 1001 || got[999] != intEvent(999) || got[1000] != End {
+				t.Errorf("End overtook events: got %d events, last %v", len(got), got[len(got)-1])
+			}
+		})
+	}
+}
+
+func TestAsyncSlowObserver(t *testing.T) {
+	ctx := context.Background()
+	em, o := Pair(WithAsync())
+
+	release := make(chan struct{})
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		<-release
+	}))
+
+	fast := make(chan Event, 4)
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		fast <- e
+	}))
+
+	// neither Emit nor the fast observer wait for the stuck one
+	emitted := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			em.Emit(ctx, intEvent(i))
+		}
+		close(emitted)
+	}()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case e := <-fast:
+			if e != intEvent(i) {
+				t.Errorf("expected %d, got %v", i, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("fast observer held up at event %d", i)
+		}
+	}
+
+	select {
+	case <-emitted:
+	case <-time.After(time.Second):
+		t.Fatal("Emit held up by slow observer")
+	}
+
+	close(release)
+	em.End(ctx)
+	if err := Drain(ctx, o); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPreemptiveEnd(t *testing.T) {
+	ctx := context.Background()
+	em, o := Pair(WithBuffer(16), WithPreemptiveEnd())
+
+	var got []Event
+	started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		got = append(got, e)
+		switch {
+		case e == intEvent(0):
+			close(started)
+			<-release
+		case e == End:
+			close(done)
+		}
+	}))
+
+	em.Emit(ctx, intEvent(0))
+	<-started
+
+	// these wait in the queue and are discarded
+	for i := 1; i < 10; i++ {
+		em.Emit(ctx, intEvent(i))
+	}
+	em.End(ctx)
+	close(release)
+	<-done
+
+	if fmt.Sprint(got) != "[0 End]" {
+		t.Errorf("expected the queue to be discarded, got %v", got)
+	}
+}
+
+func ExampleWithBarrier() {
+	ctx := context.Background()
+	em, o := Pair(WithAsync(), WithBuffer(16), WithBarrier("string"))
+
+	var applied atomic.Int32
+	for i := 0; i < 3; i++ {
+		o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+			if e != End {
+				time.Sleep(time.Millisecond)
+				applied.Add(1)
+			}
+		}))
+	}
+
+	// returns once all observers applied the change
+	em.Emit(ctx, stringEvent("config"))
+	fmt.Println(applied.Load())
+
+	// Output:
+	// 3
+}
+
+func TestBarrierOrdering(t *testing.T) {
+	ctx := context.Background()
+	em, o := Pair(WithAsync(), WithBuffer(16), WithBarrier("string"))
+
+	var (
+		lock sync.Mutex
+		got  []Event
+	)
+	release := make(chan struct{})
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if e == stringEvent("barrier") {
+			<-release
+		}
+	}))
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		lock.Lock()
+		got = append(got, e)
+		lock.Unlock()
+	}))
+
+	emitted := make(chan struct{})
+	go func() {
+		em.Emit(ctx, stringEvent("barrier"))
+		em.Emit(ctx, intEvent(1))
+		close(emitted)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-emitted:
+		t.Fatal("Emit returned before all observers handled the barrier")
+	default:
+	}
+
+	lock.Lock()
+	if fmt.Sprint(got) != "[barrier]" {
+		t.Errorf("expected only the barrier to be delivered, got %v", got)
+	}
+	lock.Unlock()
+
+	close(release)
+	<-emitted
+}
+
+func ExampleWithManualEnd() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	// drops everything, but still ends
+	auto := Map(func(ctx context.Context, em Emitter, e Event) {})
+
+	// ends only after a final summary
+	n := 0
+	manual := Map(func(ctx context.Context, em Emitter, e Event) {
+		if e == End {
+			em.Emit(ctx, stringEvent(fmt.Sprint(n, " events")))
+			em.End(ctx)
+			return
+		}
+		n++
+	}, WithManualEnd())
+
+	for name, f := range map[string]Filter{"auto": auto, "manual": manual} {
+		name := name
+		o.Register(ctx, f)
+		f.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+			fmt.Println(name, e)
+		}))
+	}
+
+	em.Emit(ctx, stringEvent("a"))
+	em.End(ctx)
+
+	// Unordered output:
+	// auto End
+	// manual 1 events
+	// manual End
+}
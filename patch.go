@@ -0,0 +1,203 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PatchOp is a single operation of a patch, modeled after JSON Patch (RFC 6902).
+// Op is one of "add", "replace" and "remove"; Path is a JSON Pointer to the key.
+type PatchOp[V any] struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value V      `json:"value,omitempty"`
+}
+
+// PatchEvent is emitted by Store.Replace and carries the changes needed to get from the old to the new state.
+type PatchEvent[V any] struct {
+	Ops []PatchOp[V]
+}
+
+func (PatchEvent[V]) EventType() string {
+	return "patch"
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+func keyPath(key string) string {
+	return "/" + pointerEscaper.Replace(key)
+}
+
+func pathKey(path string) (string, error) {
+	if !strings.HasPrefix(path, "/") || strings.Contains(path[1:], "/") {
+		return "", fmt.Errorf("invalid patch path %q", path)
+	}
+
+	return pointerUnescaper.Replace(path[1:]), nil
+}
+
+// Diff returns the minimal patch that turns from into to. The operations are ordered by key.
+func Diff[V any](from, to map[string]V) []PatchOp[V] {
+	var ops []PatchOp[V]
+
+	for k, v := range to {
+		old, ok := from[k]
+		switch {
+		case !ok:
+			ops = append(ops, PatchOp[V]{Op: "add", Path: keyPath(k), Value: v})
+		case !reflect.DeepEqual(old, v):
+			ops = append(ops, PatchOp[V]{Op: "replace", Path: keyPath(k), Value: v})
+		}
+	}
+
+	for k := range from {
+		if _, ok := to[k]; !ok {
+			ops = append(ops, PatchOp[V]{Op: "remove", Path: keyPath(k)})
+		}
+	}
+
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Path < ops[j].Path })
+	return ops
+}
+
+// Apply applies ops to m. It fails if an operation does not fit the contents of m,
+// e.g. when removing a key that does not exist, but keeps the operations applied before.
+func Apply[V any](m map[string]V, ops []PatchOp[V]) error {
+	for _, op := range ops {
+		k, err := pathKey(op.Path)
+		if err != nil {
+			return err
+		}
+
+		_, exists := m[k]
+
+		switch op.Op {
+		case "add":
+			m[k] = op.Value
+		case "replace", "remove":
+			if !exists {
+				return fmt.Errorf("patch: %s of missing key %q", op.Op, k)
+			}
+
+			if op.Op == "replace" {
+				m[k] = op.Value
+			} else {
+				delete(m, k)
+			}
+		default:
+			return fmt.Errorf("patch: unsupported op %q", op.Op)
+		}
+	}
+
+	return nil
+}
+
+// Patch returns the change as patch.
+func (ch ChangeEvent[V]) Patch() []PatchOp[V] {
+	switch {
+	case ch.Op == ChangeDelete:
+		return []PatchOp[V]{{Op: "remove", Path: keyPath(ch.Key)}}
+	case ch.HadPrev:
+		return []PatchOp[V]{{Op: "replace", Path: keyPath(ch.Key), Value: ch.Value}}
+	default:
+		return []PatchOp[V]{{Op: "add", Path: keyPath(ch.Key), Value: ch.Value}}
+	}
+}
+
+// Replace replaces the contents of the store with m and emits the difference as a single PatchEvent.
+// Nothing is emitted if the contents do not change.
+func (s *Store[V]) Replace(ctx context.Context, m map[string]V) {
+	s.emitLock.Lock()
+	defer s.emitLock.Unlock()
+
+	s.lock.Lock()
+	ops := Diff(s.data, m)
+	s.data = make(map[string]V, len(m))
+	for k, v := range m {
+		s.data[k] = v
+	}
+	s.lock.Unlock()
+
+	if len(ops) > 0 {
+		s.em.Emit(ctx, PatchEvent[V]{Ops: ops})
+	}
+}
+
+// Replica is an Observer that reconstructs the state of a Store from its ChangeEvents and PatchEvents.
+// Register it using Store.RegisterSnapshot to start out with the full state.
+type Replica[V any] struct {
+	lock sync.RWMutex
+	data map[string]V
+	err  error
+}
+
+// NewReplica returns an empty Replica.
+func NewReplica[V any]() *Replica[V] {
+	return &Replica[V]{
+		data: make(map[string]V),
+	}
+}
+
+// OnEvent applies changes and patches. Other events are ignored.
+func (r *Replica[V]) OnEvent(ctx context.Context, e Event) {
+	var ops []PatchOp[V]
+
+	switch e := e.(type) {
+	case ChangeEvent[V]:
+		ops = e.Patch()
+	case PatchEvent[V]:
+		ops = e.Ops
+	default:
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := Apply(r.data, ops); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Err returns the first error that occurred while applying a patch, which means the replica went out of sync.
+func (r *Replica[V]) Err() error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.err
+}
+
+// Snapshot returns a copy of the reconstructed state.
+func (r *Replica[V]) Snapshot() map[string]V {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	m := make(map[string]V, len(r.data))
+	for k, v := range r.data {
+		m[k] = v
+	}
+
+	return m
+}
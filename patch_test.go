@@ -0,0 +1,55 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+)
+
+func ExampleDiff() {
+	from := map[string]int{"a": 1, "b": 2, "c/d": 3}
+	to := map[string]int{"a": 1, "b": 5, "e": 4}
+
+	for _, op := range Diff(from, to) {
+		fmt.Println(op.Op, op.Path, op.Value)
+	}
+
+	// Output:
+	// replace /b 5
+	// remove /c~1d 0
+	// add /e 4
+}
+
+func ExampleReplica() {
+	ctx := context.Background()
+	s := NewStore[string]()
+	s.Set(ctx, "greeting", "hello")
+
+	r := NewReplica[string]()
+	s.RegisterSnapshot(ctx, r)
+
+	s.Set(ctx, "name", "world")
+	s.Replace(ctx, map[string]string{"greeting": "hi", "name": "world", "punctuation": "!"})
+	s.Delete(ctx, "name")
+
+	fmt.Println(r.Snapshot(), r.Err())
+
+	// Output:
+	// map[greeting:hi punctuation:!] <nil>
+}
@@ -0,0 +1,36 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+)
+
+// Pipe forwards the events of from to to until ctx is cancelled. When from ends, to is ended.
+// Cancelling ctx only detaches to from from; to is not ended, so it can be fed from elsewhere.
+func Pipe(ctx context.Context, from Observable, to Emitter) {
+	to = emitterOrNop(to)
+	observableOrNop(from).Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if e == End {
+			to.End(ctx)
+			return
+		}
+
+		to.Emit(ctx, e)
+	}))
+}
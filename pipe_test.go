@@ -0,0 +1,39 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+)
+
+func ExamplePipe() {
+	ctx := context.Background()
+
+	src, from := Pair()
+	to, o := Pair()
+	o.Register(ctx, printObserver{})
+
+	Pipe(ctx, from, to)
+
+	src.Emit(ctx, stringEvent("a"))
+	src.End(ctx)
+
+	// Output:
+	// a
+	// End
+}
@@ -0,0 +1,125 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+)
+
+// Pipeline is a chain of filters fed by a source. It emits what the last stage emits, and is a Filter itself.
+type Pipeline struct {
+	from   Observable
+	stages []Filter
+	detach context.CancelFunc
+
+	// ended[i] is closed when stage i emitted End
+	ended []chan struct{}
+
+	lock sync.Mutex
+	// started is set once End was passed to the first stage
+	started bool
+}
+
+// NewPipeline registers the stages on from and on each other, in order. Stages that are a Closer are closed
+// after they emitted End. The pipeline is torn down when ctx is cancelled; see Shutdown for a graceful end.
+func NewPipeline(ctx context.Context, from Observable, stages ...Filter) *Pipeline {
+	if len(stages) == 0 {
+		stages = []Filter{Map(func(ctx context.Context, em Emitter, e Event) { em.Emit(ctx, e) })}
+	}
+
+	srcCtx, detach := context.WithCancel(ctx)
+	p := &Pipeline{
+		from:   from,
+		stages: stages,
+		detach: detach,
+		ended:  make([]chan struct{}, len(stages)),
+	}
+
+	from.Register(srcCtx, ObserverFunc(p.OnEvent))
+
+	for i, stage := range stages {
+		if i > 0 {
+			stages[i-1].Register(ctx, stage)
+		}
+
+		ended, stage := make(chan struct{}), stage
+		p.ended[i] = ended
+
+		var once sync.Once
+		stage.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+			if e != End {
+				return
+			}
+
+			once.Do(func() {
+				if closer, ok := stage.(Closer); ok {
+					closer.Close()
+				}
+				close(ended)
+			})
+		}))
+	}
+
+	return p
+}
+
+// start reports whether End wasn't passed to the first stage yet, and marks it passed.
+func (p *Pipeline) start() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.started {
+		return false
+	}
+
+	p.started = true
+	return true
+}
+
+// OnEvent passes e to the first stage, like the source does. End is only passed once.
+func (p *Pipeline) OnEvent(ctx context.Context, e Event) {
+	if e == End && !p.start() {
+		return
+	}
+
+	p.stages[0].OnEvent(ctx, e)
+}
+
+// Register registers oer on the last stage.
+func (p *Pipeline) Register(ctx context.Context, oer Observer) {
+	p.stages[len(p.stages)-1].Register(ctx, oer)
+}
+
+// Shutdown detaches the pipeline from its source and passes End to the first stage. Then it waits for the stages
+// to end one after the other, so each stage is drained before its successor stops and none emits into a stopped
+// stage. It returns ctx.Err() if ctx is done before the last stage ended.
+func (p *Pipeline) Shutdown(ctx context.Context) error {
+	p.detach()
+	p.OnEvent(ctx, End)
+
+	for _, ended := range p.ended {
+		select {
+		case <-ended:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+)
+
+func ExamplePipeline() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	upper := Map(func(ctx context.Context, em Emitter, e Event) {
+		if s, ok := e.(stringEvent); ok {
+			e = stringEvent(s + "!")
+		}
+		em.Emit(ctx, e)
+	}, WithBuffer(8))
+
+	count, closed := 0, false
+	counter := Map(func(ctx context.Context, em Emitter, e Event) {
+		if e == End {
+			em.Emit(ctx, stringEvent(fmt.Sprint(count, " events")))
+			return
+		}
+		count++
+		em.Emit(ctx, e)
+	}, WithClose(func() error {
+		closed = true
+		return nil
+	}))
+
+	p := NewPipeline(ctx, o, upper, counter)
+	p.Register(ctx, printObserver{})
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("b"))
+
+	// the buffered first stage is drained before the counter stops
+	if err := p.Shutdown(ctx); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println("counter closed:", closed)
+
+	// detached from the source
+	em.Emit(ctx, stringEvent("c"))
+
+	// Output:
+	// a!
+	// b!
+	// 2 events
+	// End
+	// counter closed: true
+}
@@ -0,0 +1,69 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"time"
+)
+
+// Quiesce returns nil once o has emitted no event for idle, or ends. If ctx is done first, it returns
+// the context's error. Use it instead of sleeping in tests and shutdown code that wait for a stream to settle.
+// Only the WithClock option is used.
+func Quiesce(ctx context.Context, o Observable, idle time.Duration, opts ...Option) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		activity = make(chan struct{}, 1)
+		ended    = make(chan struct{})
+	)
+	o.Register(ctx, ObserverFunc(func(_ context.Context, e Event) {
+		if e == End {
+			close(ended)
+			return
+		}
+
+		select {
+		case activity <- struct{}{}:
+		default:
+		}
+	}))
+
+	t := newConfig(opts).clock.NewTimer(idle)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-activity:
+			if !t.Stop() {
+				select {
+				case <-t.C():
+				default:
+				}
+			}
+			t.Reset(idle)
+		case <-t.C():
+			return nil
+		case <-ended:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
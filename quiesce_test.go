@@ -0,0 +1,67 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQuiesce(t *testing.T) {
+	ctx := context.Background()
+	em, o := Pair(WithAsync(), WithBuffer(16))
+
+	var seen atomic.Int64
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if e != End {
+			seen.Add(1)
+		}
+	}))
+
+	go func() {
+		for i := 0; i < 20; i++ {
+			em.Emit(ctx, intEvent(i))
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	if err := Quiesce(ctx, o, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if n := seen.Load(); n != 20 {
+		t.Errorf("expected 20 events before the stream was quiet, got %d", n)
+	}
+}
+
+func TestQuiesceTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	em, o := Pair()
+	go func() {
+		for ctx.Err() == nil {
+			em.Emit(ctx, intEvent(0))
+		}
+	}()
+
+	if err := Quiesce(ctx, o, time.Hour); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline to be exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned by QuotaEmitter.TryEmit for events over the quota of their producer.
+var ErrQuotaExceeded = errors.New("voyeur: quota exceeded")
+
+// QuotaOptions configure NewQuotaEmitter.
+type QuotaOptions struct {
+	// Limit is the number of events each producer may emit per Window. Zero means no limit.
+	Limit int
+
+	// Window is the length of the windows quotas are counted in. Defaults to one second.
+	Window time.Duration
+
+	// Producer tells producers apart. It defaults to the id set on the context using WithProducer, so without
+	// ids, all events count against the same quota.
+	Producer func(context.Context, Event) string
+
+	// Divert, if not nil, gets the events over quota instead of dropping them, e.g. for logging them.
+	Divert Emitter
+
+	// Clock defaults to SystemClock.
+	Clock Clock
+}
+
+// quotaWindow counts the events of a producer in the current window.
+type quotaWindow struct {
+	start time.Time
+	count int
+}
+
+// QuotaEmitter is an Emitter that passes events on to another Emitter as long as their producer stays within
+// its quota, protecting shared streams from a component that emits too much. End is always passed on.
+type QuotaEmitter struct {
+	em   Emitter
+	opts QuotaOptions
+
+	lock    sync.Mutex
+	windows map[string]*quotaWindow
+}
+
+// NewQuotaEmitter returns a QuotaEmitter passing events on to em.
+func NewQuotaEmitter(em Emitter, opts QuotaOptions) *QuotaEmitter {
+	if opts.Window <= 0 {
+		opts.Window = time.Second
+	}
+	if opts.Producer == nil {
+		opts.Producer = func(ctx context.Context, _ Event) string {
+			return producerFrom(ctx)
+		}
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+
+	return &QuotaEmitter{
+		em:      emitterOrNop(em),
+		opts:    opts,
+		windows: make(map[string]*quotaWindow),
+	}
+}
+
+// Emit passes e on, or diverts or drops it if its producer exceeded its quota.
+func (q *QuotaEmitter) Emit(ctx context.Context, e Event) {
+	q.TryEmit(ctx, e)
+}
+
+// End passes End on.
+func (q *QuotaEmitter) End(ctx context.Context) {
+	q.em.End(ctx)
+}
+
+// TryEmit is like Emit, but returns ErrQuotaExceeded if e was over quota.
+func (q *QuotaEmitter) TryEmit(ctx context.Context, e Event) error {
+	if e == End {
+		q.End(ctx)
+		return nil
+	}
+
+	if !q.take(q.opts.Producer(ctx, e)) {
+		if q.opts.Divert != nil {
+			q.opts.Divert.Emit(ctx, e)
+		}
+		return ErrQuotaExceeded
+	}
+
+	q.em.Emit(ctx, e)
+	return nil
+}
+
+// take counts an event of producer and reports whether it is within the quota.
+func (q *QuotaEmitter) take(producer string) bool {
+	if q.opts.Limit <= 0 {
+		return true
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	now := q.opts.Clock.Now()
+	w, ok := q.windows[producer]
+	if !ok || now.Sub(w.start) >= q.opts.Window {
+		w = &quotaWindow{start: now}
+		q.windows[producer] = w
+
+		// forget producers that went quiet, so the map doesn't grow without bound
+		for p, other := range q.windows {
+			if now.Sub(other.start) >= q.opts.Window {
+				delete(q.windows, p)
+			}
+		}
+	}
+
+	if w.count >= q.opts.Limit {
+		return false
+	}
+
+	w.count++
+	return true
+}
@@ -0,0 +1,70 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleNewQuotaEmitter() {
+	ctx := context.Background()
+	clock := newFakeClock()
+
+	em, o := Pair()
+	o.Register(ctx, printObserver{})
+
+	rejected, ro := Pair()
+	ro.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println("over quota:", e)
+	}))
+
+	q := NewQuotaEmitter(em, QuotaOptions{Limit: 2, Window: time.Second, Divert: rejected, Clock: clock})
+	noisy, quiet := WithProducer(ctx, "noisy"), WithProducer(ctx, "quiet")
+
+	q.Emit(noisy, stringEvent("n1"))
+	q.Emit(noisy, stringEvent("n2"))
+	q.Emit(noisy, stringEvent("n3"))
+	q.Emit(quiet, stringEvent("q1"))
+
+	// the next window starts with a fresh quota
+	clock.Advance(time.Second)
+	if err := q.TryEmit(noisy, stringEvent("n4")); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// n1
+	// n2
+	// over quota: n3
+	// q1
+	// n4
+}
+
+func TestQuotaZeroLimit(t *testing.T) {
+	ctx := context.Background()
+	q := NewQuotaEmitter(NopEmitter, QuotaOptions{})
+
+	for i := 0; i < 100; i++ {
+		if err := q.TryEmit(ctx, intEvent(i)); err != nil {
+			t.Fatalf("event %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Backfiller is a stream that keeps its history, like a journal. Backfill passes oer the stored events from
+// position from on and then registers it for the live events, without gaps or duplicates.
+type Backfiller interface {
+	Backfill(ctx context.Context, from uint64, oer Observer) error
+}
+
+// RegisterOption configures Register.
+type RegisterOption func(*registerConfig)
+
+type registerConfig struct {
+	backfill Backfiller
+	from     uint64
+}
+
+// WithBackfill makes Register pass the history of b from position from on before the live events.
+// b usually is the registered Observable itself, e.g. a journal.Journal.
+func WithBackfill(b Backfiller, from uint64) RegisterOption {
+	return func(cfg *registerConfig) {
+		cfg.backfill = b
+		cfg.from = from
+	}
+}
+
+// Register registers oer on o. With WithBackfill, the Backfiller registers oer after replaying the history,
+// and its error is returned. Use Subscribe to get a handle for unregistering oer.
+func Register(ctx context.Context, o Observable, oer Observer, opts ...RegisterOption) error {
+	var cfg registerConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.backfill != nil {
+		return cfg.backfill.Backfill(ctx, cfg.from, oer)
+	}
+
+	observableOrNop(o).Register(ctx, oer)
+	return nil
+}
+
+// Subscription is a registration made by Subscribe.
+type Subscription struct {
+	cancel    context.CancelFunc
+	cancelled atomic.Bool
+
+	once sync.Once
+	done chan struct{}
+}
+
+// Subscribe registers oer on o like Register, and returns a Subscription for unregistering it.
+// The registration also ends when ctx is cancelled, or when the stream ended.
+func Subscribe(ctx context.Context, o Observable, oer Observer, opts ...RegisterOption) (*Subscription, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &Subscription{cancel: cancel, done: make(chan struct{})}
+	context.AfterFunc(ctx, sub.end)
+
+	err := Register(ctx, o, ObserverFunc(func(ctx context.Context, e Event) {
+		if sub.cancelled.Load() {
+			return
+		}
+
+		oer.OnEvent(ctx, e)
+		if e == End {
+			sub.end()
+		}
+	}), opts...)
+	if err != nil {
+		sub.Cancel()
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+func (sub *Subscription) end() {
+	sub.once.Do(func() {
+		close(sub.done)
+	})
+}
+
+// Cancel unregisters the observer. Once it returned, the observer isn't called anymore, but calls that
+// are in progress on other goroutines aren't waited for. Observers may cancel their own subscription.
+func (sub *Subscription) Cancel() {
+	sub.cancelled.Store(true)
+	sub.cancel()
+	sub.end()
+}
+
+// Done returns a channel that is closed when the registration ended.
+func (sub *Subscription) Done() <-chan struct{} {
+	return sub.done
+}
+
+// Standby registers standby on o as a warm standby for the observer whose registration is primary, e.g. a
+// *Subscription, an *ObserverHealth of Guard, or a context. standby is registered right away, but only gets the
+// events that are delivered after primary is done, so it takes over once the primary unregistered or was evicted.
+// End is passed to it in any case.
+func Standby(
+	ctx context.Context, o Observable, primary interface{ Done() <-chan struct{} }, standby Observer, opts ...RegisterOption,
+) (*Subscription, error) {
+	down := primary.Done()
+	return Subscribe(ctx, o, ObserverFunc(func(ctx context.Context, e Event) {
+		select {
+		case <-down:
+		default:
+			if e != End {
+				return
+			}
+		}
+
+		standby.OnEvent(ctx, e)
+	}), opts...)
+}
@@ -0,0 +1,128 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// memHistory is a Backfiller keeping all events in memory.
+type memHistory struct {
+	em     Emitter
+	o      Observable
+	events []Event
+}
+
+func (h *memHistory) Backfill(ctx context.Context, from uint64, oer Observer) error {
+	for _, e := range h.events[from:] {
+		oer.OnEvent(ctx, e)
+	}
+
+	h.o.Register(ctx, oer)
+	return nil
+}
+
+func (h *memHistory) Emit(ctx context.Context, e Event) {
+	h.events = append(h.events, e)
+	h.em.Emit(ctx, e)
+}
+
+func ExampleWithBackfill() {
+	ctx := context.Background()
+	em, o := Pair()
+	h := &memHistory{em: em, o: o}
+
+	h.Emit(ctx, stringEvent("a"))
+	h.Emit(ctx, stringEvent("b"))
+
+	Register(ctx, o, printObserver{}, WithBackfill(h, 1))
+	h.Emit(ctx, stringEvent("c"))
+
+	// Output:
+	// b
+	// c
+}
+
+func ExampleSubscribe() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	var sub *Subscription
+	sub, _ = Subscribe(ctx, o, ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println(e)
+		if e == stringEvent("stop") {
+			sub.Cancel()
+		}
+	}))
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("stop"))
+	em.Emit(ctx, stringEvent("b"))
+
+	<-sub.Done()
+
+	// Output:
+	// a
+	// stop
+}
+
+func TestSubscriptionDone(t *testing.T) {
+	ctx := context.Background()
+	em, o := Pair()
+
+	sub, err := Subscribe(ctx, o, NopObserver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-sub.Done():
+		t.Fatal("done before the stream ended")
+	default:
+	}
+
+	em.End(ctx)
+	<-sub.Done()
+
+	// cancelling an ended subscription does nothing
+	sub.Cancel()
+}
+
+func ExampleStandby() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	primary, _ := Subscribe(ctx, o, ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println("primary:", e)
+	}))
+	Standby(ctx, o, primary, ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println("standby:", e)
+	}))
+
+	em.Emit(ctx, stringEvent("a"))
+	primary.Cancel()
+	em.Emit(ctx, stringEvent("b"))
+	em.End(ctx)
+
+	// Output:
+	// primary: a
+	// standby: b
+	// standby: End
+}
@@ -0,0 +1,154 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var defaultRegistry = NewTypeRegistry()
+
+// RegisterType registers the type of e in the default TypeRegistry. It is meant to be called from init functions
+// and panics if another Go type already registered the same EventType.
+func RegisterType(e Event) {
+	if err := defaultRegistry.Register(e); err != nil {
+		panic(err)
+	}
+}
+
+// LookupType returns the Go type registered for typ in the default TypeRegistry.
+func LookupType(typ string) (reflect.Type, bool) {
+	return defaultRegistry.Lookup(typ)
+}
+
+// DefaultTypes returns the TypeRegistry used by RegisterType and LookupType.
+func DefaultTypes() *TypeRegistry {
+	return defaultRegistry
+}
+
+// TypeRegistry maps EventType strings to the Go types that use them.
+type TypeRegistry struct {
+	lock     sync.RWMutex
+	types    map[string]reflect.Type
+	fallback bool
+}
+
+// NewTypeRegistry returns an empty TypeRegistry.
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		types: make(map[string]reflect.Type),
+	}
+}
+
+type typeCollisionError struct {
+	typ        string
+	registered reflect.Type
+	t          reflect.Type
+}
+
+func (err typeCollisionError) Error() string {
+	return fmt.Sprintf("event type collision: %q is used by %s and %s", err.typ, err.registered, err.t)
+}
+
+// Register registers the Go type of e under e.EventType().
+// Registering the same Go type twice is fine, but it is an error if a different type already uses the EventType.
+func (r *TypeRegistry) Register(e Event) error {
+	typ, t := e.EventType(), reflect.TypeOf(e)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if registered, ok := r.types[typ]; ok && registered != t {
+		return typeCollisionError{typ: typ, registered: registered, t: t}
+	}
+
+	r.types[typ] = t
+	return nil
+}
+
+// Lookup returns the Go type registered for typ.
+func (r *TypeRegistry) Lookup(typ string) (reflect.Type, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	t, ok := r.types[typ]
+	return t, ok
+}
+
+// Types returns the registered EventTypes in lexical order.
+func (r *TypeRegistry) Types() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	typs := make([]string, 0, len(r.types))
+	for typ := range r.types {
+		typs = append(typs, typ)
+	}
+
+	sort.Strings(typs)
+	return typs
+}
+
+// SetFallback sets whether Unmarshal returns a DataEvent for unknown types instead of failing.
+func (r *TypeRegistry) SetFallback(fallback bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.fallback = fallback
+}
+
+// Unmarshal returns a new event of the Go type registered for typ, after passing a pointer to it to unmarshal.
+// unmarshal typically is a decoding function bound to the encoded event, like
+//
+//	r.Unmarshal(typ, func(v interface{}) error { return json.Unmarshal(data, v) })
+func (r *TypeRegistry) Unmarshal(typ string, unmarshal func(v interface{}) error) (Event, error) {
+	r.lock.RLock()
+	t, ok := r.types[typ]
+	fallback := r.fallback
+	r.lock.RUnlock()
+
+	if !ok && fallback {
+		e := DataEvent{Type: typ}
+		if err := unmarshal(&e.Data); err != nil {
+			return nil, fmt.Errorf("unmarshaling event of type %q: %w", typ, err)
+		}
+		return e, nil
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", typ)
+	}
+
+	isPtr := t.Kind() == reflect.Ptr
+	if isPtr {
+		t = t.Elem()
+	}
+
+	v := reflect.New(t)
+	if err := unmarshal(v.Interface()); err != nil {
+		return nil, fmt.Errorf("unmarshaling event of type %q: %w", typ, err)
+	}
+
+	if !isPtr {
+		v = v.Elem()
+	}
+
+	return v.Interface().(Event), nil
+}
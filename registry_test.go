@@ -0,0 +1,45 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import "fmt"
+
+type otherStringEvent string
+
+func (otherStringEvent) EventType() string {
+	return "string"
+}
+
+func ExampleTypeRegistry() {
+	r := NewTypeRegistry()
+
+	fmt.Println(r.Register(stringEvent("")))
+	fmt.Println(r.Register(intEvent(0)))
+	fmt.Println(r.Register(otherStringEvent("")))
+
+	t, _ := r.Lookup("int")
+	fmt.Println(t)
+	fmt.Println(r.Types())
+
+	// Output:
+	// <nil>
+	// <nil>
+	// event type collision: "string" is used by voyeur.stringEvent and voyeur.otherStringEvent
+	// voyeur.intEvent
+	// [int string]
+}
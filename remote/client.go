@@ -0,0 +1,237 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package remote
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/cloudevents"
+)
+
+// Client connects to a Server like NewFilter does, but reconnects with exponential backoff when the connection
+// fails. When reconnecting, it asks the Server to resume after the last Sequenced event it received, and drops
+// events it already emitted, so observers experience a pause rather than a gap. See Server.Resume.
+type Client struct {
+	// Dial opens a new connection to the Server.
+	Dial func(ctx context.Context) (io.ReadWriteCloser, error)
+
+	Encoder cloudevents.Encoder
+	Decoder cloudevents.Decoder
+
+	Backoff voyeur.Backoff
+
+	// Clock defaults to voyeur.SystemClock.
+	Clock voyeur.Clock
+
+	// OnError is called when dialing or a connection fails. It may be nil.
+	OnError func(error)
+}
+
+// clientFilter is the local side of a remote Filter that survives reconnects.
+type clientFilter struct {
+	cl    *Client
+	clock voyeur.Clock
+	em    voyeur.Emitter
+	o     voyeur.Observable
+
+	lock   sync.Mutex
+	cur    *conn
+	closer io.Closer
+	ready  chan struct{} // closed when cur is set or the filter ended
+	ended  bool
+
+	// last is the sequence number of the last event emitted. It is only used by the reading goroutine.
+	last uint64
+}
+
+// Filter connects to the Server and returns the Filter running there. Events observed while reconnecting
+// wait for the connection. The Filter ends when the Server sends End or ctx is cancelled.
+func (cl *Client) Filter(ctx context.Context) voyeur.Filter {
+	clock := cl.Clock
+	if clock == nil {
+		clock = voyeur.SystemClock
+	}
+
+	em, o := voyeur.Pair()
+	f := &clientFilter{
+		cl:    cl,
+		clock: clock,
+		em:    em,
+		o:     o,
+		ready: make(chan struct{}),
+	}
+
+	go f.run(ctx)
+	return f
+}
+
+func (f *clientFilter) run(ctx context.Context) {
+	defer f.end(ctx)
+
+	for attempt := 0; ctx.Err() == nil; attempt++ {
+		if attempt > 0 && !f.wait(ctx, attempt-1) {
+			return
+		}
+
+		rw, err := f.cl.Dial(ctx)
+		if err != nil {
+			f.error(err)
+			continue
+		}
+
+		c := newConn(rw, f.cl.Encoder, f.cl.Decoder, f.clock)
+		c.hello = &frame{Hello: true, Resume: f.last}
+		go c.greet()
+
+		f.connected(c, rw)
+		attempt = 0
+
+		err = f.read(ctx, c)
+		f.disconnected(c)
+		if err == nil {
+			// End was received
+			return
+		}
+		if ctx.Err() == nil {
+			f.error(err)
+		}
+	}
+}
+
+// read emits the events received on c until End, which returns nil, or an error.
+func (f *clientFilter) read(ctx context.Context, c *conn) error {
+	for {
+		e, seq, err := c.recv()
+		if err != nil {
+			return err
+		}
+		if e == voyeur.End {
+			return nil
+		}
+
+		if seq > 0 {
+			if seq <= f.last {
+				// already emitted before reconnecting
+				continue
+			}
+			f.last = seq
+		}
+
+		f.em.Emit(ctx, e)
+	}
+}
+
+// wait waits for the backoff delay and returns false if ctx was cancelled meanwhile.
+func (f *clientFilter) wait(ctx context.Context, attempt int) bool {
+	t := f.clock.NewTimer(f.cl.Backoff.Delay(attempt))
+	defer t.Stop()
+
+	select {
+	case <-t.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (f *clientFilter) connected(c *conn, closer io.Closer) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.cur, f.closer = c, closer
+	close(f.ready)
+}
+
+// disconnected closes c, unless it was replaced already.
+func (f *clientFilter) disconnected(c *conn) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.cur != c {
+		return
+	}
+
+	f.closer.Close()
+	f.cur, f.closer = nil, nil
+	f.ready = make(chan struct{})
+}
+
+func (f *clientFilter) end(ctx context.Context) {
+	f.lock.Lock()
+	f.ended = true
+	if f.cur != nil {
+		f.closer.Close()
+		f.cur, f.closer = nil, nil
+	} else {
+		close(f.ready)
+	}
+	f.lock.Unlock()
+
+	f.em.End(ctx)
+}
+
+// conn waits for the current connection. It returns nil if the filter ended or ctx was cancelled.
+func (f *clientFilter) conn(ctx context.Context) *conn {
+	for {
+		f.lock.Lock()
+		c, ready, ended := f.cur, f.ready, f.ended
+		f.lock.Unlock()
+
+		switch {
+		case ended:
+			return nil
+		case c != nil:
+			return c
+		}
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (f *clientFilter) error(err error) {
+	if f.cl.OnError != nil {
+		f.cl.OnError(err)
+	}
+}
+
+func (f *clientFilter) OnEvent(ctx context.Context, e voyeur.Event) {
+	for {
+		c := f.conn(ctx)
+		if c == nil {
+			return
+		}
+
+		if err := c.send(e); err == nil {
+			return
+		}
+
+		// the reading goroutine notices the broken connection and reconnects
+		f.disconnected(c)
+	}
+}
+
+func (f *clientFilter) Register(ctx context.Context, oer voyeur.Observer) {
+	f.o.Register(ctx, oer)
+}
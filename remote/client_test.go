@@ -0,0 +1,126 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package remote
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/cloudevents"
+)
+
+type seqWord struct {
+	Seq  uint64
+	Word string
+}
+
+func (seqWord) EventType() string {
+	return "seq-word"
+}
+
+func (w seqWord) Sequence() uint64 {
+	return w.Seq
+}
+
+func TestClientReconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	types := voyeur.NewTypeRegistry()
+	types.Register(seqWord{})
+	enc := cloudevents.Encoder{Source: "test"}
+	dec := cloudevents.Decoder{Types: types}
+
+	upper := func() voyeur.Filter {
+		return voyeur.Map(func(ctx context.Context, em voyeur.Emitter, e voyeur.Event) {
+			if w, ok := e.(seqWord); ok {
+				e = seqWord{w.Seq, strings.ToUpper(w.Word)}
+			}
+			em.Emit(ctx, e)
+		})
+	}
+
+	resumed := make(chan uint64, 1)
+	srv := &Server{
+		NewFilter: upper,
+		Resume: func(after uint64) voyeur.Filter {
+			resumed <- after
+			return upper()
+		},
+		Encoder: enc,
+		Decoder: dec,
+	}
+
+	conns := make(chan net.Conn, 2)
+	cl := &Client{
+		Dial: func(ctx context.Context) (io.ReadWriteCloser, error) {
+			local, far := net.Pipe()
+			conns <- far
+			go srv.ServeConn(ctx, far)
+			return local, nil
+		},
+		Encoder: enc,
+		Decoder: dec,
+		Backoff: voyeur.Backoff{Min: time.Millisecond},
+	}
+
+	f := cl.Filter(ctx)
+	got := make(chan voyeur.Event, 3)
+	f.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		got <- e
+	}))
+
+	expect := func(exp voyeur.Event) {
+		t.Helper()
+		select {
+		case e := <-got:
+			if e != exp {
+				t.Fatalf("expected %v, got %v", exp, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %v", exp)
+		}
+	}
+
+	f.OnEvent(ctx, seqWord{1, "a"})
+	expect(seqWord{1, "A"})
+
+	// break the connection
+	(<-conns).Close()
+
+	select {
+	case after := <-resumed:
+		if after != 1 {
+			t.Errorf("expected to resume after 1, got %d", after)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("client didn't reconnect")
+	}
+
+	// the server repeating an event is not emitted twice
+	f.OnEvent(ctx, seqWord{1, "again"})
+	f.OnEvent(ctx, seqWord{2, "b"})
+	f.OnEvent(ctx, voyeur.End)
+	expect(seqWord{2, "B"})
+	expect(voyeur.End)
+}
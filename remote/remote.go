@@ -0,0 +1,474 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+Package remote runs Filters in other processes. Events observed by the local Filter are streamed to a
+Server running the actual Filter, and the events it emits are streamed back and emitted locally.
+
+Events travel as CloudEvents over any stream connection, e.g. TCP or a Unix socket, one JSON object each.
+This keeps voyeur free of an RPC framework dependency; the protocol is simple enough to be spoken by
+filters written in other languages.
+*/
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/cloudevents"
+)
+
+// frame is the unit sent over the connection. A frame either carries an event, signals End,
+// is a keepalive ping or the pong answering it, or is the hello a client starts with.
+type frame struct {
+	Event *cloudevents.CloudEvent `json:"event,omitempty"`
+	Seq   uint64                  `json:"seq,omitempty"`
+	End   bool                    `json:"end,omitempty"`
+	Ping  bool                    `json:"ping,omitempty"`
+	Pong  bool                    `json:"pong,omitempty"`
+
+	// Hello is sent first by clients. Resume is the sequence number of the last event it received
+	// on a previous connection, if any.
+	Hello  bool   `json:"hello,omitempty"`
+	Resume uint64 `json:"resume,omitempty"`
+}
+
+// Sequenced is implemented by events that have a position in their stream, e.g. a journal offset.
+// The Server sends the position along, so a Client can resume after the last event it received.
+type Sequenced interface {
+	voyeur.Event
+	Sequence() uint64
+}
+
+// conn encodes and decodes frames.
+type conn struct {
+	enc   cloudevents.Encoder
+	dec   cloudevents.Decoder
+	clock voyeur.Clock
+
+	wlock sync.Mutex
+	w     *json.Encoder
+	r     *json.Decoder
+
+	// hello, if not nil, is sent before any other frame
+	hello *frame
+
+	// lastSeen is the time the last frame was received, in Unix nanoseconds
+	lastSeen atomic.Int64
+}
+
+func newConn(rw io.ReadWriter, enc cloudevents.Encoder, dec cloudevents.Decoder, clock voyeur.Clock) *conn {
+	c := &conn{
+		enc:   enc,
+		dec:   dec,
+		clock: clock,
+		w:     json.NewEncoder(rw),
+		r:     json.NewDecoder(rw),
+	}
+
+	c.lastSeen.Store(clock.Now().UnixNano())
+	return c
+}
+
+func (c *conn) sendFrame(f frame) error {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	if err := c.sendHello(); err != nil {
+		return err
+	}
+	return c.w.Encode(f)
+}
+
+// sendHello sends the hello frame if it wasn't sent yet. The write lock must be held.
+func (c *conn) sendHello() error {
+	if c.hello == nil {
+		return nil
+	}
+
+	err := c.w.Encode(*c.hello)
+	c.hello = nil
+	return err
+}
+
+// greet sends the hello frame without waiting for another frame to be sent.
+// Call it on its own goroutine, as the server may be writing before reading.
+func (c *conn) greet() {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	c.sendHello()
+}
+
+func (c *conn) send(e voyeur.Event) error {
+	var f frame
+	if e == voyeur.End {
+		f.End = true
+	} else {
+		ce, err := c.enc.Encode(e)
+		if err != nil {
+			return err
+		}
+		f.Event = ce
+
+		if seq, ok := e.(Sequenced); ok {
+			f.Seq = seq.Sequence()
+		}
+	}
+
+	return c.sendFrame(f)
+}
+
+// recv returns the next event and its sequence number, if it has one. Pings are answered on the way.
+func (c *conn) recv() (voyeur.Event, uint64, error) {
+	for {
+		f, err := c.recvFrame()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		switch {
+		case f.Ping:
+			if err := c.sendFrame(frame{Pong: true}); err != nil {
+				return nil, 0, err
+			}
+		case f.Pong, f.Hello:
+		case f.End:
+			return voyeur.End, 0, nil
+		case f.Event == nil:
+			return nil, 0, errEmptyFrame
+		default:
+			e, err := c.dec.Decode(f.Event)
+			return e, f.Seq, err
+		}
+	}
+}
+
+func (c *conn) recvFrame() (frame, error) {
+	var f frame
+	if err := c.r.Decode(&f); err != nil {
+		return f, err
+	}
+
+	c.lastSeen.Store(c.clock.Now().UnixNano())
+	return f, nil
+}
+
+// PeerDead is emitted on Server.Diagnostics when a client stopped responding and was disconnected.
+type PeerDead struct {
+	// Remote is the address of the client, if known.
+	Remote string
+
+	// LastSeen is the time something was received from the client the last time.
+	LastSeen time.Time
+}
+
+func (PeerDead) EventType() string {
+	return "remote.peer-dead"
+}
+
+// Server runs a Filter for each connection.
+type Server struct {
+	// NewFilter returns the Filter for a new connection.
+	NewFilter func() voyeur.Filter
+
+	// Resume, if not nil, returns the Filter for a client reconnecting after it received the event with
+	// sequence number after. Only then the server waits for the hello frame clients start with,
+	// before calling NewFilter or Resume. See Sequenced.
+	Resume func(after uint64) voyeur.Filter
+
+	Encoder cloudevents.Encoder
+	Decoder cloudevents.Decoder
+
+	// Limit, if not nil, limits the rate of events sent back on each connection, so a slow or greedy client
+	// can't make the server buffer without bound.
+	Limit *voyeur.LimitOptions
+
+	// Authorize is called for each accepted connection, which is closed if it returns an error.
+	// It may be nil, in which case all connections are accepted. See auth.ConnAuthorizer.
+	Authorize func(net.Conn) error
+
+	// KeepAlive, if not zero, is the interval at which clients are pinged. A client that doesn't send anything
+	// for Timeout is considered dead: its connection is closed and a PeerDead event is emitted on Diagnostics.
+	KeepAlive time.Duration
+
+	// Timeout defaults to three times KeepAlive.
+	Timeout time.Duration
+
+	// Clock defaults to voyeur.SystemClock.
+	Clock voyeur.Clock
+
+	diagOnce sync.Once
+	diagEm   voyeur.Emitter
+	diagO    voyeur.Observable
+}
+
+func (s *Server) diagnostics() (voyeur.Emitter, voyeur.Observable) {
+	s.diagOnce.Do(func() {
+		s.diagEm, s.diagO = voyeur.Pair(voyeur.WithName("remote diagnostics"))
+	})
+
+	return s.diagEm, s.diagO
+}
+
+// Diagnostics returns an Observable emitting a PeerDead event whenever a dead client is disconnected.
+func (s *Server) Diagnostics() voyeur.Observable {
+	_, o := s.diagnostics()
+	return o
+}
+
+func (s *Server) clock() voyeur.Clock {
+	if s.Clock == nil {
+		return voyeur.SystemClock
+	}
+
+	return s.Clock
+}
+
+// Serve accepts connections on l and serves them until ctx is cancelled or accepting fails.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		go func() {
+			defer nc.Close()
+			if s.Authorize != nil && s.Authorize(nc) != nil {
+				return
+			}
+			s.ServeConn(ctx, nc)
+		}()
+	}
+}
+
+// ServeConn passes the events received on rw to a new Filter and sends back the events it emits,
+// until End was sent back, the connection fails, the client is found dead or ctx is cancelled.
+// For dead clients to be disconnected, rw must be an io.Closer.
+func (s *Server) ServeConn(ctx context.Context, rw io.ReadWriter) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c := newConn(rw, s.Encoder, s.Decoder, s.clock())
+	f, err := s.filter(c)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	errs := make(chan error, 1)
+	var out voyeur.Observer = voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		if err := c.send(e); err != nil {
+			select {
+			case errs <- err:
+			default:
+			}
+		}
+
+		if e == voyeur.End {
+			close(done)
+		}
+	})
+
+	if s.Limit != nil {
+		out = voyeur.Limit(out, *s.Limit)
+		defer out.(voyeur.Closer).Close()
+	}
+	f.Register(ctx, out)
+
+	go func() {
+		for {
+			e, _, err := c.recv()
+			if err != nil {
+				select {
+				case errs <- err:
+				default:
+				}
+				return
+			}
+
+			f.OnEvent(ctx, e)
+			if e == voyeur.End {
+				return
+			}
+		}
+	}()
+
+	if s.KeepAlive > 0 {
+		go s.keepAlive(ctx, c, rw, errs)
+	}
+
+	select {
+	case <-done:
+		return nil
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// filter returns the Filter for c, resuming if the client asks for it.
+func (s *Server) filter(c *conn) (voyeur.Filter, error) {
+	if s.Resume == nil {
+		return s.NewFilter(), nil
+	}
+
+	hello, err := c.recvFrame()
+	if err != nil {
+		return nil, err
+	}
+	if !hello.Hello {
+		return nil, errNoHello
+	}
+
+	if hello.Resume > 0 {
+		return s.Resume(hello.Resume), nil
+	}
+	return s.NewFilter(), nil
+}
+
+// errNoHello is returned by ServeConn when the client didn't start with a hello frame.
+var errNoHello = errors.New("remote: expected hello")
+
+// errEmptyFrame is returned by ServeConn and reported by filters when a frame carries neither an event
+// nor a control message.
+var errEmptyFrame = errors.New("remote: frame without event")
+
+// errPeerDead is returned by ServeConn when the client stopped responding.
+var errPeerDead = errors.New("remote: peer dead")
+
+// keepAlive pings the client and reports it dead if it stops responding.
+func (s *Server) keepAlive(ctx context.Context, c *conn, rw io.ReadWriter, errs chan<- error) {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 3 * s.KeepAlive
+	}
+
+	clock := s.clock()
+	t := clock.NewTicker(s.KeepAlive)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C():
+		}
+
+		lastSeen := time.Unix(0, c.lastSeen.Load())
+		if clock.Now().Sub(lastSeen) < timeout {
+			// pinging may block if the peer doesn't read, so don't hold up the check
+			go c.sendFrame(frame{Ping: true})
+			continue
+		}
+
+		// closing unblocks pending reads and writes
+		if closer, ok := rw.(io.Closer); ok {
+			closer.Close()
+		}
+
+		var remote string
+		if nc, ok := rw.(net.Conn); ok {
+			remote = nc.RemoteAddr().String()
+		}
+
+		em, _ := s.diagnostics()
+		em.Emit(ctx, PeerDead{Remote: remote, LastSeen: lastSeen})
+
+		select {
+		case errs <- errPeerDead:
+		default:
+		}
+		return
+	}
+}
+
+// filter is the local side of a remote Filter.
+type filter struct {
+	c       *conn
+	em      voyeur.Emitter
+	o       voyeur.Observable
+	onError func(error)
+}
+
+// NewFilter returns a Filter that sends the events it observes over rw to a Server and emits the events coming back.
+// If the connection fails, onError is called, if not nil, and the Filter ends. Pings of the Server are answered.
+// Use a Client to reconnect instead.
+func NewFilter(ctx context.Context, rw io.ReadWriter, enc cloudevents.Encoder, dec cloudevents.Decoder, onError func(error)) voyeur.Filter {
+	em, o := voyeur.Pair()
+	c := newConn(rw, enc, dec, voyeur.SystemClock)
+	c.hello = &frame{Hello: true}
+	f := &filter{
+		c:       c,
+		em:      em,
+		o:       o,
+		onError: onError,
+	}
+
+	go c.greet()
+	go f.run(ctx)
+	return f
+}
+
+func (f *filter) run(ctx context.Context) {
+	for {
+		e, _, err := f.c.recv()
+		if err != nil {
+			f.error(err)
+			f.em.End(ctx)
+			return
+		}
+
+		f.em.Emit(ctx, e)
+		if e == voyeur.End {
+			return
+		}
+	}
+}
+
+func (f *filter) error(err error) {
+	if f.onError != nil {
+		f.onError(err)
+	}
+}
+
+func (f *filter) OnEvent(ctx context.Context, e voyeur.Event) {
+	if err := f.c.send(e); err != nil {
+		f.error(err)
+	}
+}
+
+func (f *filter) Register(ctx context.Context, oer voyeur.Observer) {
+	f.o.Register(ctx, oer)
+}
@@ -0,0 +1,195 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package remote
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/cloudevents"
+)
+
+type word string
+
+func (word) EventType() string {
+	return "word"
+}
+
+func TestRemoteFilter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	types := voyeur.NewTypeRegistry()
+	types.Register(word(""))
+	enc := cloudevents.Encoder{Source: "test"}
+	dec := cloudevents.Decoder{Types: types}
+
+	srv := &Server{
+		NewFilter: func() voyeur.Filter {
+			return voyeur.Map(func(ctx context.Context, em voyeur.Emitter, e voyeur.Event) {
+				if w, ok := e.(word); ok {
+					e = word(strings.ToUpper(string(w)))
+				}
+				em.Emit(ctx, e)
+			})
+		},
+		Encoder: enc,
+		Decoder: dec,
+	}
+
+	local, far := net.Pipe()
+	go srv.ServeConn(ctx, far)
+
+	f := NewFilter(ctx, local, enc, dec, func(err error) { t.Error(err) })
+
+	got := make(chan voyeur.Event, 3)
+	f.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		got <- e
+	}))
+
+	f.OnEvent(ctx, word("hello"))
+	f.OnEvent(ctx, word("world"))
+	f.OnEvent(ctx, voyeur.End)
+
+	for _, exp := range []voyeur.Event{word("HELLO"), word("WORLD"), voyeur.End} {
+		select {
+		case e := <-got:
+			if e != exp {
+				t.Errorf("expected %v, got %v", exp, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %v", exp)
+		}
+	}
+}
+
+func TestDeadPeer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := &Server{
+		NewFilter: func() voyeur.Filter { return voyeur.Fwd },
+		KeepAlive: 5 * time.Millisecond,
+	}
+
+	dead := make(chan voyeur.Event, 1)
+	srv.Diagnostics().Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		dead <- e
+	}))
+
+	// the client never reads, so it never answers pings
+	_, far := net.Pipe()
+
+	served := make(chan error)
+	go func() { served <- srv.ServeConn(ctx, far) }()
+
+	select {
+	case err := <-served:
+		if err != errPeerDead {
+			t.Errorf("expected errPeerDead, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("dead peer not detected")
+	}
+
+	if e, ok := (<-dead).(PeerDead); !ok || e.Remote != "pipe" {
+		t.Errorf("unexpected diagnostics event %v", e)
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := &Server{
+		NewFilter: func() voyeur.Filter {
+			return voyeur.Map(func(ctx context.Context, em voyeur.Emitter, e voyeur.Event) {})
+		},
+		KeepAlive: 10 * time.Millisecond,
+		Timeout:   100 * time.Millisecond,
+	}
+
+	local, far := net.Pipe()
+	served := make(chan error, 1)
+	go func() { served <- srv.ServeConn(ctx, far) }()
+
+	// the client answers pings, so it stays connected although it doesn't send events
+	NewFilter(ctx, local, cloudevents.Encoder{}, cloudevents.Decoder{}, nil)
+
+	select {
+	case err := <-served:
+		t.Fatalf("live client was disconnected: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestEmptyFrame(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := &Server{
+		NewFilter: func() voyeur.Filter { return voyeur.Fwd },
+	}
+
+	local, far := net.Pipe()
+	served := make(chan error, 1)
+	go func() { served <- srv.ServeConn(ctx, far) }()
+
+	go local.Write([]byte("{}\n"))
+
+	select {
+	case err := <-served:
+		if err != errEmptyFrame {
+			t.Errorf("expected errEmptyFrame, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("empty frame not rejected")
+	}
+}
+
+func TestServeConnLimitLeak(t *testing.T) {
+	ctx := context.Background()
+	before := runtime.NumGoroutine()
+
+	srv := &Server{
+		NewFilter: func() voyeur.Filter { return voyeur.Fwd },
+		Limit:     &voyeur.LimitOptions{Interval: time.Millisecond},
+	}
+
+	for i := 0; i < 10; i++ {
+		local, far := net.Pipe()
+		served := make(chan error, 1)
+		go func() { served <- srv.ServeConn(ctx, far) }()
+
+		// the client drops without sending End
+		local.Close()
+		<-served
+	}
+
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 100 {
+			t.Fatalf("goroutines leaked: %d before, %d after serving", before, runtime.NumGoroutine())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package remote
+
+import (
+	"context"
+	"sync"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+// Stream returns a function for Server.NewFilter that streams the events of o to every client. Events sent
+// by clients are ignored, except End, which ends the stream for that client. Wrap o with voyeur.Retain to let
+// clients connecting late catch up on the last events before receiving live ones.
+func Stream(o voyeur.Observable) func() voyeur.Filter {
+	return func() voyeur.Filter {
+		return &streamFilter{o: o}
+	}
+}
+
+// streamFilter passes the events of o to the single observer of a connection.
+type streamFilter struct {
+	o voyeur.Observable
+
+	lock   sync.Mutex
+	oer    voyeur.Observer
+	cancel context.CancelFunc
+	ended  bool
+}
+
+func (f *streamFilter) Register(ctx context.Context, oer voyeur.Observer) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	f.lock.Lock()
+	f.oer, f.cancel = oer, cancel
+	f.lock.Unlock()
+
+	f.o.Register(ctx, voyeur.ObserverFunc(f.emit))
+}
+
+// emit passes e on, but nothing after End.
+func (f *streamFilter) emit(ctx context.Context, e voyeur.Event) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.ended || f.oer == nil {
+		return
+	}
+	if e == voyeur.End {
+		f.ended = true
+		f.cancel()
+	}
+
+	f.oer.OnEvent(ctx, e)
+}
+
+func (f *streamFilter) OnEvent(ctx context.Context, e voyeur.Event) {
+	if e == voyeur.End {
+		f.emit(ctx, e)
+	}
+}
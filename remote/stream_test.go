@@ -0,0 +1,82 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package remote
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"cryptoscope.co/go/voyeur"
+	"cryptoscope.co/go/voyeur/cloudevents"
+)
+
+func TestStreamCatchUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	types := voyeur.NewTypeRegistry()
+	types.Register(word(""))
+	enc := cloudevents.Encoder{Source: "test"}
+	dec := cloudevents.Decoder{Types: types}
+
+	em, o := voyeur.Pair()
+	live := voyeur.Retain(voyeur.RetainOptions{N: 2})
+	o.Register(ctx, live)
+
+	srv := &Server{
+		NewFilter: Stream(live),
+		Encoder:   enc,
+		Decoder:   dec,
+	}
+
+	em.Emit(ctx, word("a"))
+	em.Emit(ctx, word("b"))
+	em.Emit(ctx, word("c"))
+
+	local, far := net.Pipe()
+	go srv.ServeConn(ctx, far)
+
+	f := NewFilter(ctx, local, enc, dec, func(err error) { t.Error(err) })
+	got := make(chan voyeur.Event, 4)
+	f.Register(ctx, voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		got <- e
+	}))
+
+	expect := func(exp voyeur.Event) {
+		t.Helper()
+		select {
+		case e := <-got:
+			if e != exp {
+				t.Fatalf("expected %v, got %v", exp, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %v", exp)
+		}
+	}
+
+	expect(word("b"))
+	expect(word("c"))
+
+	em.Emit(ctx, word("d"))
+	expect(word("d"))
+
+	f.OnEvent(ctx, voyeur.End)
+	expect(voyeur.End)
+}
@@ -0,0 +1,179 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recorded is an event and the time it was observed at.
+type Recorded struct {
+	Time  time.Time
+	Event Event
+}
+
+// Recorder is an Observer that records all events it observes, for replaying them later using a Player.
+type Recorder struct {
+	clock Clock
+
+	lock   sync.Mutex
+	events []Recorded
+}
+
+// NewRecorder returns an empty Recorder. Only the WithClock option is used.
+func NewRecorder(opts ...Option) *Recorder {
+	return &Recorder{clock: newConfig(opts).clock}
+}
+
+// OnEvent records e.
+func (r *Recorder) OnEvent(ctx context.Context, e Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.events = append(r.events, Recorded{Time: r.clock.Now(), Event: e})
+}
+
+// Events returns the recorded events.
+func (r *Recorder) Events() []Recorded {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]Recorded(nil), r.events...)
+}
+
+// Player replays recorded events. It can be paused and moved to other positions while playing.
+type Player struct {
+	events []Recorded
+	speed  float64
+	clock  Clock
+
+	lock   sync.Mutex
+	pos    int
+	paused chan struct{}
+}
+
+// NewPlayer returns a Player for events, which must be ordered by time.
+// If speed is zero, events are replayed as fast as possible. Otherwise the time between events is the
+// original time divided by speed, i.e. 1 replays with the original timing and 10 replays ten times as fast.
+// Only the WithClock option is used.
+func NewPlayer(events []Recorded, speed float64, opts ...Option) *Player {
+	return &Player{
+		events: events,
+		speed:  speed,
+		clock:  newConfig(opts).clock,
+	}
+}
+
+// Seek moves the player to the i-th event. Negative positions seek to the first event.
+func (p *Player) Seek(i int) {
+	if i < 0 {
+		i = 0
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.pos = i
+}
+
+// SeekTime moves the player to the first event recorded at or after t.
+func (p *Player) SeekTime(t time.Time) {
+	p.Seek(sort.Search(len(p.events), func(i int) bool {
+		return !p.events[i].Time.Before(t)
+	}))
+}
+
+// Pause makes the player stop before emitting the next event, until Resume is called.
+func (p *Player) Pause() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.paused == nil {
+		p.paused = make(chan struct{})
+	}
+}
+
+// Resume continues playing after Pause.
+func (p *Player) Resume() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.paused != nil {
+		close(p.paused)
+		p.paused = nil
+	}
+}
+
+// next waits until the player is not paused and returns the current event and the one before it.
+func (p *Player) next(ctx context.Context) (prev, cur *Recorded, err error) {
+	for {
+		p.lock.Lock()
+		paused, pos := p.paused, p.pos
+		if paused == nil {
+			p.pos++
+		}
+		p.lock.Unlock()
+
+		if paused == nil {
+			if pos >= len(p.events) {
+				return nil, nil, nil
+			}
+
+			if pos > 0 {
+				prev = &p.events[pos-1]
+			}
+
+			return prev, &p.events[pos], nil
+		}
+
+		select {
+		case <-paused:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+// Play emits the events on em, starting at the current position, and returns when all events have been emitted.
+// It does not end em. Play returns early with the context's error if ctx is cancelled.
+func (p *Player) Play(ctx context.Context, em Emitter) error {
+	for {
+		prev, cur, err := p.next(ctx)
+		if err != nil || cur == nil {
+			return err
+		}
+
+		if p.speed > 0 && prev != nil {
+			d := time.Duration(float64(cur.Time.Sub(prev.Time)) / p.speed)
+			if d > 0 {
+				t := p.clock.NewTimer(d)
+				select {
+				case <-t.C():
+				case <-ctx.Done():
+					t.Stop()
+					return ctx.Err()
+				}
+			}
+		}
+
+		em.Emit(ctx, cur.Event)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func ExamplePlayer() {
+	ctx := context.Background()
+	clock := newFakeClock()
+
+	rec := NewRecorder(WithClock(clock))
+	for _, s := range []string{"a", "b", "c"} {
+		rec.OnEvent(ctx, stringEvent(s))
+		clock.Advance(time.Hour)
+	}
+
+	// skip the first event and replay as fast as possible
+	p := NewPlayer(rec.Events(), 0)
+	p.SeekTime(time.Unix(0, 0).Add(time.Minute))
+
+	em, o := Pair()
+	o.Register(ctx, printObserver{})
+	p.Play(ctx, em)
+
+	// Output:
+	// b
+	// c
+}
+
+func TestPlayerSpeed(t *testing.T) {
+	ctx := context.Background()
+	start := time.Unix(0, 0)
+	events := []Recorded{
+		{Time: start, Event: stringEvent("a")},
+		{Time: start.Add(100 * time.Millisecond), Event: stringEvent("b")},
+	}
+
+	em, o := Pair()
+	var got []time.Time
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		got = append(got, time.Now())
+	}))
+
+	if err := NewPlayer(events, 10).Play(ctx, em); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(got))
+	}
+
+	if d := got[1].Sub(got[0]); d < 10*time.Millisecond || d > 90*time.Millisecond {
+		t.Errorf("expected about 10ms between events at 10x speed, got %v", d)
+	}
+}
+
+func TestPlayerPause(t *testing.T) {
+	ctx := context.Background()
+	events := []Recorded{{Event: stringEvent("a")}, {Event: stringEvent("b")}}
+
+	em, o := Pair()
+	seen := make(chan Event, 2)
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		seen <- e
+	}))
+
+	p := NewPlayer(events, 0)
+	p.Pause()
+
+	done := make(chan error)
+	go func() { done <- p.Play(ctx, em) }()
+
+	select {
+	case e := <-seen:
+		t.Fatalf("got %v while paused", e)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	p.Resume()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != 2 {
+		t.Errorf("expected 2 events after resume, got %d", len(seen))
+	}
+}
+
+func TestPlayerSeekNegative(t *testing.T) {
+	ctx := context.Background()
+	events := []Recorded{{Event: stringEvent("a")}, {Event: stringEvent("b")}}
+
+	em, o := Pair()
+	var got []Event
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		got = append(got, e)
+	}))
+
+	p := NewPlayer(events, 0)
+	p.Seek(-1)
+	if err := p.Play(ctx, em); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 || got[0] != stringEvent("a") {
+		t.Errorf("expected to play from the start, got %v", got)
+	}
+}
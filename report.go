@@ -0,0 +1,152 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Outcomes of deliveries in a DeliveryReport.
+const (
+	ReportDelivered = "delivered"
+	ReportPanicked  = "panicked"
+)
+
+// DeliveryReport describes how an observer handled an event.
+type DeliveryReport struct {
+	// Seq identifies the event: it is the number of the event on the stream, starting at 1.
+	Seq uint64
+
+	// Type is the EventType of the event.
+	Type string
+
+	// Observer is the name of the observer, see NameOf.
+	Observer string
+
+	// Latency is the time from the delivery of the event until the observer returned. For async streams it
+	// includes the time the event waited in the mailbox of the observer.
+	Latency time.Duration
+
+	// Outcome is ReportDelivered or ReportPanicked.
+	Outcome string
+}
+
+func (DeliveryReport) EventType() string {
+	return "delivery-report"
+}
+
+// Reporter is implemented by the Emitter and Observable returned by Pair. If the stream was created
+// WithReports, ReportsObservable returns a stream with a DeliveryReport for each event and observer, which can
+// be filtered and aggregated like any other stream. Reports are emitted on their own goroutine and the stream
+// ends after the observers handled End. Otherwise, ReportsObservable returns NopObservable.
+type Reporter interface {
+	ReportsObservable() Observable
+}
+
+// reporter emits the DeliveryReports of a stream.
+type reporter struct {
+	clock Clock
+	em    Emitter
+	o     Observable
+
+	seq atomic.Uint64
+
+	// endsLeft is the number of observers that still have to handle End
+	endsLeft atomic.Int64
+}
+
+func newReporter(clock Clock) *reporter {
+	r := &reporter{clock: clock}
+	r.em, r.o = Pair(WithBuffer(64))
+	return r
+}
+
+// expectEnds sets the number of observers that End is delivered to.
+func (r *reporter) expectEnds(ctx context.Context, n int) {
+	if n == 0 {
+		r.em.End(ctx)
+		return
+	}
+
+	r.endsLeft.Store(int64(n))
+}
+
+func (r *reporter) report(d delivery, oer Observer, latency time.Duration, outcome string) {
+	r.em.Emit(d.ctx, DeliveryReport{
+		Seq:      d.seq,
+		Type:     d.e.EventType(),
+		Observer: NameOf(oer),
+		Latency:  latency,
+		Outcome:  outcome,
+	})
+
+	if d.e == End && r.endsLeft.Add(-1) == 0 {
+		r.em.End(d.ctx)
+	}
+}
+
+// observe passes d on to oer and reports it, if the stream was created WithReports.
+func (o *observable) observe(d delivery, oer Observer) {
+	if o.cfg.recover != nil {
+		defer o.recoverObserver(d)
+	}
+
+	if o.reports == nil {
+		callObserver(d.ctx, oer, d.e)
+		return
+	}
+
+	start := d.sent
+	if start.IsZero() {
+		start = o.cfg.clock.Now()
+	}
+
+	outcome := ReportPanicked
+	defer func() {
+		o.reports.report(d, oer, o.cfg.clock.Now().Sub(start), outcome)
+	}()
+
+	callObserver(d.ctx, oer, d.e)
+	outcome = ReportDelivered
+}
+
+// recoverObserver passes the panic of an observer handling d to the error handler set WithRecovery.
+func (o *observable) recoverObserver(d delivery) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	o.cfg.recover(NewErrEvent(&PanicError{Value: r}, d.e).WithStack())
+}
+
+// ReportsObservable returns the reports of the stream, see Reporter.
+func (o *observable) ReportsObservable() Observable {
+	if o.reports == nil {
+		return NopObservable
+	}
+
+	return o.reports.o
+}
+
+// ReportsObservable returns the reports of the stream, see Reporter.
+func (em *emitter) ReportsObservable() Observable {
+	return (*observable)(em).ReportsObservable()
+}
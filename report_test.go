@@ -0,0 +1,65 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// slowObserver takes a second per event.
+type slowObserver struct {
+	clock *fakeClock
+}
+
+func (o slowObserver) OnEvent(context.Context, Event) {
+	o.clock.Advance(time.Second)
+}
+
+func ExampleReporter() {
+	ctx := context.Background()
+	clock := newFakeClock()
+
+	em, o := Pair(WithReports(), WithClock(clock))
+	o.Register(ctx, slowObserver{clock})
+
+	// aggregate the reports with a filter of their own
+	var total time.Duration
+	sum := Map(func(ctx context.Context, em Emitter, e Event) {
+		if r, ok := e.(DeliveryReport); ok {
+			fmt.Println(r.Seq, r.Type, r.Observer, r.Latency, r.Outcome)
+			total += r.Latency
+		}
+	})
+	reports := o.(Reporter).ReportsObservable()
+	reports.Register(ctx, sum)
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("b"))
+	em.End(ctx)
+
+	<-WaitAll(ctx, sum)
+	fmt.Println("total", total)
+
+	// Output:
+	// 1 string voyeur.slowObserver 1s delivered
+	// 2 string voyeur.slowObserver 1s delivered
+	// 3 End voyeur.slowObserver 1s delivered
+	// total 3s
+}
@@ -0,0 +1,172 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+)
+
+// RetainOptions configure Retain.
+type RetainOptions struct {
+	// N is the number of events kept, per key if Key is set.
+	N int
+
+	// Key, if not nil, returns the key of an event. Then the last N events of each key are kept.
+	// Use ByType to keep the last events of each type.
+	Key func(Event) string
+
+	// Evict, if not nil, picks the event that is dropped when there are more than N, by its index in kept,
+	// which is ordered from oldest to newest. By default, or if Evict returns an index out of range, the oldest
+	// event is dropped.
+	// See EvictLowestPriority.
+	Evict func(kept []Event) int
+}
+
+// ByType returns the type of e. Use it as the Key of RetainOptions.
+func ByType(e Event) string {
+	return e.EventType()
+}
+
+// EvictLowestPriority picks the oldest of the events with the lowest priority, see PriorityOf.
+// Use it as the Evict function of RetainOptions, to keep important events longer.
+func EvictLowestPriority(kept []Event) int {
+	evict := 0
+	for i, e := range kept {
+		if PriorityOf(e) < PriorityOf(kept[evict]) {
+			evict = i
+		}
+	}
+
+	return evict
+}
+
+type retainer struct {
+	opts RetainOptions
+	em   Emitter
+	o    *observable
+
+	// emitLock makes sure no event is emitted while a new observer catches up
+	emitLock sync.Mutex
+	events   []Event
+	ended    bool
+}
+
+// Retain returns a Filter that forwards events and keeps the last ones, which it passes to each newly
+// registered observer before the live events. That way late observers, e.g. clients connecting to a served
+// stream, catch up. No event is missed or seen twice. If the Filter ended, late observers get End after
+// catching up.
+func Retain(opts RetainOptions, options ...Option) Filter {
+	em, o := Pair(options...)
+	return &retainer{opts: opts, em: em, o: o.(*observable)}
+}
+
+// Replay returns a Filter that keeps the last n events and replays them to late observers, like a ReplaySubject.
+// It is a shorthand for Retain with only N set.
+func Replay(n int, options ...Option) Filter {
+	return Retain(RetainOptions{N: n}, options...)
+}
+
+func (r *retainer) OnEvent(ctx context.Context, e Event) {
+	r.emitLock.Lock()
+	defer r.emitLock.Unlock()
+
+	if e == End {
+		r.ended = true
+	} else {
+		r.retain(e)
+	}
+
+	r.em.Emit(ctx, e)
+}
+
+// retain adds e to the kept events and drops one if there are too many now.
+func (r *retainer) retain(e Event) {
+	if r.opts.N <= 0 {
+		return
+	}
+
+	r.events = append(r.events, e)
+
+	if r.opts.Key == nil && r.opts.Evict == nil {
+		if len(r.events) > r.opts.N {
+			r.events = append(r.events[:0], r.events[len(r.events)-r.opts.N:]...)
+		}
+		return
+	}
+
+	// the events that count against the same limit as e
+	idx := make([]int, 0, len(r.events))
+	if r.opts.Key == nil {
+		for i := range r.events {
+			idx = append(idx, i)
+		}
+	} else {
+		key := r.opts.Key(e)
+		for i, kept := range r.events {
+			if r.opts.Key(kept) == key {
+				idx = append(idx, i)
+			}
+		}
+	}
+	if len(idx) <= r.opts.N {
+		return
+	}
+
+	evict := 0
+	if r.opts.Evict != nil {
+		group := make([]Event, len(idx))
+		for i, j := range idx {
+			group[i] = r.events[j]
+		}
+		if i := r.opts.Evict(group); i >= 0 && i < len(group) {
+			evict = i
+		}
+	}
+
+	i := idx[evict]
+	r.events = append(r.events[:i], r.events[i+1:]...)
+}
+
+func (r *retainer) Register(ctx context.Context, oer Observer) {
+	r.emitLock.Lock()
+	defer r.emitLock.Unlock()
+
+	// events still queued by streams created WithBuffer or WithFairness are kept already,
+	// so they must be delivered before oer is registered
+	r.o.flush()
+
+	for _, e := range r.events {
+		oer.OnEvent(ctx, e)
+	}
+
+	if r.ended {
+		oer.OnEvent(ctx, End)
+		return
+	}
+
+	r.o.Register(ctx, oer)
+}
+
+func (r *retainer) Name() string {
+	return r.o.Name()
+}
+
+func (r *retainer) String() string {
+	return nameOr(r.o.Name(), "retain", r)
+}
@@ -0,0 +1,159 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func ExampleRetain() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	r := Retain(RetainOptions{N: 2})
+	o.Register(ctx, r)
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("b"))
+	em.Emit(ctx, stringEvent("c"))
+
+	// catches up on the last two events
+	r.Register(ctx, printObserver{})
+
+	em.Emit(ctx, stringEvent("d"))
+
+	// Output:
+	// b
+	// c
+	// d
+}
+
+func ExampleRetain_key() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	// keep the last event per type
+	r := Retain(RetainOptions{N: 1, Key: ByType})
+	o.Register(ctx, r)
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, intEvent(1))
+	em.Emit(ctx, intEvent(2))
+	em.Emit(ctx, End)
+
+	r.Register(ctx, printObserver{})
+
+	// Output:
+	// a
+	// 2
+	// End
+}
+
+func ExampleReplay() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	r := Replay(1)
+	o.Register(ctx, r)
+
+	em.Emit(ctx, stringEvent("state 1"))
+	em.Emit(ctx, stringEvent("state 2"))
+	em.End(ctx)
+
+	// late observers get the latest state, and End
+	r.Register(ctx, printObserver{})
+
+	// Output:
+	// state 2
+	// End
+}
+
+func ExampleEvictLowestPriority() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	r := Retain(RetainOptions{N: 2, Evict: EvictLowestPriority})
+	o.Register(ctx, r)
+
+	em.Emit(ctx, NewEvent("alert").WithPriority(High).Build())
+	em.Emit(ctx, NewEvent("tick 1").WithPriority(Low).Build())
+	em.Emit(ctx, NewEvent("tick 2").WithPriority(Low).Build())
+
+	// the alert outlives the older tick
+	r.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println(e.EventType(), PriorityOf(e))
+	}))
+
+	// Output:
+	// alert high
+	// tick 2 low
+}
+
+func TestRetainBuffered(t *testing.T) {
+	ctx := context.Background()
+	r := Retain(RetainOptions{N: 8}, WithBuffer(16))
+
+	// keep the events queued until the late observer registers
+	release := make(chan struct{})
+	r.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		<-release
+	}))
+
+	for i := 0; i < 4; i++ {
+		r.OnEvent(ctx, intEvent(i))
+	}
+
+	var seen []string
+	var lock sync.Mutex
+	go close(release)
+	r.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		lock.Lock()
+		seen = append(seen, fmt.Sprint(e))
+		lock.Unlock()
+	}))
+
+	r.OnEvent(ctx, intEvent(4))
+	r.(*retainer).o.flush()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if got := strings.Join(seen, " "); got != "0 1 2 3 4" {
+		t.Fatalf("got events %q, want each once", got)
+	}
+}
+
+func TestRetainEvictOutOfRange(t *testing.T) {
+	ctx := context.Background()
+	r := Retain(RetainOptions{N: 2, Evict: func(kept []Event) int { return len(kept) }})
+
+	for i := 0; i < 3; i++ {
+		r.OnEvent(ctx, intEvent(i))
+	}
+
+	var seen []string
+	r.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		seen = append(seen, fmt.Sprint(e))
+	}))
+	if got := strings.Join(seen, " "); got != "1 2" {
+		t.Fatalf("got events %q, want the oldest dropped", got)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+)
+
+// Router is an Observer that dispatches events to handlers by their Go type.
+// Register it on an Observable and add handlers using Handle.
+type Router struct {
+	lock     sync.RWMutex
+	handlers []func(context.Context, Event) bool
+}
+
+// NewRouter returns a Router without handlers.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle makes r call fn for every event that is a T.
+// If T is an interface type, fn is called for all events implementing it.
+func Handle[T Event](r *Router, fn func(context.Context, T)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.handlers = append(r.handlers, func(ctx context.Context, e Event) bool {
+		t, ok := e.(T)
+		if ok {
+			fn(ctx, t)
+		}
+		return ok
+	})
+}
+
+// OnEvent calls the handlers matching the type of e. Events without matching handler are dropped.
+func (r *Router) OnEvent(ctx context.Context, e Event) {
+	r.lock.RLock()
+	handlers := r.handlers
+	r.lock.RUnlock()
+
+	for _, h := range handlers {
+		h(ctx, e)
+	}
+}
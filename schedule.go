@@ -0,0 +1,206 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// Scheduler is an Emitter that can also emit events in the future. Scheduled events are kept in a heap
+// and fired by a single timer of the Clock, so many of them cost little.
+type Scheduler struct {
+	em    Emitter
+	clock Clock
+
+	lock    sync.Mutex
+	pending scheduleHeap
+	seq     uint64
+	end     context.Context
+	wake    chan struct{}
+}
+
+// scheduled is an event waiting to be emitted.
+type scheduled struct {
+	at  time.Time
+	seq uint64
+	d   delivery
+
+	// index is the position in the heap, or -1 once the event was emitted or cancelled
+	index int
+}
+
+// NewScheduler returns a Scheduler emitting on em. Only the WithClock option is used.
+func NewScheduler(em Emitter, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		em:    em,
+		clock: newConfig(opts).clock,
+		wake:  make(chan struct{}, 1),
+	}
+
+	go s.run()
+	return s
+}
+
+// Emit emits e right away. End is deferred until all scheduled events were emitted, see End.
+func (s *Scheduler) Emit(ctx context.Context, e Event) {
+	if e == End {
+		s.End(ctx)
+		return
+	}
+
+	s.em.Emit(ctx, e)
+}
+
+// End ends the Emitter after all scheduled events were emitted. Events scheduled afterwards are dropped.
+func (s *Scheduler) End(ctx context.Context) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.end == nil {
+		s.end = ctx
+		s.notify()
+	}
+}
+
+// EmitAt emits e at t, or right away if t has passed. The returned function cancels the emission and reports
+// whether it was still pending.
+func (s *Scheduler) EmitAt(ctx context.Context, e Event, t time.Time) (cancel func() bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.end != nil {
+		return func() bool { return false }
+	}
+
+	s.seq++
+	item := &scheduled{at: t, seq: s.seq, d: delivery{ctx: ctx, e: e}}
+	heap.Push(&s.pending, item)
+	if item.index == 0 {
+		s.notify()
+	}
+
+	return func() bool {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		if item.index < 0 {
+			return false
+		}
+
+		heap.Remove(&s.pending, item.index)
+		return true
+	}
+}
+
+// EmitAfter emits e after d, see EmitAt.
+func (s *Scheduler) EmitAfter(ctx context.Context, e Event, d time.Duration) (cancel func() bool) {
+	return s.EmitAt(ctx, e, s.clock.Now().Add(d))
+}
+
+// Pending returns the number of scheduled events.
+func (s *Scheduler) Pending() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.pending)
+}
+
+// notify wakes run. s.lock must be held.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next pops the next event if it is due. Otherwise it returns how long to wait, or zero to wait for a change.
+func (s *Scheduler) next() (d *delivery, wait time.Duration, end context.Context) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.pending) == 0 {
+		return nil, 0, s.end
+	}
+
+	item := s.pending[0]
+	if wait := item.at.Sub(s.clock.Now()); wait > 0 {
+		return nil, wait, nil
+	}
+
+	heap.Pop(&s.pending)
+	return &item.d, 0, nil
+}
+
+func (s *Scheduler) run() {
+	for {
+		d, wait, end := s.next()
+		switch {
+		case d != nil:
+			s.em.Emit(d.ctx, d.e)
+		case end != nil:
+			s.em.End(end)
+			return
+		case wait == 0:
+			<-s.wake
+		default:
+			t := s.clock.NewTimer(wait)
+			select {
+			case <-t.C():
+			case <-s.wake:
+				t.Stop()
+			}
+		}
+	}
+}
+
+// scheduleHeap orders scheduled events by time and the order they were scheduled in.
+type scheduleHeap []*scheduled
+
+func (h scheduleHeap) Len() int {
+	return len(h)
+}
+
+func (h scheduleHeap) Less(i, j int) bool {
+	if !h[i].at.Equal(h[j].at) {
+		return h[i].at.Before(h[j].at)
+	}
+
+	return h[i].seq < h[j].seq
+}
+
+func (h scheduleHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *scheduleHeap) Push(x interface{}) {
+	item := x.(*scheduled)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *scheduleHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	item.index = -1
+	*h = old[:len(old)-1]
+	return item
+}
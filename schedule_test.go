@@ -0,0 +1,62 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+func ExampleScheduler() {
+	ctx := context.Background()
+	clock := newFakeClock()
+
+	em, o := Pair()
+	done := make(chan struct{})
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		fmt.Println(e)
+		if e == End {
+			close(done)
+		}
+	}))
+
+	s := NewScheduler(em, WithClock(clock))
+	s.EmitAfter(ctx, stringEvent("reminder"), 2*time.Second)
+	s.EmitAt(ctx, stringEvent("deadline"), time.Unix(3, 0))
+	cancel := s.EmitAfter(ctx, stringEvent("timeout"), time.Second)
+	s.Emit(ctx, stringEvent("now"))
+
+	// the job finished in time
+	cancel()
+
+	// End waits for the scheduled events
+	s.End(ctx)
+	for s.Pending() > 0 {
+		clock.Advance(time.Second)
+		runtime.Gosched()
+	}
+	<-done
+
+	// Output:
+	// now
+	// reminder
+	// deadline
+	// End
+}
@@ -0,0 +1,164 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// ChangeOp says what kind of change a ChangeEvent describes.
+type ChangeOp int
+
+const (
+	// ChangeSet means the key was set to a value.
+	ChangeSet ChangeOp = iota
+	// ChangeDelete means the key was deleted.
+	ChangeDelete
+)
+
+func (op ChangeOp) String() string {
+	switch op {
+	case ChangeSet:
+		return "set"
+	case ChangeDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// ChangeEvent is emitted by a Store when a key is changed.
+type ChangeEvent[V any] struct {
+	Op    ChangeOp
+	Key   string
+	Value V
+
+	// Prev is the value before the change, if HadPrev is true.
+	Prev    V
+	HadPrev bool
+}
+
+func (ChangeEvent[V]) EventType() string {
+	return "change"
+}
+
+// Store is an observable key-value store. Every change is emitted as a ChangeEvent to its observers.
+// Observers must not change the store from within OnEvent.
+type Store[V any] struct {
+	// emitLock makes sure changes are emitted in the order they are applied
+	emitLock sync.Mutex
+
+	lock sync.RWMutex
+	data map[string]V
+
+	em Emitter
+	o  Observable
+}
+
+// NewStore returns an empty Store. The options configure the stream of changes.
+func NewStore[V any](opts ...Option) *Store[V] {
+	em, o := Pair(opts...)
+	return &Store[V]{
+		data: make(map[string]V),
+		em:   em,
+		o:    o,
+	}
+}
+
+// Get returns the value stored under key.
+func (s *Store[V]) Get(key string) (V, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	v, ok := s.data[key]
+	return v, ok
+}
+
+// Snapshot returns a copy of the contents of the store.
+func (s *Store[V]) Snapshot() map[string]V {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	m := make(map[string]V, len(s.data))
+	for k, v := range s.data {
+		m[k] = v
+	}
+
+	return m
+}
+
+// Set stores v under key and emits the change.
+func (s *Store[V]) Set(ctx context.Context, key string, v V) {
+	s.emitLock.Lock()
+	defer s.emitLock.Unlock()
+
+	s.lock.Lock()
+	prev, hadPrev := s.data[key]
+	s.data[key] = v
+	s.lock.Unlock()
+
+	s.em.Emit(ctx, ChangeEvent[V]{Op: ChangeSet, Key: key, Value: v, Prev: prev, HadPrev: hadPrev})
+}
+
+// Delete deletes key and emits the change. Deleting a key that is not set emits nothing.
+func (s *Store[V]) Delete(ctx context.Context, key string) {
+	s.emitLock.Lock()
+	defer s.emitLock.Unlock()
+
+	s.lock.Lock()
+	prev, hadPrev := s.data[key]
+	delete(s.data, key)
+	s.lock.Unlock()
+
+	if hadPrev {
+		s.em.Emit(ctx, ChangeEvent[V]{Op: ChangeDelete, Key: key, Prev: prev, HadPrev: true})
+	}
+}
+
+// Register registers oer for changes made from now on.
+func (s *Store[V]) Register(ctx context.Context, oer Observer) {
+	s.o.Register(ctx, oer)
+}
+
+// RegisterSnapshot first passes oer a ChangeSet event for every key in the store, in lexical order,
+// and then registers it for the changes made after that. No change is missed or seen twice.
+func (s *Store[V]) RegisterSnapshot(ctx context.Context, oer Observer) {
+	s.emitLock.Lock()
+	defer s.emitLock.Unlock()
+
+	// changes still queued by streams created WithBuffer or WithFairness are part of the snapshot already,
+	// so they must be delivered before oer is registered
+	if f, ok := s.o.(interface{ flush() }); ok {
+		f.flush()
+	}
+
+	snap := s.Snapshot()
+	keys := make([]string, 0, len(snap))
+	for k := range snap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		oer.OnEvent(ctx, ChangeEvent[V]{Op: ChangeSet, Key: k, Value: snap[k]})
+	}
+
+	s.o.Register(ctx, oer)
+}
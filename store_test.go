@@ -0,0 +1,84 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func ExampleStore() {
+	ctx := context.Background()
+	s := NewStore[int]()
+
+	s.Set(ctx, "b", 2)
+	s.Set(ctx, "a", 1)
+
+	s.RegisterSnapshot(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		ch := e.(ChangeEvent[int])
+		fmt.Println(ch.Op, ch.Key, ch.Value)
+	}))
+
+	s.Set(ctx, "a", 3)
+	s.Delete(ctx, "b")
+	s.Delete(ctx, "c")
+
+	// Output:
+	// set a 1
+	// set b 2
+	// set a 3
+	// delete b 0
+}
+
+func TestStoreRegisterSnapshotBuffered(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore[int](WithBuffer(16))
+
+	// keep the changes queued until the snapshot is being taken
+	release := make(chan struct{})
+	s.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		<-release
+	}))
+
+	for i := 0; i < 4; i++ {
+		s.Set(ctx, fmt.Sprint(i), i)
+	}
+
+	var seen []string
+	var lock sync.Mutex
+	go close(release)
+	s.RegisterSnapshot(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if change, ok := e.(ChangeEvent[int]); ok {
+			lock.Lock()
+			seen = append(seen, change.Key)
+			lock.Unlock()
+		}
+	}))
+
+	s.Set(ctx, "4", 4)
+	s.o.(*observable).flush()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if got := strings.Join(seen, " "); got != "0 1 2 3 4" {
+		t.Fatalf("got changes %q, want each key once", got)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"errors"
+)
+
+// ErrObserverExited is reported by streams created WithSupervisor for observers that ended the goroutine
+// they were called on, e.g. using runtime.Goexit.
+var ErrObserverExited = errors.New("voyeur: observer exited its goroutine")
+
+// supervisor calls observers on a worker goroutine, and replaces the worker if an observer ends it.
+type supervisor struct {
+	jobs    chan func()
+	onError func(error)
+}
+
+func newSupervisor(onError func(error)) *supervisor {
+	s := &supervisor{
+		jobs:    make(chan func()),
+		onError: onError,
+	}
+
+	go s.run()
+	return s
+}
+
+// run keeps a worker running until stop was called.
+func (s *supervisor) run() {
+	for {
+		exited := make(chan bool)
+		go s.work(exited)
+		if !<-exited {
+			return
+		}
+	}
+}
+
+// work runs jobs until stop was called, and then reports false on exited. If a job ends the goroutine,
+// it reports true instead.
+func (s *supervisor) work(exited chan<- bool) {
+	clean := false
+	defer func() {
+		exited <- !clean
+	}()
+
+	for job := range s.jobs {
+		job()
+	}
+	clean = true
+}
+
+// observe calls o.observe(d, oer) on the worker and waits for it to return.
+func (s *supervisor) observe(o *observable, d delivery, oer Observer) {
+	done, returned := make(chan struct{}), false
+	s.jobs <- func() {
+		defer close(done)
+		o.observe(d, oer)
+		returned = true
+	}
+	<-done
+
+	if !returned {
+		s.onError(NewErrEvent(ErrObserverExited, d.e))
+	}
+}
+
+// stop ends the worker once it is idle.
+func (s *supervisor) stop() {
+	close(s.jobs)
+}
@@ -0,0 +1,99 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+func ExampleWithSupervisor() {
+	ctx := context.Background()
+
+	errs, eo := Pair()
+	eo.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if errors.Is(e.(ErrEvent), ErrObserverExited) {
+			fmt.Println("observer exited while handling", e.(ErrEvent).Event)
+		}
+	}))
+
+	em, o := Pair(WithSupervisor(ErrorsTo(ctx, errs)))
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if e == stringEvent("b") {
+			runtime.Goexit()
+		}
+	}))
+	o.Register(ctx, printObserver{})
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("b"))
+	em.Emit(ctx, stringEvent("c"))
+
+	// Output:
+	// a
+	// observer exited while handling b
+	// b
+	// c
+}
+
+func TestSupervisorContainsCrashes(t *testing.T) {
+	ctx := context.Background()
+
+	var reported []error
+	em, o := Pair(WithSupervisor(func(err error) {
+		reported = append(reported, err)
+	}))
+
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		switch e {
+		case stringEvent("panic"):
+			panic("boom")
+		case stringEvent("exit"):
+			runtime.Goexit()
+		}
+	}))
+
+	var seen []Event
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		seen = append(seen, e)
+	}))
+
+	for _, e := range []stringEvent{"a", "panic", "b", "exit", "c"} {
+		em.Emit(ctx, e)
+	}
+	em.End(ctx)
+
+	if fmt.Sprint(seen) != "[a panic b exit c End]" {
+		t.Errorf("other observer missed events: %v", seen)
+	}
+
+	if len(reported) != 2 {
+		t.Fatalf("expected 2 errors, got %v", reported)
+	}
+
+	var pe *PanicError
+	if !errors.As(reported[0], &pe) || pe.Value != "boom" {
+		t.Errorf("expected panic to be reported, got %v", reported[0])
+	}
+	if !errors.Is(reported[1], ErrObserverExited) {
+		t.Errorf("expected exit to be reported, got %v", reported[1])
+	}
+}
@@ -0,0 +1,138 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Outcomes of events recorded in a TraceEntry.
+const (
+	// TraceDelivered means the event was passed to the observers, or to their mailboxes if the stream is async.
+	TraceDelivered = "delivered"
+
+	// TraceDiscarded means the event was discarded because of a preemptive End, see WithPreemptiveEnd.
+	TraceDiscarded = "discarded"
+
+	// TraceDoubleEnd means End was emitted again and ignored.
+	TraceDoubleEnd = "double end"
+)
+
+// TraceEntry summarizes an event that went through a stream.
+type TraceEntry struct {
+	Time time.Time
+
+	// Type is the EventType of the event.
+	Type string
+
+	// Observers is the number of observers registered when the event was delivered.
+	Observers int
+
+	// Outcome is one of the Trace constants, or "panic: " and the panic value if an observer panicked.
+	Outcome string
+}
+
+func (te TraceEntry) String() string {
+	return fmt.Sprintf("%s %s %s (%d observers)", te.Time.Format("15:04:05.000000"), te.Type, te.Outcome, te.Observers)
+}
+
+// Tracer is implemented by the Emitter and Observable returned by Pair. Unless tracing was turned off using
+// WithTrace(0), Trace returns the entries of the last events, oldest first. If an observer panics, also in an
+// async stream, they are also written to stderr before the panic continues, to show what happened right before
+// the crash.
+type Tracer interface {
+	Trace() []TraceEntry
+}
+
+// DumpTrace writes the trace of t to w, one entry per line.
+func DumpTrace(w io.Writer, t Tracer) error {
+	for _, te := range t.Trace() {
+		if _, err := fmt.Fprintln(w, te); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// traceRing keeps the last entries of a trace.
+type traceRing struct {
+	clock Clock
+
+	lock    sync.Mutex
+	entries []TraceEntry
+	next    int
+}
+
+func newTraceRing(n int, clock Clock) *traceRing {
+	return &traceRing{clock: clock, entries: make([]TraceEntry, 0, n)}
+}
+
+func (r *traceRing) add(e Event, observers int, outcome string) {
+	te := TraceEntry{Time: r.clock.Now(), Type: e.EventType(), Observers: observers, Outcome: outcome}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.entries) < cap(r.entries) {
+		r.entries = append(r.entries, te)
+		return
+	}
+
+	r.entries[r.next] = te
+	r.next = (r.next + 1) % len(r.entries)
+}
+
+func (r *traceRing) trace() []TraceEntry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append(append([]TraceEntry(nil), r.entries[r.next:]...), r.entries[:r.next]...)
+}
+
+// Trace returns the trace of the stream, see Tracer.
+func (o *observable) Trace() []TraceEntry {
+	if o.trace == nil {
+		return nil
+	}
+
+	return o.trace.trace()
+}
+
+// Trace returns the trace of the stream, see Tracer.
+func (em *emitter) Trace() []TraceEntry {
+	return (*observable)(em).Trace()
+}
+
+// dumpPanic records that an observer of e panicked with r, and writes the trace to stderr.
+func (o *observable) dumpPanic(e Event, observers int, r interface{}) {
+	o.trace.add(e, observers, fmt.Sprint("panic: ", r))
+	fmt.Fprintf(os.Stderr, "voyeur: observer of %v panicked, last events:\n", o)
+	DumpTrace(os.Stderr, o)
+}
+
+// traceDiscarded records that e was discarded, if the stream is traced.
+func (o *observable) traceDiscarded(e Event) {
+	if o.trace != nil {
+		o.trace.add(e, 0, TraceDiscarded)
+	}
+}
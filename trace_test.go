@@ -0,0 +1,127 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ExampleWithTrace() {
+	ctx := context.Background()
+	clock := newFakeClock()
+
+	em, o := Pair(WithTrace(3), WithClock(clock))
+	o.Register(ctx, ObserverFunc(func(context.Context, Event) {}))
+
+	for _, word := range []string{"a", "b", "c", "d"} {
+		em.Emit(ctx, stringEvent(word))
+		clock.Advance(time.Second)
+	}
+	em.End(ctx)
+	em.End(ctx)
+
+	for _, te := range em.(Tracer).Trace() {
+		fmt.Println(te.Time.Unix(), te.Type, te.Outcome, te.Observers)
+	}
+
+	// Output:
+	// 3 string delivered 1
+	// 4 End delivered 1
+	// 4 End double end 0
+}
+
+func TestTracePanic(t *testing.T) {
+	ctx := context.Background()
+
+	em, o := Pair(WithTrace(10))
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if e == stringEvent("boom") {
+			panic("boom")
+		}
+	}))
+
+	em.Emit(ctx, stringEvent("ok"))
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected panic to continue, got %v", r)
+			}
+		}()
+		em.Emit(ctx, stringEvent("boom"))
+	}()
+
+	trace := o.(Tracer).Trace()
+	if len(trace) != 2 || trace[0].Outcome != TraceDelivered || trace[1].Outcome != "panic: boom" {
+		t.Errorf("unexpected trace %v", trace)
+	}
+}
+
+func TestTraceDefault(t *testing.T) {
+	ctx := context.Background()
+
+	em, o := Pair()
+	for i := 0; i < 20; i++ {
+		em.Emit(ctx, intEvent(i))
+	}
+	if n := len(o.(Tracer).Trace()); n != defaultTrace {
+		t.Errorf("expected %d entries by default, got %d", defaultTrace, n)
+	}
+
+	em, o = Pair(WithTrace(0))
+	em.Emit(ctx, intEvent(0))
+	if trace := o.(Tracer).Trace(); trace != nil {
+		t.Errorf("expected no trace, got %v", trace)
+	}
+}
+
+func TestTracePanicAsync(t *testing.T) {
+	// the panic crashes the process, so it happens in a child
+	if os.Getenv("VOYEUR_TRACE_CRASH") == "1" {
+		ctx := context.Background()
+		em, o := Pair(WithName("crashing"), WithAsync())
+		o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+			if e == stringEvent("boom") {
+				panic("boom")
+			}
+		}))
+
+		em.Emit(ctx, stringEvent("ok"))
+		em.Emit(ctx, stringEvent("boom"))
+		time.Sleep(time.Second)
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestTracePanicAsync$")
+	cmd.Env = append(os.Environ(), "VOYEUR_TRACE_CRASH=1")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatal("expected the panic to crash the process")
+	}
+
+	for _, exp := range []string{"observer of crashing panicked", "string panic: boom"} {
+		if !strings.Contains(string(out), exp) {
+			t.Errorf("expected %q in the output:\n%s", exp, out)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"reflect"
+)
+
+// Into returns an Observable that only passes on events of o that are a T, and End.
+func Into[T Event](o Observable) Observable {
+	return into[T]{o}
+}
+
+type into[T Event] struct {
+	o Observable
+}
+
+func (in into[T]) Register(ctx context.Context, oer Observer) {
+	in.o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if _, ok := e.(T); ok || e == End {
+			oer.OnEvent(ctx, e)
+		}
+	}))
+}
+
+// MapT returns a Filter that calls fn for each observed event that is a T and emits the result if fn returns true.
+// Events that are not a T are dropped, like in Into. End is passed on.
+func MapT[T, U Event](fn func(context.Context, T) (U, bool), opts ...Option) Filter {
+	f := Map(func(ctx context.Context, em Emitter, e Event) {
+		if e == End {
+			em.Emit(ctx, e)
+			return
+		}
+
+		t, ok := e.(T)
+		if !ok {
+			return
+		}
+
+		if u, ok := fn(ctx, t); ok {
+			em.Emit(ctx, u)
+		}
+	}, opts...)
+
+	m := mapFilterOf(f)
+	m.in, m.out = typeOf[T](), typeOf[U]()
+
+	return f
+}
+
+// typeOf returns the type T, also if it is an interface.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// ObserverT is an Observer of events of type T. OnEnd is called when the stream ends.
+type ObserverT[T Event] interface {
+	OnEvent(context.Context, T)
+	OnEnd(context.Context)
+}
+
+// ObserverFuncT is an ObserverT that ignores End.
+type ObserverFuncT[T Event] func(context.Context, T)
+
+func (fn ObserverFuncT[T]) OnEvent(ctx context.Context, e T) {
+	fn(ctx, e)
+}
+
+func (fn ObserverFuncT[T]) OnEnd(context.Context) {}
+
+// ObservableT emits events of type T.
+type ObservableT[T Event] interface {
+	Register(context.Context, ObserverT[T])
+}
+
+// EmitterT emits events of type T.
+type EmitterT[T Event] interface {
+	Emit(context.Context, T)
+	End(context.Context)
+}
+
+// PairT is like Pair, but only takes and passes on events of type T, so observers need no type assertions.
+func PairT[T Event](opts ...Option) (EmitterT[T], ObservableT[T]) {
+	em, o := Pair(opts...)
+	return typedEmitter[T]{em}, Typed[T](o)
+}
+
+// Typed returns an ObservableT that passes on the events of o that are a T, like Into.
+func Typed[T Event](o Observable) ObservableT[T] {
+	return typedObservable[T]{o}
+}
+
+type typedEmitter[T Event] struct {
+	em Emitter
+}
+
+func (em typedEmitter[T]) Emit(ctx context.Context, e T) {
+	em.em.Emit(ctx, e)
+}
+
+func (em typedEmitter[T]) End(ctx context.Context) {
+	em.em.End(ctx)
+}
+
+type typedObservable[T Event] struct {
+	o Observable
+}
+
+func (o typedObservable[T]) Register(ctx context.Context, oer ObserverT[T]) {
+	o.o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if e == End {
+			oer.OnEnd(ctx)
+		} else if t, ok := e.(T); ok {
+			oer.OnEvent(ctx, t)
+		}
+	}))
+}
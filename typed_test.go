@@ -0,0 +1,112 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type intEvent int
+
+func (intEvent) EventType() string {
+	return "int"
+}
+
+func ExampleInto() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	Into[stringEvent](o).Register(ctx, printObserver{})
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, intEvent(1))
+	em.Emit(ctx, stringEvent("b"))
+	em.End(ctx)
+
+	// Output:
+	// a
+	// b
+	// End
+}
+
+func ExampleMapT() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	upper := MapT(func(ctx context.Context, e stringEvent) (stringEvent, bool) {
+		return stringEvent(strings.ToUpper(string(e))), e != ""
+	})
+
+	o.Register(ctx, upper)
+	upper.Register(ctx, printObserver{})
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, intEvent(1))
+	em.Emit(ctx, stringEvent(""))
+	em.Emit(ctx, stringEvent("b"))
+
+	// Output:
+	// A
+	// B
+}
+
+func ExamplePairT() {
+	ctx := context.Background()
+	em, o := PairT[intEvent]()
+
+	sum := 0
+	o.Register(ctx, ObserverFuncT[intEvent](func(ctx context.Context, e intEvent) {
+		sum += int(e)
+	}))
+
+	em.Emit(ctx, 1)
+	em.Emit(ctx, 2)
+	em.End(ctx)
+
+	fmt.Println(sum)
+
+	// Output:
+	// 3
+}
+
+type endObserver struct {
+	ObserverFuncT[stringEvent]
+}
+
+func (endObserver) OnEnd(context.Context) {
+	fmt.Println("end")
+}
+
+func ExampleTyped() {
+	ctx := context.Background()
+	em, o := Pair()
+
+	Typed[stringEvent](o).Register(ctx, endObserver{func(ctx context.Context, e stringEvent) {
+		fmt.Println(strings.ToUpper(string(e)))
+	}})
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, intEvent(1))
+	em.End(ctx)
+
+	// Output:
+	// A
+	// end
+}
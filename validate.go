@@ -0,0 +1,173 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ValidationError lists the problems found by Validate.
+type ValidationError struct {
+	Problems []string
+}
+
+func (err *ValidationError) Error() string {
+	return "voyeur: invalid pipeline: " + strings.Join(err.Problems, "; ")
+}
+
+// Validate checks the stages of p for common mistakes: stages that are used twice, which makes events go in
+// circles, typed stages created using MapT that can't get the events the stage before them emits, and stages
+// that only emit once their input ended, like Reduce, while neither the source nor a stage before them, like
+// Take, ends on its own. Validate can't tell whether a stream ends, so the last is also reported for sources
+// that are ended by their emitter or pipelines ended using Shutdown.
+// It returns a *ValidationError listing the problems, or nil.
+func Validate(p *Pipeline) error {
+	var problems []string
+
+	for i, stage := range p.stages {
+		for j := 0; j < i; j++ {
+			if sameStage(p.stages[j], stage) {
+				problems = append(problems, fmt.Sprintf("stage %d (%s) is stage %d again, events go in circles", i, NameOf(stage), j))
+				break
+			}
+		}
+	}
+
+	var prev *mapFilter
+	for i, stage := range p.stages {
+		m := typedStage(stage)
+		if m != nil && prev != nil && !accepts(m.in, prev.out) {
+			problems = append(problems, fmt.Sprintf("stage %d takes %v, but stage %d emits %v", i, m.in, i-1, prev.out))
+		}
+		prev = m
+	}
+
+	ends := endsOnItsOwn(p.from)
+	for i, stage := range p.stages {
+		if m := mapFilterOf(stage); m != nil && m.waits && !ends {
+			problems = append(problems, fmt.Sprintf("stage %d (%s) only emits once its input ends, but nothing before it ends on its own", i, NameOf(stage)))
+		}
+		ends = ends || endsOnItsOwn(stage)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// sameStage returns whether a and b are the same filter. Filters that can't be compared, e.g. because they are
+// funcs or structs holding funcs, are never the same.
+func sameStage(a, b Filter) (same bool) {
+	t := reflect.TypeOf(a)
+	if t != reflect.TypeOf(b) || !t.Comparable() {
+		return false
+	}
+
+	// comparable structs may still hold incomparable values in interface fields
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+
+	return a == b
+}
+
+// mapFilterOf returns the mapFilter of filters created using Map, or nil.
+func mapFilterOf(v interface{}) *mapFilter {
+	switch v := v.(type) {
+	case *mapFilter:
+		return v
+	case *closingMapFilter:
+		return v.mapFilter
+	}
+
+	return nil
+}
+
+// typedStage returns the mapFilter of stages created using MapT, or nil.
+func typedStage(f Filter) *mapFilter {
+	m := mapFilterOf(f)
+	if m == nil || m.in == nil {
+		return nil
+	}
+	return m
+}
+
+// endsOnItsOwn returns whether v is known to end without its input ending, e.g. because it is a Take.
+func endsOnItsOwn(v interface{}) bool {
+	var stages []Filter
+	switch v := v.(type) {
+	case *Pipeline:
+		stages = v.stages
+	default:
+		m := mapFilterOf(v)
+		return m != nil && m.ends
+	}
+
+	for _, stage := range stages {
+		if endsOnItsOwn(stage) {
+			return true
+		}
+	}
+	return false
+}
+
+// accepts returns whether events of type out may be events of type in.
+func accepts(in, out reflect.Type) bool {
+	if out.AssignableTo(in) {
+		return true
+	}
+
+	// an interface may hold a value of the concrete type
+	return out.Kind() == reflect.Interface && (in.Implements(out) || in.Kind() == reflect.Interface)
+}
+
+// DryRun passes events through the stages of p and returns what the last stage emitted, to check the wiring
+// before the source is started. It waits until the last stage was idle for idle, or ctx is done. The stages
+// keep the state the events left them in, so use a pipeline built for the dry run, and don't pass End.
+func DryRun(ctx context.Context, p *Pipeline, idle time.Duration, events ...Event) ([]Event, error) {
+	var (
+		lock sync.Mutex
+		got  []Event
+	)
+
+	rctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	p.Register(rctx, ObserverFunc(func(ctx context.Context, e Event) {
+		lock.Lock()
+		defer lock.Unlock()
+		got = append(got, e)
+	}))
+
+	for _, e := range events {
+		p.stages[0].OnEvent(ctx, e)
+	}
+
+	err := Quiesce(ctx, p, idle)
+
+	lock.Lock()
+	defer lock.Unlock()
+	return got, err
+}
@@ -0,0 +1,100 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func ExampleValidate() {
+	ctx := context.Background()
+	_, o := Pair()
+
+	parse := MapT(func(ctx context.Context, e stringEvent) (intEvent, bool) {
+		i, err := strconv.Atoi(string(e))
+		return intEvent(i), err == nil
+	})
+	upper := MapT(func(ctx context.Context, e stringEvent) (stringEvent, bool) {
+		return e, true
+	})
+
+	fmt.Println(Validate(NewPipeline(ctx, o, parse, upper)))
+
+	// Output:
+	// voyeur: invalid pipeline: stage 1 takes voyeur.stringEvent, but stage 0 emits voyeur.intEvent
+}
+
+func ExampleDryRun() {
+	ctx := context.Background()
+	_, o := Pair()
+
+	double := MapT(func(ctx context.Context, e intEvent) (intEvent, bool) {
+		return e * 2, true
+	})
+	p := NewPipeline(ctx, o, double, Take(2))
+
+	got, err := DryRun(ctx, p, 10*time.Millisecond, intEvent(1), intEvent(2), intEvent(3))
+	fmt.Println(got, err)
+
+	// Output:
+	// [2 4 End] <nil>
+}
+
+func TestValidateUnendedSource(t *testing.T) {
+	ctx := context.Background()
+	_, o := Pair()
+
+	sum := func(s int, e Event) int { return s + int(e.(intEvent)) }
+
+	if err := Validate(NewPipeline(ctx, o, Reduce(0, sum))); err == nil {
+		t.Error("Reduce on a source that doesn't end on its own passed")
+	}
+
+	if err := Validate(NewPipeline(ctx, o, Take(3), Reduce(0, sum))); err != nil {
+		t.Errorf("Reduce after Take failed: %v", err)
+	}
+}
+
+func TestValidateFuncStage(t *testing.T) {
+	ctx := context.Background()
+	_, o := Pair()
+
+	// neither stage can be used as a map key
+	_, inner := Pair()
+	f := NewFilter(inner, ObserverFunc(func(context.Context, Event) {}))
+	fn := filterFunc(func(ctx context.Context, e Event) {})
+
+	if err := Validate(NewPipeline(ctx, o, f, fn)); err != nil {
+		t.Errorf("unexpected problems: %v", err)
+	}
+
+	tap := Tap(func(context.Context, Event) {})
+	if err := Validate(NewPipeline(ctx, o, tap, f, tap)); err == nil {
+		t.Error("repeated stage not reported")
+	}
+}
+
+// filterFunc is a Filter of func type, which can't be compared.
+type filterFunc func(context.Context, Event)
+
+func (f filterFunc) OnEvent(ctx context.Context, e Event) { f(ctx, e) }
+func (f filterFunc) Register(context.Context, Observer)   {}
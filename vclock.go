@@ -0,0 +1,182 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// VectorClock maps nodes to the number of events they emitted that are known to a node.
+type VectorClock map[string]uint64
+
+// Causality is how two vector clocks relate.
+type Causality int
+
+const (
+	// Concurrent clocks don't know of each other.
+	Concurrent Causality = iota
+	// HappenedBefore means the first clock is known to the second.
+	HappenedBefore
+	// HappenedAfter means the second clock is known to the first.
+	HappenedAfter
+	// Identical clocks are equal.
+	Identical
+)
+
+func (c Causality) String() string {
+	switch c {
+	case HappenedBefore:
+		return "happened before"
+	case HappenedAfter:
+		return "happened after"
+	case Identical:
+		return "identical"
+	default:
+		return "concurrent"
+	}
+}
+
+// Compare returns how vc relates to other.
+func (vc VectorClock) Compare(other VectorClock) Causality {
+	less, greater := false, false
+	for node, n := range vc {
+		switch m := other[node]; {
+		case n < m:
+			less = true
+		case n > m:
+			greater = true
+		}
+	}
+	for node, m := range other {
+		if _, ok := vc[node]; !ok && m > 0 {
+			less = true
+		}
+	}
+
+	switch {
+	case less && greater:
+		return Concurrent
+	case less:
+		return HappenedBefore
+	case greater:
+		return HappenedAfter
+	default:
+		return Identical
+	}
+}
+
+// Merge sets each entry of vc to the maximum of its value and the one in other.
+func (vc VectorClock) Merge(other VectorClock) {
+	for node, m := range other {
+		if m > vc[node] {
+			vc[node] = m
+		}
+	}
+}
+
+// Copy returns a copy of vc.
+func (vc VectorClock) Copy() VectorClock {
+	cp := make(VectorClock, len(vc))
+	for node, n := range vc {
+		cp[node] = n
+	}
+
+	return cp
+}
+
+// VectorTimestamp is the vector clock of an event and the node that emitted it.
+type VectorTimestamp struct {
+	Node  string      `json:"node"`
+	Clock VectorClock `json:"clock"`
+}
+
+// vectorState is the vector clock of a stream created WithVectorClock.
+type vectorState struct {
+	node string
+
+	lock  sync.Mutex
+	clock VectorClock
+}
+
+// stamp counts env as an event of the node and sets its timestamp. The clock of an envelope that was
+// stamped by another node is merged first, so the new timestamp comes after it.
+func (s *vectorState) stamp(env Envelope) Envelope {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.clock == nil {
+		s.clock = make(VectorClock)
+	}
+	s.clock.Merge(env.Vector.Clock)
+	s.clock[s.node]++
+
+	env.Vector = VectorTimestamp{Node: s.node, Clock: s.clock.Copy()}
+	return env
+}
+
+// VectorOf returns the vector timestamp of e if it is an Envelope stamped by a stream created WithVectorClock.
+func VectorOf(e Event) (VectorTimestamp, bool) {
+	env, ok := e.(Envelope)
+	return env.Vector, ok && env.Vector.Node != ""
+}
+
+// CausalityError reports an event that was observed after an event that it happened before.
+type CausalityError struct {
+	// Event is the late event.
+	Event Event
+
+	// Seen is the merged vector clock of the events observed before it.
+	Seen VectorClock
+}
+
+func (err *CausalityError) Error() string {
+	ts, _ := VectorOf(err.Event)
+	return fmt.Sprintf("voyeur: causality violated: event %d of %s observed after %v", ts.Clock[ts.Node], ts.Node, err.Seen)
+}
+
+// CheckCausality returns a Filter that passes on all events and calls onError with an ErrEvent wrapping a
+// *CausalityError for each event that was observed after an event that depends on it, or after a later event
+// of the same node. Only events stamped by streams created WithVectorClock are checked.
+// onError may be ErrorsTo, to collect the violations on a stream.
+func CheckCausality(onError func(error), opts ...Option) Filter {
+	var (
+		lock sync.Mutex
+		seen = make(VectorClock)
+	)
+
+	return Map(func(ctx context.Context, em Emitter, e Event) {
+		if ts, ok := VectorOf(e); ok {
+			lock.Lock()
+			late := seen[ts.Node] >= ts.Clock[ts.Node]
+			var snapshot VectorClock
+			if late {
+				snapshot = seen.Copy()
+			}
+			seen.Merge(ts.Clock)
+			lock.Unlock()
+
+			if late && onError != nil {
+				onError(NewErrEvent(&CausalityError{Event: e, Seen: snapshot}, e))
+			}
+		}
+
+		em.Emit(ctx, e)
+	}, opts...)
+}
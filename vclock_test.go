@@ -0,0 +1,82 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestVectorClockCompare(t *testing.T) {
+	a := VectorClock{"a": 1}
+	ab := VectorClock{"a": 1, "b": 1}
+	b := VectorClock{"b": 1}
+
+	for _, tc := range []struct {
+		x, y VectorClock
+		exp  Causality
+	}{
+		{a, ab, HappenedBefore},
+		{ab, a, HappenedAfter},
+		{a, b, Concurrent},
+		{ab, ab.Copy(), Identical},
+		{VectorClock{}, nil, Identical},
+	} {
+		if got := tc.x.Compare(tc.y); got != tc.exp {
+			t.Errorf("%v compared to %v: expected %v, got %v", tc.x, tc.y, tc.exp, got)
+		}
+	}
+}
+
+func ExampleCheckCausality() {
+	ctx := context.Background()
+
+	emA, oA := Pair(WithVectorClock("a"))
+	emB, oB := Pair(WithVectorClock("b"))
+
+	var stamped []Event
+	record := ObserverFunc(func(ctx context.Context, e Event) {
+		stamped = append(stamped, e)
+	})
+	oA.Register(ctx, record)
+	oB.Register(ctx, record)
+
+	// b forwards the question of a, then emits an event of its own
+	emA.Emit(ctx, stringEvent("question"))
+	emB.Emit(ctx, stamped[0])
+	emB.Emit(ctx, stringEvent("unrelated"))
+
+	// the reply overtakes the question on its way to the checker
+	em, o := Pair()
+	check := CheckCausality(func(err error) {
+		var ce *CausalityError
+		if errors.As(err, &ce) {
+			fmt.Println("late:", ce.Event.(Envelope).Event)
+		}
+	})
+	o.Register(ctx, check)
+
+	for _, i := range []int{1, 0, 2} {
+		em.Emit(ctx, stamped[i])
+	}
+
+	// Output:
+	// late: question
+}
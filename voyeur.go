@@ -19,10 +19,10 @@ package voyeur
 
 import (
 	"context"
-	"fmt"
 	"reflect"
-	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 func init() {
@@ -61,149 +61,428 @@ type Observable interface {
 	Register(context.Context, Observer)
 }
 
-// FilterBuilder is a function taking an arbitrary number of inputs and returns a Filter
-type FilterBuilder struct {
-	v interface{}
+// ObservableFunc is an observable that calls the function for each registered observer
+type ObservableFunc func(context.Context, Observer)
+
+func (o ObservableFunc) Register(ctx context.Context, oer Observer) {
+	o(ctx, oer)
 }
 
-func NewFilterBuilder(f interface{}) *FilterBuilder {
-	return &FilterBuilder{f}
+// Filter is bot observer and observant. A generalization of map, filter and reduce.
+type Filter interface {
+	Observable
+	Observer
 }
 
-// Valid returns whether FilterBuilder is a valid FilterBuilder
-func (fb *FilterBuilder) Valid() bool {
-	t := reflect.TypeOf(fb.v)
-	if t.Kind() != reflect.Func {
-		return false
-	}
+type filter struct {
+	Observable
+	Observer
+}
 
-	if t.NumOut() != 1 {
-		return false
-	}
+// NewFilter constructs a Filter from an observable and an observer.
+func NewFilter(o Observable, oer Observer) Filter {
+	return filter{Observable: o, Observer: oer}
+}
 
-	if t.Out(0) != reflect.TypeOf(Filter(nil)) {
-		return false
-	}
+// Emitter lets you send events. Emit may be called from many goroutines at once.
+type Emitter interface {
+	Emit(context.Context, Event)
+	End(context.Context)
+}
+
+// EmitterFunc is an emitter that calls the function for each event, including End
+type EmitterFunc func(context.Context, Event)
+
+func (em EmitterFunc) Emit(ctx context.Context, e Event) {
+	em(ctx, e)
+}
+
+func (em EmitterFunc) End(ctx context.Context) {
+	em(ctx, End)
+}
+
+type observable struct {
+	cfg       config
+	done      chan struct{}
+	queue     chan delivery
+	lock      sync.Mutex
+	observers []*registration
+
+	// deliverLock serializes deliveries, which call the observers of synchronous streams without holding lock
+	deliverLock sync.Mutex
+
+	// ending is set when End was emitted with WithPreemptiveEnd, so queued events are discarded
+	ending atomic.Bool
+
+	// ended is set when End was emitted
+	ended atomic.Bool
+
+	// running counts the delivery goroutines of async streams. drained is closed once they all returned after End.
+	running sync.WaitGroup
+	drained chan struct{}
+
+	// fair is the queue used instead of queue by streams created WithFairness
+	fair *fairQueue
+
+	// queued counts the events in queue or fair that weren't delivered yet. idle is signalled when it drops to zero.
+	queued int
+	idle   *sync.Cond
+
+	// trace is set for streams created WithTrace
+	trace *traceRing
+
+	// reports is set for streams created WithReports
+	reports *reporter
+
+	// vector is set for streams created WithVectorClock
+	vector *vectorState
+
+	// super calls the observers of streams created WithSupervisor
+	super *supervisor
+}
+
+type emitter observable
+
+// deliveryContext is cancelled with the embedded registration context, but has the values of the emit context.
+type deliveryContext struct {
+	context.Context
+	values context.Context
+}
 
-	return true
+func (ctx deliveryContext) Value(key interface{}) interface{} {
+	return ctx.values.Value(key)
 }
 
-type paramMismatchError struct {
-	t  reflect.Type
-	vs []interface{}
+// registration is a registered observer.
+type registration struct {
+	oer Observer
+
+	// mbox is the mailbox of the observer in async streams
+	mbox *mailbox
+
+	// stop stops watching the registration context
+	stop func() bool
+}
+
+// delivery is an event on its way to an observer.
+type delivery struct {
+	ctx context.Context
+	e   Event
+
+	// seq and sent are set for mailbox deliveries of streams created WithReports
+	seq  uint64
+	sent time.Time
+
+	// barrier is closed once all observers handled a barrier event, see WithBarrier
+	barrier chan struct{}
+	// acks counts the mailboxes that still have to handle a barrier event
+	acks *sync.WaitGroup
 }
 
-func newParamMismatchError(t reflect.Type, vs interface{}) error {
-	return paramMismatchError{
-		t:  t,
-		vs: vs.([]interface{}),
+// done marks d as handled by an observer or discarded.
+func (d delivery) done() {
+	if d.acks != nil {
+		d.acks.Done()
 	}
 }
 
-func (err paramMismatchError) Error() string {
-	gotTypeStrs := make([]string, len(err.vs))
-	expTypeStrs := make([]string, err.t.NumIn())
+func (o *observable) Register(ctx context.Context, oer Observer) {
+	if debugMode && oer == nil {
+		violation("Register called with nil Observer on %v", o)
+	}
+
+	if o.cfg.regCtx {
+		inner := oer
+		oer = ObserverFunc(func(emitCtx context.Context, e Event) {
+			inner.OnEvent(deliveryContext{Context: ctx, values: emitCtx}, e)
+		})
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
 
-	for i, v := range err.vs {
-		t := reflect.TypeOf(v)
-		gotTypeStrs[i] = fmt.Sprintf("%s.%s", t.PkgPath(), t.Name())
+	select {
+	case <-o.done:
+		// nothing will be delivered anymore
+		return
+	default:
 	}
 
-	for i := 0; i < err.t.NumIn(); i++ {
-		t := err.t.In(i)
-		gotTypeStrs[i] = fmt.Sprintf("%s.%s", t.PkgPath(), t.Name())
+	var mbox *mailbox
+	if o.cfg.async {
+		mbox = newMailbox(mailboxSize(o.cfg.buffer))
+		o.running.Add(1)
+		go o.runMailbox(mbox, oer)
 	}
 
-	return fmt.Sprintf("parameter mismatch error: got (%s), expected (%s)", strings.Join(gotTypeStrs, " "), strings.Join(expTypeStrs, " "))
+	reg := &registration{oer: oer, mbox: mbox}
+
+	// observers is copied on write, so deliveries can use it without holding the lock
+	o.observers = append(o.observers[:len(o.observers):len(o.observers)], reg)
+
+	// remove the observer when ctx is cancelled. This needs no goroutine, which matters on small targets.
+	reg.stop = context.AfterFunc(ctx, func() {
+		o.lock.Lock()
+		defer o.lock.Unlock()
+
+		for i, r := range o.observers {
+			if r == reg {
+				o.observers = append(o.observers[:i:i], o.observers[i+1:]...)
+				if mbox != nil {
+					mbox.close()
+				}
+				return
+			}
+		}
+	})
 }
 
-func (fb *FilterBuilder) Build(vs ...interface{}) (Filter, error) {
-	t := reflect.TypeOf(fb.v)
+// deliver passes e on to all observers, in the order they were registered in. Observers registered while e is
+// being delivered, e.g. by an observer of e, don't get e, but all events emitted after Register returned.
+// Barrier events are only passed on once all observers handled them.
+func (o *observable) deliver(d delivery) {
+	ctx, e := d.ctx, d.e
+
+	o.deliverLock.Lock()
+	defer o.deliverLock.Unlock()
+
+	o.lock.Lock()
+	regs := o.observers
+	if o.cfg.async && e == End {
+		// nothing follows End, so later registrations needn't get a mailbox. This also keeps them from
+		// missing End, which the mailboxes of regs get below.
+		o.end()
+	}
+	o.lock.Unlock()
+
+	if o.trace != nil {
+		n := len(regs)
+		defer func() {
+			if r := recover(); r != nil {
+				o.dumpPanic(e, n, r)
+				panic(r)
+			}
+
+			o.trace.add(e, n, TraceDelivered)
+		}()
+	}
 
-	if len(vs) != t.NumIn() {
-		return nil, newParamMismatchError(t, vs)
+	if d.barrier != nil {
+		defer close(d.barrier)
+		if o.cfg.async {
+			d.acks = new(sync.WaitGroup)
+			d.acks.Add(len(regs))
+		}
 	}
 
-	for i := range vs {
-		tv := reflect.TypeOf(vs[i])
-		tp := t.In(i)
-		if tv != tp {
-			return nil, newParamMismatchError(t, vs)
+	if o.reports != nil {
+		d.seq = o.reports.seq.Add(1)
+		if e == End {
+			o.reports.expectEnds(ctx, len(regs))
 		}
 	}
 
-	var rvs = make([]reflect.Value, len(vs))
+	for _, reg := range regs {
+		if reg.mbox == nil {
+			if o.super != nil {
+				o.super.observe(o, d, reg.oer)
+			} else {
+				o.observe(d, reg.oer)
+			}
+			continue
+		}
 
-	for i, v := range vs {
-		rvs[i] = reflect.ValueOf(v)
+		if o.reports != nil {
+			d.sent = o.cfg.clock.Now()
+		}
+		// the lock isn't held, so observers may register or be cancelled meanwhile.
+		// A mailbox closed by cancellation takes no more events.
+		if !reg.mbox.push(d) {
+			d.done()
+		}
+		if e == End {
+			reg.mbox.close()
+		}
 	}
 
-	// spread this over several lines, because each of these may, but shouldn't panic.
-	// the resulting stack trace will be more informative/easier to read.
-	out := reflect.ValueOf(fb.v).Call(rvs)
-	filterValue := out[0]
-	filterIface := filterValue.Interface()
+	if d.acks != nil {
+		d.acks.Wait()
+	}
 
-	return filterIface.(Filter), nil
+	if e == End && !o.cfg.async {
+		o.lock.Lock()
+		defer o.lock.Unlock()
+		o.end()
+	}
 }
 
-// Filter is bot observer and observant. A generalization of map, filter and reduce.
-type Filter interface {
-	Observable
-	Observer
+// end removes all observers after End and closes done. Mailboxes are closed by deliver once End is queued.
+// o.lock must be held.
+func (o *observable) end() {
+	// nothing follows End, so the contexts needn't be watched
+	for _, reg := range o.observers {
+		reg.stop()
+	}
+	o.observers = nil
+
+	close(o.done)
+	if o.super != nil {
+		o.super.stop()
+	}
+	go func() {
+		o.running.Wait()
+		close(o.drained)
+	}()
 }
 
-type filter struct {
-	Observable
-	Observer
+// runMailbox delivers the events in mbox to oer until it is closed.
+func (o *observable) runMailbox(mbox *mailbox, oer Observer) {
+	defer o.running.Done()
+
+	var cur Event
+	if o.trace != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				o.dumpPanic(cur, 1, r)
+				panic(r)
+			}
+		}()
+	}
+
+	for {
+		d, ok := mbox.pop()
+		if !ok {
+			return
+		}
+		if d.e != End && o.ending.Load() {
+			o.traceDiscarded(d.e)
+			d.done()
+			continue
+		}
+
+		cur = d.e
+		o.observe(d, oer)
+		d.done()
+	}
 }
 
-// NewFilter constructs a Filter from an observable and an observer.
-func NewFilter(o Observable, oer Observer) Filter {
-	return filter{Observable: o, Observer: oer}
+// run delivers queued events until End has been delivered.
+func (o *observable) run() {
+	for d := range o.queue {
+		if d.e != End && o.ending.Load() {
+			o.traceDiscarded(d.e)
+			if d.barrier != nil {
+				close(d.barrier)
+			}
+			o.dequeue()
+			continue
+		}
+		o.deliver(d)
+		if d.e == End {
+			// events emitted after End may be left in queue, but are never delivered
+			o.lock.Lock()
+			o.queued = 0
+			o.idle.Broadcast()
+			o.lock.Unlock()
+			return
+		}
+		o.dequeue()
+	}
 }
 
-// Emitter lets you send events
-type Emitter interface {
-	Emit(context.Context, Event)
-	End(context.Context)
+// enqueue counts an event that is put into queue or fair.
+func (em *emitter) enqueue() {
+	em.lock.Lock()
+	em.queued++
+	em.lock.Unlock()
 }
 
-type observable struct {
-	done      chan struct{}
-	lock      sync.Mutex
-	observers map[*Observer]struct{}
+// dequeue counts an event taken from queue or fair that was delivered or discarded.
+func (o *observable) dequeue() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.queued--
+	if o.queued == 0 {
+		o.idle.Broadcast()
+	}
 }
 
-type emitter observable
+// flush waits until all events emitted so far were delivered. It must not be called concurrently with Emit.
+func (o *observable) flush() {
+	if o.idle == nil {
+		return
+	}
 
-func (o *observable) Register(ctx context.Context, oer Observer) {
-	func() {
-		o.lock.Lock()
-		defer o.lock.Unlock()
-		o.observers[&oer] = struct{}{}
-	}()
-	go func() {
-		select {
-		case <-o.done:
-		case <-ctx.Done():
-			o.lock.Lock()
-			defer o.lock.Unlock()
-			delete(o.observers, &oer)
-		}
-	}()
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for o.queued > 0 {
+		o.idle.Wait()
+	}
 }
 
 func (em *emitter) Emit(ctx context.Context, e Event) {
-	em.lock.Lock()
-	defer em.lock.Unlock()
-
-	for o := range em.observers {
-		(*o).OnEvent(ctx, e)
+	if em.cfg.sealing {
+		e = seal(ctx, e, em.cfg.envelope, em.cfg.ids, em.cfg.hlc)
+		if env, ok := e.(Envelope); ok && em.vector != nil {
+			e = em.vector.stamp(env)
+		}
+		if env, ok := e.(Envelope); ok && em.cfg.lineage {
+			e, ctx = adopt(ctx, env)
+		}
+	}
+	if debugMode && e != End && em.ended.Load() {
+		violation("%v emitted after End on %v", e, em)
 	}
 
 	if e == End {
-		close(em.done)
+		if !em.ended.CompareAndSwap(false, true) {
+			if em.trace != nil {
+				em.trace.add(e, 0, TraceDoubleEnd)
+			}
+			if em.cfg.doubleEnd != nil {
+				em.cfg.doubleEnd(ctx)
+			}
+			return
+		}
+
+		if em.cfg.preempt {
+			em.ending.Store(true)
+		}
+	}
+
+	d := delivery{ctx: ctx, e: e}
+	if e != End && em.cfg.barriers[e.EventType()] {
+		d.barrier = make(chan struct{})
+	}
+
+	switch {
+	case em.fair != nil:
+		em.enqueue()
+		if !em.fair.push(producerFrom(ctx), d) {
+			// dropped after End, so nobody will handle a barrier event
+			(*observable)(em).dequeue()
+			return
+		}
+	case em.queue == nil:
+		(*observable)(em).deliver(d)
+		return
+	default:
+		em.enqueue()
+		select {
+		case em.queue <- d:
+		case <-em.done:
+			(*observable)(em).dequeue()
+			return
+		}
+	}
+
+	if d.barrier != nil {
+		select {
+		case <-d.barrier:
+		case <-ctx.Done():
+		}
 	}
 }
 
@@ -211,11 +490,70 @@ func (em *emitter) End(ctx context.Context) {
 	em.Emit(ctx, End)
 }
 
+// Name returns the name set using WithName.
+func (o *observable) Name() string {
+	return o.cfg.name
+}
+
+func (o *observable) String() string {
+	return nameOr(o.cfg.name, "observable", o)
+}
+
+func (o *observable) Drained() <-chan struct{} {
+	return o.drained
+}
+
+func (em *emitter) Drained() <-chan struct{} {
+	return em.drained
+}
+
+// Name returns the name set using WithName.
+func (em *emitter) Name() string {
+	return em.cfg.name
+}
+
+func (em *emitter) String() string {
+	return nameOr(em.cfg.name, "emitter", em)
+}
+
 // Pair returns an Emitter and corresponding Observable. Events emitted on one can be observed on the other.
-func Pair() (Emitter, Observable) {
+// By default, Emit calls all observers before returning; see the Options for other delivery modes.
+// Observers get each event in the order they were registered in. An observer registered while an event is
+// being delivered, e.g. from within OnEvent, doesn't get that event, but all that are delivered after
+// Register returned.
+func Pair(opts ...Option) (Emitter, Observable) {
 	o := &observable{
-		done:      make(chan struct{}),
-		observers: make(map[*Observer]struct{}),
+		cfg:     newConfig(opts),
+		done:    make(chan struct{}),
+		drained: make(chan struct{}),
+	}
+
+	if o.cfg.reports {
+		o.reports = newReporter(o.cfg.clock)
+	}
+	if o.cfg.supervise && !o.cfg.async {
+		o.super = newSupervisor(o.cfg.recover)
+	}
+	if o.cfg.vector != "" {
+		o.vector = &vectorState{node: o.cfg.vector}
+	}
+	if o.cfg.trace > 0 {
+		o.trace = newTraceRing(o.cfg.trace, o.cfg.clock)
+	}
+
+	switch {
+	case o.cfg.fair:
+		max := o.cfg.buffer
+		if max < 1 {
+			max = 1
+		}
+		o.fair = newFairQueue(max)
+		o.idle = sync.NewCond(&o.lock)
+		go o.runFair()
+	case o.cfg.buffer > 0 && !o.cfg.async:
+		o.queue = make(chan delivery, o.cfg.buffer)
+		o.idle = sync.NewCond(&o.lock)
+		go o.run()
 	}
 
 	em := (*emitter)(o)
@@ -239,25 +577,68 @@ func (e simpleEvent) Context() context.Context {
 }
 
 type mapFilter struct {
-	o  Observable
+	o  *observable
 	em Emitter
 
 	f func(context.Context, Emitter, Event)
+
+	// in and out are the event types of filters created using MapT, see Validate
+	in, out reflect.Type
+
+	// ends is set for filters that end on their own, like Take, and waits for filters that only emit once
+	// their input ended, like Reduce, see Validate
+	ends, waits bool
 }
 
-func Map(f func(context.Context, Emitter, Event)) Filter {
-	em, o := Pair()
-	return &mapFilter{
-		o:  o,
+// Map returns a Filter that calls f for each event it observes. f can use the passed Emitter to emit events to the observers of the filter.
+// When End is observed, f is called with it and the filter ends afterwards, unless WithManualEnd is passed.
+// The options configure the Emitter.
+func Map(f func(context.Context, Emitter, Event), opts ...Option) Filter {
+	em, o := Pair(opts...)
+	m := &mapFilter{
+		o:  o.(*observable),
 		em: em,
 		f:  f,
 	}
+
+	if m.o.cfg.close != nil {
+		return &closingMapFilter{mapFilter: m}
+	}
+	return m
+}
+
+// closingMapFilter is a mapFilter created WithClose.
+type closingMapFilter struct {
+	*mapFilter
+	once sync.Once
+	err  error
+}
+
+func (m *closingMapFilter) Close() error {
+	m.once.Do(func() {
+		m.err = m.o.cfg.close()
+	})
+
+	return m.err
 }
 
 func (m *mapFilter) OnEvent(ctx context.Context, e Event) {
 	m.f(ctx, m.em, e)
+
+	if e == End && !m.o.cfg.manualEnd && !m.o.ended.Load() {
+		m.em.End(ctx)
+	}
 }
 
 func (m *mapFilter) Register(ctx context.Context, oer Observer) {
 	m.o.Register(ctx, oer)
 }
+
+// Name returns the name set using WithName.
+func (m *mapFilter) Name() string {
+	return m.o.Name()
+}
+
+func (m *mapFilter) String() string {
+	return nameOr(m.o.Name(), "map", m)
+}
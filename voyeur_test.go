@@ -20,6 +20,8 @@ package voyeur
 import (
 	"context"
 	"fmt"
+	"sync"
+	"testing"
 	"time"
 )
 
@@ -41,102 +43,159 @@ func (e stringEvent) String() string {
 	return string(e)
 }
 
-func Example() {
-	// build a context we can cancel
+func TestDoubleEnd(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"sync":     nil,
+		"buffered": {WithBuffer(4)},
+		"async":    {WithAsync()},
+		"fair":     {WithFairness()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			doubled := make(chan struct{}, 1)
+			em, o := Pair(append(opts, WithDoubleEndHook(func(ctx context.Context) {
+				doubled <- struct{}{}
+			}))...)
+
+			ends := make(chan struct{}, 2)
+			o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+				if e == End {
+					ends <- struct{}{}
+				}
+			}))
+
+			em.End(ctx)
+			em.End(ctx)
+
+			select {
+			case <-doubled:
+			default:
+				t.Error("double End hook not called")
+			}
+
+			<-ends
+			select {
+			case <-ends:
+				t.Error("End delivered twice")
+			case <-time.After(10 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestDeliveryOrder(t *testing.T) {
 	ctx := context.Background()
-	ctx, cancel := context.WithCancel(ctx)
 	em, o := Pair()
 
-	// create and register a very simple observer. It just prints events to stdout.
-	printer := printObserver{}
-	o.Register(ctx, printer)
-
-	// emit some events.
-	em.Emit(ctx, stringEvent("test"))
-	em.Emit(ctx, stringEvent("foo"))
-
-	// cancel the observation. This only affects observers that use the context returned by context.WithCancel(ctx)
-	cancel()
-	time.Sleep(time.Millisecond) // kick scheduler, otherwise the observer will not be removed before we emit the next event
-
-	// this event is not seen by the observer anymore, because we cancelled the observation before.
-	em.Emit(ctx, stringEvent("bar"))
-
-	// let's reregister, this time without being able to cancel
-	ctx = context.Background()
-	o.Register(ctx, printer)
-
-	em.End(ctx)
+	var got []int
+	for i := 0; i < 10; i++ {
+		i := i
+		o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+			got = append(got, i)
+		}))
+	}
 
-	// Output:
-	// test
-	// foo
-	// End
+	em.Emit(ctx, stringEvent("a"))
+	if fmt.Sprint(got) != "[0 1 2 3 4 5 6 7 8 9]" {
+		t.Errorf("observers not called in registration order: %v", got)
+	}
 }
 
-func ExampleFilter() {
-	var strEv stringEvent
+func TestRegisterDuringEmit(t *testing.T) {
 	ctx := context.Background()
+	em, o := Pair()
 
-	m := Map(func(ctx context.Context, em Emitter, e Event) {
-		strEv += e.(stringEvent)
-		em.Emit(ctx, strEv)
-	})
+	var (
+		lock sync.Mutex
+		seen = make(map[string][]Event)
+	)
+	record := func(name string) Observer {
+		return ObserverFunc(func(ctx context.Context, e Event) {
+			lock.Lock()
+			defer lock.Unlock()
+			seen[name] = append(seen[name], e)
+		})
+	}
 
-	em, o := Pair()
+	started, release := make(chan struct{}), make(chan struct{})
+	o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+		if e != stringEvent("a") {
+			return
+		}
 
-	o.Register(ctx, m)
-	m.Register(ctx, printObserver{})
+		// registering from within OnEvent doesn't deadlock
+		o.Register(ctx, record("nested"))
 
-	em.Emit(ctx, stringEvent("a"))
-	em.Emit(ctx, stringEvent("b"))
-	em.Emit(ctx, stringEvent("c"))
+		close(started)
+		<-release
+	}))
 
-	// Output:
-	// a
-	// ab
-	// abc
-}
+	go func() {
+		<-started
+		// registering from another goroutine doesn't wait for the delivery
+		o.Register(ctx, record("concurrent"))
+		close(release)
+	}()
 
-func ExampleFilterBuilder() {
-	fb := NewFilterBuilder(
-		func(l int) Filter {
-			return Map(func(ctx context.Context, em Emitter, e Event) {
-				if se, ok := e.(stringEvent); ok && len(se) == l {
-					em.Emit(ctx, e)
-				}
-			})
-		})
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("b"))
 
-	// only forward events of length 4
-	m, err := fb.Build(4)
-	if err != nil {
-		fmt.Println(err)
-		return
+	for _, name := range []string{"nested", "concurrent"} {
+		if fmt.Sprint(seen[name]) != "[b]" {
+			t.Errorf("expected %s observer to see only b, got %v", name, seen[name])
+		}
 	}
+}
 
+func TestRegisterDuringAsyncEmit(t *testing.T) {
 	ctx := context.Background()
+	em, o := Pair(WithAsync(), WithBuffer(1))
+
+	nested := make(chan Event, 4)
+	regCtx, cancel := context.WithCancel(ctx)
+	registered := make(chan struct{})
+	o.Register(regCtx, ObserverFunc(func(ctx context.Context, e Event) {
+		if e != intEvent(0) {
+			return
+		}
+
+		// let Emit fill the mailbox and block on it
+		time.Sleep(10 * time.Millisecond)
+
+		o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+			nested <- e
+		}))
+		cancel()
+		close(registered)
+	}))
+
+	emitted := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			em.Emit(ctx, intEvent(i))
+		}
+		close(emitted)
+	}()
+
+	select {
+	case <-registered:
+	case <-time.After(time.Second):
+		t.Fatal("registering from within OnEvent deadlocked")
+	}
 
-	em, o := Pair()
-
-	o.Register(ctx, m)
-	m.Register(ctx, printObserver{})
-
-	em.Emit(ctx, stringEvent("1"))
-	em.Emit(ctx, stringEvent("12"))
-	em.Emit(ctx, stringEvent("123"))
-	em.Emit(ctx, stringEvent("1234"))
-	em.Emit(ctx, stringEvent("12345"))
-	em.Emit(ctx, stringEvent("123456"))
+	<-emitted
+	em.End(ctx)
+	if err := Drain(ctx, o); err != nil {
+		t.Fatal(err)
+	}
 
-	em.Emit(ctx, stringEvent("a"))
-	em.Emit(ctx, stringEvent("ab"))
-	em.Emit(ctx, stringEvent("abc"))
-	em.Emit(ctx, stringEvent("abcd"))
-	em.Emit(ctx, stringEvent("abcde"))
-	em.Emit(ctx, stringEvent("abcdef"))
-
-	// Output:
-	// 1234
-	// abcd
+	close(nested)
+	var got []Event
+	for e := range nested {
+		got = append(got, e)
+	}
+	if len(got) == 0 || got[len(got)-1] != End {
+		t.Errorf("expected nested observer to get End, got %v", got)
+	}
 }
@@ -0,0 +1,92 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+Package voyeurtest helps testing code that uses voyeur.
+*/
+package voyeurtest
+
+import (
+	"context"
+	"sync"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+type registration struct {
+	ctx context.Context
+	oer voyeur.Observer
+}
+
+type syncObservable struct {
+	o    voyeur.Observable
+	once sync.Once
+
+	lock  sync.Mutex
+	regs  []registration
+	ended bool
+}
+
+// Sync wraps o so that registration, cancellation and delivery are deterministic, which voyeur doesn't promise:
+//   - observers get the events in the order they were registered in,
+//   - an observer gets no event once its context is cancelled, without waiting for the scheduler, and
+//   - an observer registered after End gets End right away.
+//
+// Events are still delivered on the goroutine o delivers them on, so wrap a synchronous stream to have Emit
+// return after all observers handled the event. That way examples and tests don't need to sleep.
+func Sync(o voyeur.Observable) voyeur.Observable {
+	return &syncObservable{o: o}
+}
+
+func (s *syncObservable) Register(ctx context.Context, oer voyeur.Observer) {
+	s.once.Do(func() {
+		s.o.Register(context.Background(), voyeur.ObserverFunc(s.deliver))
+	})
+
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		oer.OnEvent(ctx, voyeur.End)
+		return
+	}
+	s.regs = append(s.regs, registration{ctx: ctx, oer: oer})
+	s.lock.Unlock()
+}
+
+func (s *syncObservable) deliver(ctx context.Context, e voyeur.Event) {
+	s.lock.Lock()
+	live := s.regs[:0]
+	for _, r := range s.regs {
+		if r.ctx.Err() == nil {
+			live = append(live, r)
+		}
+	}
+	regs := append([]registration(nil), live...)
+	s.regs = live
+	if e == voyeur.End {
+		s.ended = true
+		s.regs = nil
+	}
+	s.lock.Unlock()
+
+	// observers may register and cancel while we deliver
+	for _, r := range regs {
+		if r.ctx.Err() == nil {
+			r.oer.OnEvent(ctx, e)
+		}
+	}
+}
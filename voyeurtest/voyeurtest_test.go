@@ -0,0 +1,62 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeurtest
+
+import (
+	"context"
+	"fmt"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+type word string
+
+func (word) EventType() string {
+	return "word"
+}
+
+func printer(name string) voyeur.Observer {
+	return voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		fmt.Println(name, e)
+	})
+}
+
+func ExampleSync() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	em, o := voyeur.Pair()
+	o = Sync(o)
+
+	o.Register(ctx, printer("first"))
+	o.Register(context.Background(), printer("second"))
+	em.Emit(ctx, word("hello"))
+
+	// no need to wait for the first observer to be removed
+	cancel()
+	em.Emit(ctx, word("bye"))
+	em.End(ctx)
+
+	o.Register(context.Background(), printer("late"))
+
+	// Output:
+	// first hello
+	// second hello
+	// second bye
+	// second End
+	// late End
+}
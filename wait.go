@@ -0,0 +1,112 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// WaitAll returns a channel that is closed once each of os has ended. Use it to sequence the shutdown of
+// pipelines with several stages. If ctx is cancelled first, the channel is never closed.
+// nil Observables count as ended.
+func WaitAll(ctx context.Context, os ...Observable) <-chan struct{} {
+	var live []Observable
+	for _, o := range os {
+		if o != nil {
+			live = append(live, o)
+		}
+	}
+	os = live
+
+	done := make(chan struct{})
+	if len(os) == 0 {
+		close(done)
+		return done
+	}
+
+	var left atomic.Int64
+	left.Store(int64(len(os)))
+	for _, o := range os {
+		var once sync.Once
+		ended := func() {
+			once.Do(func() {
+				if left.Add(-1) == 0 {
+					close(done)
+				}
+			})
+		}
+
+		// registering on a stream that already ended does nothing, so streams that know when they ended are watched directly
+		if e, ok := o.(ender); ok {
+			go func() {
+				select {
+				case <-e.doneChan():
+					ended()
+				case <-ctx.Done():
+				}
+			}()
+			continue
+		}
+
+		o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+			if e == End {
+				ended()
+			}
+		}))
+	}
+
+	return done
+}
+
+// ender is implemented by the Observables returned by Pair.
+type ender interface {
+	// doneChan returns a channel that is closed once End was emitted.
+	doneChan() <-chan struct{}
+}
+
+func (o *observable) doneChan() <-chan struct{} {
+	return o.done
+}
+
+// Drainer is a stream that can tell when all its observers handled End. Streams returned by Pair are Drainers,
+// both the Emitter and the Observable.
+type Drainer interface {
+	// Drained returns a channel that is closed once End was delivered to all observers and they returned.
+	Drained() <-chan struct{}
+}
+
+// Drain waits until o ended and all of its observers handled End, which for async streams means that their
+// queues are empty. If o is not a Drainer, it only waits for o to end, like WaitAll.
+// It returns the error of ctx if it is cancelled first.
+func Drain(ctx context.Context, o Observable) error {
+	var drained <-chan struct{}
+	if d, ok := o.(Drainer); ok {
+		drained = d.Drained()
+	} else {
+		drained = WaitAll(ctx, o)
+	}
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,89 @@
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package voyeur
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ExampleWaitAll() {
+	ctx := context.Background()
+
+	em1, o1 := Pair()
+	em2, o2 := Pair()
+	done := WaitAll(ctx, o1, o2)
+
+	em1.End(ctx)
+	select {
+	case <-done:
+		fmt.Println("done too early")
+	default:
+		fmt.Println("waiting")
+	}
+
+	em2.End(ctx)
+	<-done
+	fmt.Println("all ended")
+
+	// Output:
+	// waiting
+	// all ended
+}
+
+func TestWaitAllEnded(t *testing.T) {
+	ctx := context.Background()
+
+	em, o := Pair()
+	em.End(ctx)
+
+	select {
+	case <-WaitAll(ctx, o):
+	case <-time.After(time.Second):
+		t.Fatal("WaitAll did not return for a stream that already ended")
+	}
+}
+
+func ExampleDrain() {
+	ctx := context.Background()
+	em, o := Pair(WithAsync(), WithBuffer(16))
+
+	var handled atomic.Int32
+	for i := 0; i < 3; i++ {
+		o.Register(ctx, ObserverFunc(func(ctx context.Context, e Event) {
+			time.Sleep(time.Millisecond)
+			handled.Add(1)
+		}))
+	}
+
+	em.Emit(ctx, stringEvent("a"))
+	em.Emit(ctx, stringEvent("b"))
+	em.End(ctx)
+
+	// End returns right away, Drain waits for the observers to catch up
+	if err := Drain(ctx, o); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(handled.Load())
+
+	// Output:
+	// 9
+}
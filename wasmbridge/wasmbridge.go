@@ -0,0 +1,106 @@
+//go:build js && wasm
+
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+Package wasmbridge connects voyeur streams to JavaScript events, for Go programs compiled to js/wasm.
+
+Listen turns DOM events and custom events dispatched on an EventTarget into voyeur events, and Sink dispatches
+voyeur events as CustomEvents, so JavaScript code can handle them with addEventListener. By default, events
+cross the boundary as DataEvents carrying the JSON-compatible detail of the JavaScript event.
+*/
+package wasmbridge
+
+import (
+	"context"
+	"encoding/json"
+	"syscall/js"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+// Decoder turns a JavaScript event into a voyeur event. It returns false to drop the event.
+type Decoder func(js.Value) (voyeur.Event, bool)
+
+// Listen emits the events of the given type dispatched on target, e.g. js.Global().Get("document"), until ctx
+// is cancelled; then it removes its listener and ends em. If decode is nil, DecodeDetail is used.
+func Listen(ctx context.Context, target js.Value, typ string, em voyeur.Emitter, decode Decoder) {
+	if decode == nil {
+		decode = DecodeDetail
+	}
+
+	fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if e, ok := decode(args[0]); ok {
+			em.Emit(ctx, e)
+		}
+		return nil
+	})
+	target.Call("addEventListener", typ, fn)
+
+	context.AfterFunc(ctx, func() {
+		target.Call("removeEventListener", typ, fn)
+		fn.Release()
+		em.End(context.WithoutCancel(ctx))
+	})
+}
+
+// DecodeDetail returns a DataEvent with the type of the JavaScript event and its detail as data, as decoded by
+// encoding/json. DOM events without detail have nil data.
+func DecodeDetail(ev js.Value) (voyeur.Event, bool) {
+	de := voyeur.DataEvent{Type: ev.Get("type").String()}
+
+	detail := ev.Get("detail")
+	if detail.IsUndefined() || detail.IsNull() {
+		return de, true
+	}
+
+	s := js.Global().Get("JSON").Call("stringify", detail).String()
+	if err := json.Unmarshal([]byte(s), &de.Data); err != nil {
+		return nil, false
+	}
+
+	return de, true
+}
+
+// Sink is an Observer that dispatches events on an EventTarget as CustomEvents, with the EventType as type
+// and the event encoded as JSON as detail. End is not dispatched.
+type Sink struct {
+	Target js.Value
+
+	// OnError is called when an event can't be encoded. It may be nil.
+	OnError func(voyeur.Event, error)
+}
+
+// OnEvent dispatches e.
+func (s Sink) OnEvent(ctx context.Context, e voyeur.Event) {
+	if e == voyeur.End {
+		return
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		if s.OnError != nil {
+			s.OnError(e, err)
+		}
+		return
+	}
+
+	init := js.Global().Get("Object").New()
+	init.Set("detail", js.Global().Get("JSON").Call("parse", string(data)))
+	s.Target.Call("dispatchEvent", js.Global().Get("CustomEvent").New(e.EventType(), init))
+}
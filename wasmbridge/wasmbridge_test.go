@@ -0,0 +1,69 @@
+//go:build js && wasm
+
+/*
+   This file is part of voyeur.
+
+   voyeur is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   voyeur is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with voyeur.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package wasmbridge
+
+import (
+	"context"
+	"fmt"
+	"syscall/js"
+	"testing"
+
+	"cryptoscope.co/go/voyeur"
+)
+
+type click struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func (click) EventType() string {
+	return "click"
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	target := js.Global().Get("EventTarget").New()
+
+	em, o := voyeur.Pair()
+	var got []voyeur.Event
+	ended := make(chan struct{})
+	o.Register(context.Background(), voyeur.ObserverFunc(func(ctx context.Context, e voyeur.Event) {
+		got = append(got, e)
+		if e == voyeur.End {
+			close(ended)
+		}
+	}))
+
+	Listen(ctx, target, "click", em, nil)
+
+	// Go to JavaScript to Go
+	Sink{Target: target}.OnEvent(ctx, click{X: 1, Y: 2})
+	Sink{Target: target}.OnEvent(ctx, voyeur.End)
+
+	cancel()
+	<-ended
+
+	// not listening anymore
+	Sink{Target: target}.OnEvent(ctx, click{X: 3, Y: 4})
+
+	if fmt.Sprint(got) != "[click map[x:1 y:2] End]" {
+		t.Errorf("unexpected events %v", got)
+	}
+}